@@ -3,7 +3,11 @@ package main
 import (
 	"example.com/delivery-app/config"
 	"example.com/delivery-app/database"
+	"example.com/delivery-app/jobs"
+	"example.com/delivery-app/models"
+	"example.com/delivery-app/payments"
 	"example.com/delivery-app/routes"
+	"example.com/delivery-app/websocket"
 	"github.com/cloudinary/cloudinary-go/v2"
 	"log"
 	"net/http"
@@ -14,6 +18,7 @@ import (
 func main() {
 	// Init DB
 	config.LoadConfig()
+	payments.LoadProviders()
 	database.InitDB()
 	defer database.DB.Close()
 	if err := database.CreateDefaultAdmin(database.DB); err != nil {
@@ -31,7 +36,7 @@ func main() {
 	r.Use(func(c *gin.Context) {
 		c.Writer.Header().Set("Access-Control-Allow-Origin", "http://localhost:5173")
 		c.Writer.Header().Set("Access-Control-Allow-Credentials", "true")
-		c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+		c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, Idempotency-Key")
 		c.Writer.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
 
 		if c.Request.Method == "OPTIONS" {
@@ -42,8 +47,17 @@ func main() {
 		c.Next()
 	})
 
+	// websocket hub dùng chung cho routes và job nền
+	hub := websocket.NewHub(database.DB)
+	go hub.Run()
+
+	// job nền
+	models.StartTokenCleanUp(database.DB)
+	jobs.StartOrderExpiry(database.DB, hub)
+	jobs.StartIdempotencyCleanUp(database.DB)
+
 	// Setup routes (truyền DB vào nếu cần)
-	routes.SetupRoutes(r, database.DB, cld)
+	routes.SetupRoutes(r, database.DB, cld, hub)
 
 	// Run server
 	r.Run(":8080")
@@ -4,6 +4,8 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"strconv"
+	"time"
 
 	"github.com/joho/godotenv"
 )
@@ -15,7 +17,74 @@ type EmailConfig struct {
 	Port     string
 }
 
+// biểu phí giao hàng và vị trí cửa hàng
+type DeliveryConfig struct {
+	BaseFee               float64
+	PerKmFee              float64
+	FreeDeliveryThreshold float64
+	StoreLatitude         float64
+	StoreLongitude        float64
+}
+
+// đặt giao hàng theo khung giờ
+type ScheduleConfig struct {
+	LeadTime     time.Duration // đơn hẹn giờ chỉ hiện cho admin/shipper trước giờ giao khoảng này
+	MaxDaysAhead int           // đặt trước tối đa bao nhiêu ngày
+}
+
+// tự huỷ order pending chưa được nhận
+type OrderExpiryConfig struct {
+	PendingTimeout time.Duration // order pending quá lâu thì huỷ, 0 = tắt
+	Interval       time.Duration // chu kỳ quét
+	BatchSize      int           // số order tối đa mỗi lần quét
+}
+
+// Idempotency-Key của request tạo order/thanh toán
+type IdempotencyConfig struct {
+	ProcessingTimeout time.Duration // key đang xử lý quá lâu (server chết giữa chừng) thì cho dùng lại
+	Retention         time.Duration // key cũ hơn thì job dọn xoá đi
+}
+
+// cổng thanh toán online, cổng nào thiếu mã merchant thì không bật
+type PaymentConfig struct {
+	ReturnURL   string // trang của frontend khách được chuyển về sau khi thanh toán
+	MockEnabled bool   // bật cổng thanh toán giả, chỉ có tác dụng khi APP_ENV=development
+	MockSecret  string
+
+	VNPayTmnCode    string
+	VNPayHashSecret string
+	VNPayPayURL     string
+	VNPayAPIURL     string
+
+	MoMoPartnerCode string
+	MoMoAccessKey   string
+	MoMoSecretKey   string
+	MoMoEndpoint    string
+	MoMoIPNURL      string // URL public của POST /payments/webhook/momo
+
+	// tài khoản nhận chuyển khoản qua mã VietQR
+	BankBIN         string // mã BIN ngân hàng theo NAPAS, vd 970436 (Vietcombank)
+	BankAccountNo   string
+	BankAccountName string
+}
+
+// thông tin người bán in trên hoá đơn
+type InvoiceConfig struct {
+	SellerName    string
+	SellerTaxCode string  // mã số thuế
+	VATRate       float64 // thuế GTGT đã gồm trong giá bán, vd 0.08
+	FontDir       string  // thư mục chứa DejaVuSans.ttf để in tiếng Việt trong PDF
+}
+
 var (
+	AppEnv        string // development || production, mặc định production
+	Delivery      DeliveryConfig
+	Idempotency   IdempotencyConfig
+	Invoice       InvoiceConfig
+	OrderExpiry   OrderExpiryConfig
+	Payment       PaymentConfig
+	Schedule      ScheduleConfig
+	Location      = time.FixedZone("ICT", 7*60*60) // múi giờ của cửa hàng, đọc lại trong LoadConfig
 	CloudinaryURL string
 	Email         EmailConfig
 	JWTSecret     string
@@ -38,6 +107,7 @@ func LoadConfig() {
 		Host:     os.Getenv("SMTP_HOST"),
 		Port:     os.Getenv("SMTP_PORT"),
 	}
+	AppEnv = getEnv("APP_ENV", "production")
 	//cloudinary url
 	CloudinaryURL = os.Getenv("CLOUDINARY_URL")
 	fmt.Println(CloudinaryURL)
@@ -49,7 +119,105 @@ func LoadConfig() {
 	DBPort = os.Getenv("DB_PORT")
 	DBName = os.Getenv("DB_NAME")
 
+	Delivery = DeliveryConfig{
+		BaseFee:               getEnvFloat("DELIVERY_BASE_FEE", 15000),
+		PerKmFee:              getEnvFloat("DELIVERY_PER_KM_FEE", 5000),
+		FreeDeliveryThreshold: getEnvFloat("DELIVERY_FREE_THRESHOLD", 300000),
+		StoreLatitude:         getEnvFloat("STORE_LATITUDE", 21.028511),
+		StoreLongitude:        getEnvFloat("STORE_LONGITUDE", 105.804817),
+	}
+
+	Schedule = ScheduleConfig{
+		LeadTime:     getEnvDuration("SCHEDULE_LEAD_MINUTES", 60, time.Minute),
+		MaxDaysAhead: int(getEnvFloat("SCHEDULE_MAX_DAYS_AHEAD", 7)),
+	}
+	Location = loadLocation(os.Getenv("TIMEZONE"))
+
+	OrderExpiry = OrderExpiryConfig{
+		PendingTimeout: getEnvDuration("ORDER_PENDING_TIMEOUT_MINUTES", 30, time.Minute),
+		Interval:       getEnvDuration("ORDER_EXPIRY_INTERVAL_SECONDS", 60, time.Second),
+		BatchSize:      int(getEnvFloat("ORDER_EXPIRY_BATCH_SIZE", 100)),
+	}
+
+	Idempotency = IdempotencyConfig{
+		ProcessingTimeout: getEnvDuration("IDEMPOTENCY_PROCESSING_TIMEOUT_SECONDS", 300, time.Second),
+		Retention:         getEnvDuration("IDEMPOTENCY_RETENTION_HOURS", 24, time.Hour),
+	}
+
+	Payment = PaymentConfig{
+		ReturnURL:   os.Getenv("PAYMENT_RETURN_URL"),
+		MockEnabled: os.Getenv("PAYMENT_MOCK_ENABLED") == "true",
+		MockSecret:  os.Getenv("PAYMENT_MOCK_SECRET"),
+
+		VNPayTmnCode:    os.Getenv("VNPAY_TMN_CODE"),
+		VNPayHashSecret: os.Getenv("VNPAY_HASH_SECRET"),
+		VNPayPayURL:     getEnv("VNPAY_PAY_URL", "https://sandbox.vnpayment.vn/paymentv2/vpcpay.html"),
+		VNPayAPIURL:     getEnv("VNPAY_API_URL", "https://sandbox.vnpayment.vn/merchant_webapi/api/transaction"),
+
+		MoMoPartnerCode: os.Getenv("MOMO_PARTNER_CODE"),
+		MoMoAccessKey:   os.Getenv("MOMO_ACCESS_KEY"),
+		MoMoSecretKey:   os.Getenv("MOMO_SECRET_KEY"),
+		MoMoEndpoint:    getEnv("MOMO_ENDPOINT", "https://test-payment.momo.vn"),
+		MoMoIPNURL:      os.Getenv("MOMO_IPN_URL"),
+
+		BankBIN:         os.Getenv("VIETQR_BANK_BIN"),
+		BankAccountNo:   os.Getenv("VIETQR_ACCOUNT_NO"),
+		BankAccountName: os.Getenv("VIETQR_ACCOUNT_NAME"),
+	}
+
+	Invoice = InvoiceConfig{
+		SellerName:    getEnv("INVOICE_SELLER_NAME", "Delivery App"),
+		SellerTaxCode: os.Getenv("INVOICE_SELLER_TAX_CODE"),
+		VATRate:       getEnvFloat("INVOICE_VAT_RATE", 0.08),
+		FontDir:       getEnv("INVOICE_FONT_DIR", "/usr/share/fonts/truetype/dejavu"),
+	}
+
 	if JWTSecret == "" {
 		log.Fatal("❌ JWT_SECRET chưa được set trong .env")
 	}
 }
+
+// IsDevelopment cho biết server đang chạy local (APP_ENV=development)
+func IsDevelopment() bool {
+	return AppEnv == "development"
+}
+
+// đọc biến môi trường, dùng giá trị mặc định nếu không có
+func getEnv(key, def string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return def
+}
+
+// đọc biến môi trường kiểu số, dùng giá trị mặc định nếu không có hoặc sai định dạng
+func getEnvFloat(key string, def float64) float64 {
+	v := os.Getenv(key)
+	if v == "" {
+		return def
+	}
+	f, err := strconv.ParseFloat(v, 64)
+	if err != nil {
+		log.Printf("⚠️  %s không hợp lệ (%q), dùng mặc định %v", key, v, def)
+		return def
+	}
+	return f
+}
+
+// đọc khoảng thời gian tính theo unit (vd 0.5 phút), nhân trước rồi mới đổi sang Duration để không mất phần lẻ
+func getEnvDuration(key string, def float64, unit time.Duration) time.Duration {
+	return time.Duration(getEnvFloat(key, def) * float64(unit))
+}
+
+// múi giờ mặc định Asia/Ho_Chi_Minh, máy không có tzdata thì dùng UTC+7
+func loadLocation(name string) *time.Location {
+	if name == "" {
+		name = "Asia/Ho_Chi_Minh"
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		log.Printf("⚠️  Không load được múi giờ %q, dùng UTC+7", name)
+		return time.FixedZone("ICT", 7*60*60)
+	}
+	return loc
+}
@@ -13,7 +13,9 @@ var DB *sql.DB
 
 func InitDB() {
 
-	dsn := fmt.Sprintf("%s:%s@tcp(%s:%s)/%s?parseTime=true",
+	// session MySQL luôn chạy UTC (time_zone='+00:00', loc=UTC) để giờ đọc/ghi và
+	// date_format trong báo cáo không phụ thuộc múi giờ của server DB
+	dsn := fmt.Sprintf("%s:%s@tcp(%s:%s)/%s?parseTime=true&loc=UTC&time_zone=%%27%%2B00%%3A00%%27",
 		config.DBUser,
 		config.DBPass,
 		config.DBHost,
@@ -80,12 +80,17 @@ CREATE TABLE `Products` (
   `id` int NOT NULL AUTO_INCREMENT,
   `name` varchar(255) NOT NULL,
   `description` text,
+  `category` varchar(100) DEFAULT NULL,
   `price` decimal(10,2) NOT NULL,
   `qty_initial` int DEFAULT '0',
   `qty_sold` int DEFAULT '0',
+  `store_id` int DEFAULT NULL,
   `created_at` timestamp NULL DEFAULT CURRENT_TIMESTAMP,
   `updated_at` timestamp NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
   PRIMARY KEY (`id`),
+  KEY `fk_products_store` (`store_id`),
+  KEY `idx_products_category` (`category`),
+  CONSTRAINT `fk_products_store` FOREIGN KEY (`store_id`) REFERENCES `stores` (`id`) ON DELETE SET NULL,
   CONSTRAINT `Products_chk_1` CHECK ((`price` >= 0)),
   CONSTRAINT `Products_chk_2` CHECK ((`qty_initial` >= 0)),
   CONSTRAINT `Products_chk_3` CHECK ((`qty_sold` >= 0))
@@ -98,7 +103,7 @@ CREATE TABLE `Products` (
 
 LOCK TABLES `Products` WRITE;
 /*!40000 ALTER TABLE `Products` DISABLE KEYS */;
-INSERT INTO `Products` VALUES (16,'Nước Cam Ép','Nước cam tươi nguyên chất',15000.00,100,35,'2025-09-24 02:46:55','2025-09-24 09:46:55'),(17,'Nước Chanh Tươi','Nước chanh mát lạnh giải khát',12000.00,90,28,'2025-09-24 02:46:55','2025-09-24 09:46:55'),(19,'Sữa Tươi','Sữa tươi tiệt trùng nguyên chất',18000.00,80,30,'2025-09-24 02:46:55','2025-09-24 09:46:55'),(20,'Sữa Đậu Nành','Thức uống từ đậu nành bổ dưỡng',12000.00,90,22,'2025-09-24 02:46:55','2025-09-24 09:46:55'),(21,'Trà Sữa Trân Châu','Trà sữa kèm trân châu dai ngon',35000.00,100,50,'2025-09-24 02:46:55','2025-09-24 09:46:55'),(22,'Trà Đào Cam Sả','Trà đào cam sả mát lạnh',30000.00,60,20,'2025-09-28 11:34:01','2025-09-28 18:34:00'),(23,'Cà Phê Đen','Cà phê đen nguyên chất',20000.00,80,35,'2025-09-28 11:34:01','2025-09-28 18:34:00'),(24,'Cà Phê Sữa','Cà phê sữa đá truyền thống',25000.00,90,40,'2025-09-28 11:34:01','2025-09-28 18:34:00'),(25,'Sinh Tố Bơ','Sinh tố bơ béo ngậy',40000.00,50,18,'2025-09-28 11:34:01','2025-09-28 18:34:00'),(26,'Burger Bò Phô Mai','Bánh burger bò kèm phô mai tan chảy',45000.00,50,20,'2025-09-28 11:34:01','2025-09-28 18:34:00'),(27,'Burger Gà Giòn','Bánh burger gà chiên giòn',40000.00,60,25,'2025-09-28 11:34:01','2025-09-28 18:34:00'),(29,'Gà Rán 2 Miếng','Gà rán giòn rụm, hương vị đặc trưng',60000.00,80,30,'2025-09-28 11:34:01','2025-09-28 18:34:00'),(30,'Hotdog Xúc Xích','Bánh mì kẹp xúc xích và tương cà',30000.00,70,20,'2025-09-28 11:34:01','2025-09-28 18:34:00'),(31,'Pizza Phô Mai','Pizza nhỏ phủ phô mai mozzarella',70000.00,40,15,'2025-09-28 11:34:01','2025-09-28 18:34:00'),(32,'Pizza Hải Sản','Pizza hải sản tươi ngon',85000.00,35,10,'2025-09-28 11:34:01','2025-09-28 18:34:00'),(33,'Sandwich Thịt Nguội','Bánh sandwich kẹp thịt nguội và rau',35000.00,60,22,'2025-09-28 11:34:01','2025-09-28 18:34:00'),(34,'Mì Ý Sốt Bò Bằm','Mì Ý sốt cà chua bò bằm',65000.00,45,18,'2025-09-28 11:34:01','2025-09-28 18:34:00'),(35,'Salad Rau Trộn','Salad rau củ tươi mát',30000.00,50,12,'2025-09-28 11:34:01','2025-09-28 18:34:00'),(41,'Trà Đào Cam Sả','Trà đào cam xả 100% làm từ thiên nhiên',25000.00,100,0,'2025-09-29 10:03:24','2025-09-29 17:03:24');
+INSERT INTO `Products` VALUES (16,'Nước Cam Ép','Nước cam tươi nguyên chất','Đồ uống',15000.00,100,35,1,'2025-09-24 02:46:55','2025-09-24 09:46:55'),(17,'Nước Chanh Tươi','Nước chanh mát lạnh giải khát','Đồ uống',12000.00,90,28,1,'2025-09-24 02:46:55','2025-09-24 09:46:55'),(19,'Sữa Tươi','Sữa tươi tiệt trùng nguyên chất','Đồ uống',18000.00,80,30,1,'2025-09-24 02:46:55','2025-09-24 09:46:55'),(20,'Sữa Đậu Nành','Thức uống từ đậu nành bổ dưỡng','Đồ uống',12000.00,90,22,1,'2025-09-24 02:46:55','2025-09-24 09:46:55'),(21,'Trà Sữa Trân Châu','Trà sữa kèm trân châu dai ngon','Đồ uống',35000.00,100,50,1,'2025-09-24 02:46:55','2025-09-24 09:46:55'),(22,'Trà Đào Cam Sả','Trà đào cam sả mát lạnh','Đồ uống',30000.00,60,20,1,'2025-09-28 11:34:01','2025-09-28 18:34:00'),(23,'Cà Phê Đen','Cà phê đen nguyên chất','Đồ uống',20000.00,80,35,1,'2025-09-28 11:34:01','2025-09-28 18:34:00'),(24,'Cà Phê Sữa','Cà phê sữa đá truyền thống','Đồ uống',25000.00,90,40,1,'2025-09-28 11:34:01','2025-09-28 18:34:00'),(25,'Sinh Tố Bơ','Sinh tố bơ béo ngậy','Đồ uống',40000.00,50,18,1,'2025-09-28 11:34:01','2025-09-28 18:34:00'),(26,'Burger Bò Phô Mai','Bánh burger bò kèm phô mai tan chảy','Đồ ăn',45000.00,50,20,1,'2025-09-28 11:34:01','2025-09-28 18:34:00'),(27,'Burger Gà Giòn','Bánh burger gà chiên giòn','Đồ ăn',40000.00,60,25,1,'2025-09-28 11:34:01','2025-09-28 18:34:00'),(29,'Gà Rán 2 Miếng','Gà rán giòn rụm, hương vị đặc trưng','Đồ ăn',60000.00,80,30,1,'2025-09-28 11:34:01','2025-09-28 18:34:00'),(30,'Hotdog Xúc Xích','Bánh mì kẹp xúc xích và tương cà','Đồ ăn',30000.00,70,20,1,'2025-09-28 11:34:01','2025-09-28 18:34:00'),(31,'Pizza Phô Mai','Pizza nhỏ phủ phô mai mozzarella','Đồ ăn',70000.00,40,15,1,'2025-09-28 11:34:01','2025-09-28 18:34:00'),(32,'Pizza Hải Sản','Pizza hải sản tươi ngon','Đồ ăn',85000.00,35,10,1,'2025-09-28 11:34:01','2025-09-28 18:34:00'),(33,'Sandwich Thịt Nguội','Bánh sandwich kẹp thịt nguội và rau','Đồ ăn',35000.00,60,22,1,'2025-09-28 11:34:01','2025-09-28 18:34:00'),(34,'Mì Ý Sốt Bò Bằm','Mì Ý sốt cà chua bò bằm','Đồ ăn',65000.00,45,18,1,'2025-09-28 11:34:01','2025-09-28 18:34:00'),(35,'Salad Rau Trộn','Salad rau củ tươi mát','Đồ ăn',30000.00,50,12,1,'2025-09-28 11:34:01','2025-09-28 18:34:00'),(41,'Trà Đào Cam Sả','Trà đào cam xả 100% làm từ thiên nhiên','Đồ uống',25000.00,100,0,1,'2025-09-29 10:03:24','2025-09-29 17:03:24');
 /*!40000 ALTER TABLE `Products` ENABLE KEYS */;
 UNLOCK TABLES;
 
@@ -163,6 +168,383 @@ LOCK TABLES `Reviews` WRITE;
 /*!40000 ALTER TABLE `Reviews` ENABLE KEYS */;
 UNLOCK TABLES;
 
+--
+-- Table structure for table `cart_items`
+--
+
+DROP TABLE IF EXISTS `cart_items`;
+/*!40101 SET @saved_cs_client     = @@character_set_client */;
+/*!50503 SET character_set_client = utf8mb4 */;
+CREATE TABLE `cart_items` (
+  `id` int NOT NULL AUTO_INCREMENT,
+  `user_id` int NOT NULL,
+  `product_id` int NOT NULL,
+  `quantity` int NOT NULL,
+  `option_key` varchar(255) NOT NULL DEFAULT '',
+  `note` varchar(200) DEFAULT NULL,
+  `created_at` timestamp NULL DEFAULT CURRENT_TIMESTAMP,
+  `updated_at` timestamp NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `uq_cart_items_user_product` (`user_id`,`product_id`,`option_key`),
+  KEY `fk_cart_items_product` (`product_id`),
+  CONSTRAINT `fk_cart_items_product` FOREIGN KEY (`product_id`) REFERENCES `Products` (`id`) ON DELETE CASCADE,
+  CONSTRAINT `fk_cart_items_user` FOREIGN KEY (`user_id`) REFERENCES `users` (`id`) ON DELETE CASCADE,
+  CONSTRAINT `cart_items_chk_1` CHECK ((`quantity` > 0))
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
+/*!40101 SET character_set_client = @saved_cs_client */;
+
+--
+-- Dumping data for table `cart_items`
+--
+
+LOCK TABLES `cart_items` WRITE;
+/*!40000 ALTER TABLE `cart_items` DISABLE KEYS */;
+/*!40000 ALTER TABLE `cart_items` ENABLE KEYS */;
+UNLOCK TABLES;
+
+--
+-- Table structure for table `cod_entries`
+--
+
+DROP TABLE IF EXISTS `cod_entries`;
+/*!40101 SET @saved_cs_client     = @@character_set_client */;
+/*!50503 SET character_set_client = utf8mb4 */;
+CREATE TABLE `cod_entries` (
+  `id` int NOT NULL AUTO_INCREMENT,
+  `shipper_id` int NOT NULL,
+  `order_id` int DEFAULT NULL,
+  `type` enum('collection','handover') NOT NULL,
+  `amount` decimal(10,2) NOT NULL,
+  `note` varchar(255) DEFAULT NULL,
+  `recorded_by` int DEFAULT NULL,
+  `created_at` timestamp NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `uq_cod_entries_order` (`order_id`),
+  KEY `idx_cod_entries_shipper` (`shipper_id`,`created_at`),
+  KEY `fk_cod_entries_recorded_by` (`recorded_by`),
+  CONSTRAINT `fk_cod_entries_order` FOREIGN KEY (`order_id`) REFERENCES `orders` (`id`) ON DELETE SET NULL,
+  CONSTRAINT `fk_cod_entries_recorded_by` FOREIGN KEY (`recorded_by`) REFERENCES `users` (`id`) ON DELETE SET NULL,
+  CONSTRAINT `fk_cod_entries_shipper` FOREIGN KEY (`shipper_id`) REFERENCES `users` (`id`),
+  CONSTRAINT `cod_entries_chk_1` CHECK ((`amount` > 0))
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
+/*!40101 SET character_set_client = @saved_cs_client */;
+
+--
+-- Dumping data for table `cod_entries`
+--
+
+LOCK TABLES `cod_entries` WRITE;
+/*!40000 ALTER TABLE `cod_entries` DISABLE KEYS */;
+/*!40000 ALTER TABLE `cod_entries` ENABLE KEYS */;
+UNLOCK TABLES;
+
+--
+-- Table structure for table `coupon_categories`
+--
+
+DROP TABLE IF EXISTS `coupon_categories`;
+/*!40101 SET @saved_cs_client     = @@character_set_client */;
+/*!50503 SET character_set_client = utf8mb4 */;
+CREATE TABLE `coupon_categories` (
+  `coupon_id` int NOT NULL,
+  `category` varchar(100) NOT NULL,
+  PRIMARY KEY (`coupon_id`,`category`),
+  CONSTRAINT `fk_coupon_categories_coupon` FOREIGN KEY (`coupon_id`) REFERENCES `coupons` (`id`) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
+/*!40101 SET character_set_client = @saved_cs_client */;
+
+--
+-- Dumping data for table `coupon_categories`
+--
+
+LOCK TABLES `coupon_categories` WRITE;
+/*!40000 ALTER TABLE `coupon_categories` DISABLE KEYS */;
+/*!40000 ALTER TABLE `coupon_categories` ENABLE KEYS */;
+UNLOCK TABLES;
+
+--
+-- Table structure for table `coupon_products`
+--
+
+DROP TABLE IF EXISTS `coupon_products`;
+/*!40101 SET @saved_cs_client     = @@character_set_client */;
+/*!50503 SET character_set_client = utf8mb4 */;
+CREATE TABLE `coupon_products` (
+  `coupon_id` int NOT NULL,
+  `product_id` int NOT NULL,
+  PRIMARY KEY (`coupon_id`,`product_id`),
+  KEY `fk_coupon_products_product` (`product_id`),
+  CONSTRAINT `fk_coupon_products_coupon` FOREIGN KEY (`coupon_id`) REFERENCES `coupons` (`id`) ON DELETE CASCADE,
+  CONSTRAINT `fk_coupon_products_product` FOREIGN KEY (`product_id`) REFERENCES `Products` (`id`) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
+/*!40101 SET character_set_client = @saved_cs_client */;
+
+--
+-- Dumping data for table `coupon_products`
+--
+
+LOCK TABLES `coupon_products` WRITE;
+/*!40000 ALTER TABLE `coupon_products` DISABLE KEYS */;
+/*!40000 ALTER TABLE `coupon_products` ENABLE KEYS */;
+UNLOCK TABLES;
+
+--
+-- Table structure for table `coupon_redemptions`
+--
+
+DROP TABLE IF EXISTS `coupon_redemptions`;
+/*!40101 SET @saved_cs_client     = @@character_set_client */;
+/*!50503 SET character_set_client = utf8mb4 */;
+CREATE TABLE `coupon_redemptions` (
+  `id` int NOT NULL AUTO_INCREMENT,
+  `coupon_id` int NOT NULL,
+  `user_id` int NOT NULL,
+  `order_id` int NOT NULL,
+  `discount` decimal(10,2) NOT NULL,
+  `created_at` timestamp NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `uq_coupon_redemptions_order` (`order_id`),
+  KEY `idx_coupon_redemptions_user` (`coupon_id`,`user_id`),
+  KEY `fk_coupon_redemptions_user` (`user_id`),
+  CONSTRAINT `fk_coupon_redemptions_coupon` FOREIGN KEY (`coupon_id`) REFERENCES `coupons` (`id`) ON DELETE CASCADE,
+  CONSTRAINT `fk_coupon_redemptions_order` FOREIGN KEY (`order_id`) REFERENCES `orders` (`id`) ON DELETE CASCADE,
+  CONSTRAINT `fk_coupon_redemptions_user` FOREIGN KEY (`user_id`) REFERENCES `users` (`id`) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
+/*!40101 SET character_set_client = @saved_cs_client */;
+
+--
+-- Dumping data for table `coupon_redemptions`
+--
+
+LOCK TABLES `coupon_redemptions` WRITE;
+/*!40000 ALTER TABLE `coupon_redemptions` DISABLE KEYS */;
+/*!40000 ALTER TABLE `coupon_redemptions` ENABLE KEYS */;
+UNLOCK TABLES;
+
+--
+-- Table structure for table `coupons`
+--
+
+DROP TABLE IF EXISTS `coupons`;
+/*!40101 SET @saved_cs_client     = @@character_set_client */;
+/*!50503 SET character_set_client = utf8mb4 */;
+CREATE TABLE `coupons` (
+  `id` int NOT NULL AUTO_INCREMENT,
+  `code` varchar(50) NOT NULL,
+  `description` varchar(255) DEFAULT NULL,
+  `discount_type` enum('percent','fixed') NOT NULL,
+  `discount_value` decimal(10,2) NOT NULL,
+  `min_order_value` decimal(10,2) NOT NULL DEFAULT '0.00',
+  `max_discount` decimal(10,2) DEFAULT NULL,
+  `starts_at` timestamp NOT NULL,
+  `ends_at` timestamp NOT NULL,
+  `usage_limit` int DEFAULT NULL,
+  `per_user_limit` int DEFAULT NULL,
+  `used_count` int NOT NULL DEFAULT '0',
+  `is_active` tinyint(1) NOT NULL DEFAULT '1',
+  `created_at` timestamp NULL DEFAULT CURRENT_TIMESTAMP,
+  `updated_at` timestamp NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `uq_coupons_code` (`code`),
+  CONSTRAINT `coupons_chk_1` CHECK ((`discount_value` > 0)),
+  CONSTRAINT `coupons_chk_2` CHECK ((`ends_at` > `starts_at`))
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
+/*!40101 SET character_set_client = @saved_cs_client */;
+
+--
+-- Dumping data for table `coupons`
+--
+
+LOCK TABLES `coupons` WRITE;
+/*!40000 ALTER TABLE `coupons` DISABLE KEYS */;
+/*!40000 ALTER TABLE `coupons` ENABLE KEYS */;
+UNLOCK TABLES;
+
+--
+-- Table structure for table `delivery_slots`
+--
+
+DROP TABLE IF EXISTS `delivery_slots`;
+/*!40101 SET @saved_cs_client     = @@character_set_client */;
+/*!50503 SET character_set_client = utf8mb4 */;
+CREATE TABLE `delivery_slots` (
+  `id` int NOT NULL AUTO_INCREMENT,
+  `store_id` int NOT NULL,
+  `start_time` time NOT NULL,
+  `end_time` time NOT NULL,
+  `capacity` int NOT NULL,
+  `is_active` tinyint(1) NOT NULL DEFAULT '1',
+  `created_at` timestamp NULL DEFAULT CURRENT_TIMESTAMP,
+  `updated_at` timestamp NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+  PRIMARY KEY (`id`),
+  KEY `fk_delivery_slots_store` (`store_id`),
+  CONSTRAINT `fk_delivery_slots_store` FOREIGN KEY (`store_id`) REFERENCES `stores` (`id`) ON DELETE CASCADE,
+  CONSTRAINT `delivery_slots_chk_1` CHECK ((`end_time` > `start_time`)),
+  CONSTRAINT `delivery_slots_chk_2` CHECK ((`capacity` > 0))
+) ENGINE=InnoDB AUTO_INCREMENT=5 DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
+/*!40101 SET character_set_client = @saved_cs_client */;
+
+--
+-- Dumping data for table `delivery_slots`
+--
+
+LOCK TABLES `delivery_slots` WRITE;
+/*!40000 ALTER TABLE `delivery_slots` DISABLE KEYS */;
+INSERT INTO `delivery_slots` VALUES (1,1,'09:00:00','11:00:00',20,1,'2025-10-03 09:00:00','2025-10-03 09:00:00'),(2,1,'11:00:00','13:00:00',20,1,'2025-10-03 09:00:00','2025-10-03 09:00:00'),(3,1,'17:00:00','19:00:00',20,1,'2025-10-03 09:00:00','2025-10-03 09:00:00'),(4,1,'19:00:00','21:00:00',20,1,'2025-10-03 09:00:00','2025-10-03 09:00:00');
+/*!40000 ALTER TABLE `delivery_slots` ENABLE KEYS */;
+UNLOCK TABLES;
+
+--
+-- Table structure for table `flash_sale_items`
+--
+
+DROP TABLE IF EXISTS `flash_sale_items`;
+/*!40101 SET @saved_cs_client     = @@character_set_client */;
+/*!50503 SET character_set_client = utf8mb4 */;
+CREATE TABLE `flash_sale_items` (
+  `id` int NOT NULL AUTO_INCREMENT,
+  `flash_sale_id` int NOT NULL,
+  `product_id` int NOT NULL,
+  `sale_price` decimal(10,2) NOT NULL,
+  `qty_limit` int NOT NULL,
+  `qty_sold` int NOT NULL DEFAULT '0',
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `uq_flash_sale_items_product` (`flash_sale_id`,`product_id`),
+  KEY `fk_flash_sale_items_product` (`product_id`),
+  CONSTRAINT `fk_flash_sale_items_product` FOREIGN KEY (`product_id`) REFERENCES `Products` (`id`) ON DELETE CASCADE,
+  CONSTRAINT `fk_flash_sale_items_sale` FOREIGN KEY (`flash_sale_id`) REFERENCES `flash_sales` (`id`) ON DELETE CASCADE,
+  CONSTRAINT `flash_sale_items_chk_1` CHECK ((`sale_price` >= 0)),
+  CONSTRAINT `flash_sale_items_chk_2` CHECK ((`qty_limit` > 0)),
+  CONSTRAINT `flash_sale_items_chk_3` CHECK ((`qty_sold` >= 0)),
+  CONSTRAINT `flash_sale_items_chk_4` CHECK ((`qty_sold` <= `qty_limit`))
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
+/*!40101 SET character_set_client = @saved_cs_client */;
+
+--
+-- Dumping data for table `flash_sale_items`
+--
+
+LOCK TABLES `flash_sale_items` WRITE;
+/*!40000 ALTER TABLE `flash_sale_items` DISABLE KEYS */;
+/*!40000 ALTER TABLE `flash_sale_items` ENABLE KEYS */;
+UNLOCK TABLES;
+
+--
+-- Table structure for table `flash_sales`
+--
+
+DROP TABLE IF EXISTS `flash_sales`;
+/*!40101 SET @saved_cs_client     = @@character_set_client */;
+/*!50503 SET character_set_client = utf8mb4 */;
+CREATE TABLE `flash_sales` (
+  `id` int NOT NULL AUTO_INCREMENT,
+  `name` varchar(255) NOT NULL,
+  `starts_at` timestamp NOT NULL,
+  `ends_at` timestamp NOT NULL,
+  `is_active` tinyint(1) NOT NULL DEFAULT '1',
+  `created_at` timestamp NULL DEFAULT CURRENT_TIMESTAMP,
+  `updated_at` timestamp NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+  PRIMARY KEY (`id`),
+  KEY `idx_flash_sales_window` (`starts_at`,`ends_at`),
+  CONSTRAINT `flash_sales_chk_1` CHECK ((`ends_at` > `starts_at`))
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
+/*!40101 SET character_set_client = @saved_cs_client */;
+
+--
+-- Dumping data for table `flash_sales`
+--
+
+LOCK TABLES `flash_sales` WRITE;
+/*!40000 ALTER TABLE `flash_sales` DISABLE KEYS */;
+/*!40000 ALTER TABLE `flash_sales` ENABLE KEYS */;
+UNLOCK TABLES;
+
+--
+-- Table structure for table `idempotency_keys`
+--
+
+DROP TABLE IF EXISTS `idempotency_keys`;
+/*!40101 SET @saved_cs_client     = @@character_set_client */;
+/*!50503 SET character_set_client = utf8mb4 */;
+CREATE TABLE `idempotency_keys` (
+  `id` bigint unsigned NOT NULL AUTO_INCREMENT,
+  `user_id` int NOT NULL,
+  `idem_key` varchar(255) NOT NULL,
+  `scope` varchar(255) NOT NULL,
+  `request_hash` char(64) NOT NULL,
+  `status` enum('processing','completed') NOT NULL DEFAULT 'processing',
+  `response_code` int DEFAULT NULL,
+  `content_type` varchar(255) DEFAULT NULL,
+  `response_body` mediumtext,
+  `created_at` timestamp NULL DEFAULT CURRENT_TIMESTAMP,
+  `updated_at` timestamp NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `uq_user_idem_key` (`user_id`,`idem_key`),
+  KEY `idx_idempotency_updated` (`updated_at`),
+  CONSTRAINT `fk_idempotency_user` FOREIGN KEY (`user_id`) REFERENCES `users` (`id`) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
+/*!40101 SET character_set_client = @saved_cs_client */;
+
+--
+-- Dumping data for table `idempotency_keys`
+--
+
+LOCK TABLES `idempotency_keys` WRITE;
+/*!40000 ALTER TABLE `idempotency_keys` DISABLE KEYS */;
+/*!40000 ALTER TABLE `idempotency_keys` ENABLE KEYS */;
+UNLOCK TABLES;
+
+--
+-- Table structure for table `invoice_sequences`
+--
+
+DROP TABLE IF EXISTS `invoice_sequences`;
+/*!40101 SET @saved_cs_client     = @@character_set_client */;
+/*!50503 SET character_set_client = utf8mb4 */;
+CREATE TABLE `invoice_sequences` (
+  `year` int NOT NULL,
+  `last_number` int NOT NULL,
+  PRIMARY KEY (`year`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
+/*!40101 SET character_set_client = @saved_cs_client */;
+
+--
+-- Dumping data for table `invoice_sequences`
+--
+
+LOCK TABLES `invoice_sequences` WRITE;
+/*!40000 ALTER TABLE `invoice_sequences` DISABLE KEYS */;
+/*!40000 ALTER TABLE `invoice_sequences` ENABLE KEYS */;
+UNLOCK TABLES;
+
+--
+-- Table structure for table `invoices`
+--
+
+DROP TABLE IF EXISTS `invoices`;
+/*!40101 SET @saved_cs_client     = @@character_set_client */;
+/*!50503 SET character_set_client = utf8mb4 */;
+CREATE TABLE `invoices` (
+  `id` int NOT NULL AUTO_INCREMENT,
+  `order_id` int NOT NULL,
+  `invoice_no` varchar(20) NOT NULL,
+  `issued_at` timestamp NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `uq_invoices_order` (`order_id`),
+  UNIQUE KEY `uq_invoices_no` (`invoice_no`),
+  CONSTRAINT `fk_invoices_order` FOREIGN KEY (`order_id`) REFERENCES `orders` (`id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
+/*!40101 SET character_set_client = @saved_cs_client */;
+
+--
+-- Dumping data for table `invoices`
+--
+
+LOCK TABLES `invoices` WRITE;
+/*!40000 ALTER TABLE `invoices` DISABLE KEYS */;
+/*!40000 ALTER TABLE `invoices` ENABLE KEYS */;
+UNLOCK TABLES;
+
 --
 -- Table structure for table `messages`
 --
@@ -199,6 +581,37 @@ INSERT INTO `messages` VALUES (5,6,39,37,'Đơn hàng đã sẵn sàng giao','20
 /*!40000 ALTER TABLE `messages` ENABLE KEYS */;
 UNLOCK TABLES;
 
+--
+-- Table structure for table `order_item_options`
+--
+
+DROP TABLE IF EXISTS `order_item_options`;
+/*!40101 SET @saved_cs_client     = @@character_set_client */;
+/*!50503 SET character_set_client = utf8mb4 */;
+CREATE TABLE `order_item_options` (
+  `id` int NOT NULL AUTO_INCREMENT,
+  `order_item_id` int NOT NULL,
+  `option_id` int DEFAULT NULL,
+  `group_name` varchar(100) NOT NULL,
+  `option_name` varchar(100) NOT NULL,
+  `price_delta` decimal(10,2) NOT NULL DEFAULT '0.00',
+  PRIMARY KEY (`id`),
+  KEY `fk_order_item_options_item` (`order_item_id`),
+  KEY `fk_order_item_options_option` (`option_id`),
+  CONSTRAINT `fk_order_item_options_item` FOREIGN KEY (`order_item_id`) REFERENCES `order_items` (`id`) ON DELETE CASCADE,
+  CONSTRAINT `fk_order_item_options_option` FOREIGN KEY (`option_id`) REFERENCES `product_options` (`id`) ON DELETE SET NULL
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
+/*!40101 SET character_set_client = @saved_cs_client */;
+
+--
+-- Dumping data for table `order_item_options`
+--
+
+LOCK TABLES `order_item_options` WRITE;
+/*!40000 ALTER TABLE `order_item_options` DISABLE KEYS */;
+/*!40000 ALTER TABLE `order_item_options` ENABLE KEYS */;
+UNLOCK TABLES;
+
 --
 -- Table structure for table `order_items`
 --
@@ -212,11 +625,15 @@ CREATE TABLE `order_items` (
   `product_id` int NOT NULL,
   `quantity` int NOT NULL,
   `price` decimal(10,2) NOT NULL,
+  `flash_sale_item_id` int DEFAULT NULL,
+  `note` varchar(255) DEFAULT NULL,
   PRIMARY KEY (`id`),
   KEY `order_id` (`order_id`),
   KEY `product_id` (`product_id`),
+  KEY `fk_order_items_flash_sale` (`flash_sale_item_id`),
   CONSTRAINT `order_items_ibfk_1` FOREIGN KEY (`order_id`) REFERENCES `orders` (`id`) ON DELETE CASCADE,
   CONSTRAINT `order_items_ibfk_2` FOREIGN KEY (`product_id`) REFERENCES `Products` (`id`) ON DELETE CASCADE,
+  CONSTRAINT `fk_order_items_flash_sale` FOREIGN KEY (`flash_sale_item_id`) REFERENCES `flash_sale_items` (`id`) ON DELETE SET NULL,
   CONSTRAINT `order_items_chk_1` CHECK ((`quantity` > 0)),
   CONSTRAINT `order_items_chk_2` CHECK ((`price` >= 0))
 ) ENGINE=InnoDB AUTO_INCREMENT=21 DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
@@ -228,10 +645,42 @@ CREATE TABLE `order_items` (
 
 LOCK TABLES `order_items` WRITE;
 /*!40000 ALTER TABLE `order_items` DISABLE KEYS */;
-INSERT INTO `order_items` VALUES (8,7,16,7,15000.00),(9,8,34,6,65000.00),(10,8,35,7,30000.00),(13,10,29,1,60000.00),(14,10,27,2,40000.00),(15,11,26,2,45000.00),(16,11,30,2,30000.00),(17,12,26,2,45000.00),(18,12,30,2,30000.00),(19,13,26,2,45000.00),(20,13,30,2,30000.00);
+INSERT INTO `order_items` VALUES (8,7,16,7,15000.00,NULL,NULL),(9,8,34,6,65000.00,NULL,NULL),(10,8,35,7,30000.00,NULL,NULL),(13,10,29,1,60000.00,NULL,NULL),(14,10,27,2,40000.00,NULL,NULL),(15,11,26,2,45000.00,NULL,NULL),(16,11,30,2,30000.00,NULL,NULL),(17,12,26,2,45000.00,NULL,NULL),(18,12,30,2,30000.00,NULL,NULL),(19,13,26,2,45000.00,NULL,NULL),(20,13,30,2,30000.00,NULL,NULL);
 /*!40000 ALTER TABLE `order_items` ENABLE KEYS */;
 UNLOCK TABLES;
 
+--
+-- Table structure for table `order_status_events`
+--
+
+DROP TABLE IF EXISTS `order_status_events`;
+/*!40101 SET @saved_cs_client     = @@character_set_client */;
+/*!50503 SET character_set_client = utf8mb4 */;
+CREATE TABLE `order_status_events` (
+  `id` bigint unsigned NOT NULL AUTO_INCREMENT,
+  `order_id` int NOT NULL,
+  `field` enum('order_status','payment_status') NOT NULL,
+  `from_status` varchar(20) DEFAULT NULL,
+  `to_status` varchar(20) NOT NULL,
+  `actor_id` int DEFAULT NULL,
+  `actor_role` varchar(20) NOT NULL,
+  `note` varchar(255) DEFAULT NULL,
+  `created_at` timestamp NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (`id`),
+  KEY `fk_status_events_order` (`order_id`),
+  CONSTRAINT `fk_status_events_order` FOREIGN KEY (`order_id`) REFERENCES `orders` (`id`) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
+/*!40101 SET character_set_client = @saved_cs_client */;
+
+--
+-- Dumping data for table `order_status_events`
+--
+
+LOCK TABLES `order_status_events` WRITE;
+/*!40000 ALTER TABLE `order_status_events` DISABLE KEYS */;
+/*!40000 ALTER TABLE `order_status_events` ENABLE KEYS */;
+UNLOCK TABLES;
+
 --
 -- Table structure for table `orders`
 --
@@ -243,17 +692,37 @@ CREATE TABLE `orders` (
   `id` int NOT NULL AUTO_INCREMENT,
   `user_id` int NOT NULL,
   `shipper_id` int DEFAULT NULL,
-  `payment_status` enum('unpaid','paid','refunded') DEFAULT 'unpaid',
+  `store_id` int DEFAULT NULL,
+  `payment_status` enum('unpaid','paid','partially_refunded','refunded') DEFAULT 'unpaid',
   `order_status` enum('pending','processing','shipping','delivered','cancelled') DEFAULT 'pending',
   `latitude` decimal(10,8) NOT NULL,
   `longitude` decimal(11,8) NOT NULL,
+  `address_id` int DEFAULT NULL,
+  `address_label` varchar(50) NOT NULL DEFAULT '',
+  `delivery_address` varchar(255) NOT NULL DEFAULT '',
+  `driver_note` varchar(255) NOT NULL DEFAULT '',
+  `delivery_instruction` varchar(500) DEFAULT NULL,
+  `subtotal` decimal(10,2) NOT NULL DEFAULT '0.00',
+  `delivery_fee` decimal(10,2) NOT NULL DEFAULT '0.00',
+  `discount` decimal(10,2) NOT NULL DEFAULT '0.00',
   `total_amount` decimal(10,2) NOT NULL DEFAULT '0.00',
+  `delivery_slot_id` int DEFAULT NULL,
+  `scheduled_for` timestamp NULL DEFAULT NULL,
   `thumbnail_id` int DEFAULT NULL,
   `created_at` timestamp NULL DEFAULT CURRENT_TIMESTAMP,
   `updated_at` timestamp NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
   PRIMARY KEY (`id`),
   KEY `fk_orders_thumbnail` (`thumbnail_id`),
-  CONSTRAINT `fk_orders_thumbnail` FOREIGN KEY (`thumbnail_id`) REFERENCES `Images` (`id`) ON DELETE SET NULL
+  KEY `fk_orders_store` (`store_id`),
+  KEY `idx_orders_slot_schedule` (`delivery_slot_id`,`scheduled_for`),
+  KEY `fk_orders_address` (`address_id`),
+  KEY `idx_orders_created_at` (`created_at`),
+  KEY `idx_orders_shipper` (`shipper_id`,`created_at`),
+  KEY `idx_orders_status_created` (`order_status`,`created_at`),
+  CONSTRAINT `fk_orders_thumbnail` FOREIGN KEY (`thumbnail_id`) REFERENCES `Images` (`id`) ON DELETE SET NULL,
+  CONSTRAINT `fk_orders_store` FOREIGN KEY (`store_id`) REFERENCES `stores` (`id`) ON DELETE SET NULL,
+  CONSTRAINT `fk_orders_delivery_slot` FOREIGN KEY (`delivery_slot_id`) REFERENCES `delivery_slots` (`id`) ON DELETE SET NULL,
+  CONSTRAINT `fk_orders_address` FOREIGN KEY (`address_id`) REFERENCES `user_addresses` (`id`) ON DELETE SET NULL
 ) ENGINE=InnoDB AUTO_INCREMENT=14 DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
 /*!40101 SET character_set_client = @saved_cs_client */;
 
@@ -263,10 +732,105 @@ CREATE TABLE `orders` (
 
 LOCK TABLES `orders` WRITE;
 /*!40000 ALTER TABLE `orders` DISABLE KEYS */;
-INSERT INTO `orders` VALUES (6,37,39,'unpaid','shipping',21.02851100,105.80481700,149000.00,28,'2025-09-27 02:01:50','2025-09-28 11:46:30'),(7,37,39,'unpaid','shipping',21.02851100,105.80481700,165000.00,34,'2025-09-27 03:21:13','2025-09-28 11:46:44'),(8,37,NULL,'unpaid','processing',21.02851100,105.80481700,600000.00,65,'2025-10-02 03:31:43','2025-10-03 07:42:18'),(10,37,NULL,'unpaid','pending',21.02851100,105.80481700,140000.00,60,'2025-10-02 03:33:05','2025-10-02 17:37:54'),(11,37,NULL,'unpaid','pending',21.02851100,105.80481700,150000.00,57,'2025-10-02 03:33:18','2025-10-02 17:37:54'),(12,37,NULL,'unpaid','processing',21.02851100,105.80481700,150000.00,57,'2025-10-02 03:33:19','2025-10-03 14:33:15'),(13,37,NULL,'unpaid','processing',21.02851100,105.80481700,150000.00,57,'2025-10-02 03:33:20','2025-10-03 07:42:41');
+INSERT INTO `orders` VALUES (6,37,39,1,'unpaid','shipping',21.02851100,105.80481700,NULL,'','','',NULL,149000.00,0.00,0.00,149000.00,NULL,NULL,28,'2025-09-27 02:01:50','2025-09-28 11:46:30'),(7,37,39,1,'unpaid','shipping',21.02851100,105.80481700,NULL,'','','',NULL,165000.00,0.00,0.00,165000.00,NULL,NULL,34,'2025-09-27 03:21:13','2025-09-28 11:46:44'),(8,37,NULL,1,'unpaid','processing',21.02851100,105.80481700,NULL,'','','',NULL,600000.00,0.00,0.00,600000.00,NULL,NULL,65,'2025-10-02 03:31:43','2025-10-03 07:42:18'),(10,37,NULL,1,'unpaid','pending',21.02851100,105.80481700,NULL,'','','',NULL,140000.00,0.00,0.00,140000.00,NULL,NULL,60,'2025-10-02 03:33:05','2025-10-02 17:37:54'),(11,37,NULL,1,'unpaid','pending',21.02851100,105.80481700,NULL,'','','',NULL,150000.00,0.00,0.00,150000.00,NULL,NULL,57,'2025-10-02 03:33:18','2025-10-02 17:37:54'),(12,37,NULL,1,'unpaid','processing',21.02851100,105.80481700,NULL,'','','',NULL,150000.00,0.00,0.00,150000.00,NULL,NULL,57,'2025-10-02 03:33:19','2025-10-03 14:33:15'),(13,37,NULL,1,'unpaid','processing',21.02851100,105.80481700,NULL,'','','',NULL,150000.00,0.00,0.00,150000.00,NULL,NULL,57,'2025-10-02 03:33:20','2025-10-03 07:42:41');
 /*!40000 ALTER TABLE `orders` ENABLE KEYS */;
 UNLOCK TABLES;
 
+--
+-- Table structure for table `payments`
+--
+
+DROP TABLE IF EXISTS `payments`;
+/*!40101 SET @saved_cs_client     = @@character_set_client */;
+/*!50503 SET character_set_client = utf8mb4 */;
+CREATE TABLE `payments` (
+  `id` int NOT NULL AUTO_INCREMENT,
+  `order_id` int NOT NULL,
+  `provider` varchar(20) NOT NULL,
+  `provider_ref` varchar(100) DEFAULT NULL,
+  `amount` decimal(10,2) NOT NULL,
+  `status` enum('pending','succeeded','failed') NOT NULL DEFAULT 'pending',
+  `payment_url` varchar(1000) DEFAULT NULL,
+  `result_code` varchar(50) DEFAULT NULL,
+  `created_at` timestamp NULL DEFAULT CURRENT_TIMESTAMP,
+  `updated_at` timestamp NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `uq_payments_provider_ref` (`provider`,`provider_ref`),
+  KEY `fk_payments_order` (`order_id`),
+  CONSTRAINT `fk_payments_order` FOREIGN KEY (`order_id`) REFERENCES `orders` (`id`) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
+/*!40101 SET character_set_client = @saved_cs_client */;
+
+--
+-- Dumping data for table `payments`
+--
+
+LOCK TABLES `payments` WRITE;
+/*!40000 ALTER TABLE `payments` DISABLE KEYS */;
+/*!40000 ALTER TABLE `payments` ENABLE KEYS */;
+UNLOCK TABLES;
+
+--
+-- Table structure for table `product_option_groups`
+--
+
+DROP TABLE IF EXISTS `product_option_groups`;
+/*!40101 SET @saved_cs_client     = @@character_set_client */;
+/*!50503 SET character_set_client = utf8mb4 */;
+CREATE TABLE `product_option_groups` (
+  `id` int NOT NULL AUTO_INCREMENT,
+  `product_id` int NOT NULL,
+  `name` varchar(100) NOT NULL,
+  `min_select` int NOT NULL DEFAULT '0',
+  `max_select` int NOT NULL DEFAULT '1',
+  `sort_order` int NOT NULL DEFAULT '0',
+  `created_at` timestamp NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (`id`),
+  KEY `fk_option_groups_product` (`product_id`),
+  CONSTRAINT `fk_option_groups_product` FOREIGN KEY (`product_id`) REFERENCES `Products` (`id`) ON DELETE CASCADE,
+  CONSTRAINT `product_option_groups_chk_1` CHECK ((`min_select` >= 0)),
+  CONSTRAINT `product_option_groups_chk_2` CHECK ((`max_select` >= greatest(`min_select`,1)))
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
+/*!40101 SET character_set_client = @saved_cs_client */;
+
+--
+-- Dumping data for table `product_option_groups`
+--
+
+LOCK TABLES `product_option_groups` WRITE;
+/*!40000 ALTER TABLE `product_option_groups` DISABLE KEYS */;
+/*!40000 ALTER TABLE `product_option_groups` ENABLE KEYS */;
+UNLOCK TABLES;
+
+--
+-- Table structure for table `product_options`
+--
+
+DROP TABLE IF EXISTS `product_options`;
+/*!40101 SET @saved_cs_client     = @@character_set_client */;
+/*!50503 SET character_set_client = utf8mb4 */;
+CREATE TABLE `product_options` (
+  `id` int NOT NULL AUTO_INCREMENT,
+  `group_id` int NOT NULL,
+  `name` varchar(100) NOT NULL,
+  `price_delta` decimal(10,2) NOT NULL DEFAULT '0.00',
+  `is_available` tinyint(1) NOT NULL DEFAULT '1',
+  `sort_order` int NOT NULL DEFAULT '0',
+  PRIMARY KEY (`id`),
+  KEY `fk_product_options_group` (`group_id`),
+  CONSTRAINT `fk_product_options_group` FOREIGN KEY (`group_id`) REFERENCES `product_option_groups` (`id`) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
+/*!40101 SET character_set_client = @saved_cs_client */;
+
+--
+-- Dumping data for table `product_options`
+--
+
+LOCK TABLES `product_options` WRITE;
+/*!40000 ALTER TABLE `product_options` DISABLE KEYS */;
+/*!40000 ALTER TABLE `product_options` ENABLE KEYS */;
+UNLOCK TABLES;
+
 --
 -- Table structure for table `refresh_tokens`
 --
@@ -297,6 +861,149 @@ INSERT INTO `refresh_tokens` VALUES (12,35,'Gdu2gjSJRWxZZRWGSOsjkiZDDDpQ9XpUOAUD
 /*!40000 ALTER TABLE `refresh_tokens` ENABLE KEYS */;
 UNLOCK TABLES;
 
+--
+-- Table structure for table `refund_items`
+--
+
+DROP TABLE IF EXISTS `refund_items`;
+/*!40101 SET @saved_cs_client     = @@character_set_client */;
+/*!50503 SET character_set_client = utf8mb4 */;
+CREATE TABLE `refund_items` (
+  `id` int NOT NULL AUTO_INCREMENT,
+  `refund_id` int NOT NULL,
+  `order_item_id` int NOT NULL,
+  `quantity` int NOT NULL,
+  `amount` decimal(10,2) NOT NULL,
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `uq_refund_items` (`refund_id`,`order_item_id`),
+  KEY `fk_refund_items_order_item` (`order_item_id`),
+  CONSTRAINT `fk_refund_items_refund` FOREIGN KEY (`refund_id`) REFERENCES `refunds` (`id`) ON DELETE CASCADE,
+  CONSTRAINT `fk_refund_items_order_item` FOREIGN KEY (`order_item_id`) REFERENCES `order_items` (`id`) ON DELETE CASCADE,
+  CONSTRAINT `refund_items_chk_1` CHECK ((`quantity` > 0))
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
+/*!40101 SET character_set_client = @saved_cs_client */;
+
+--
+-- Dumping data for table `refund_items`
+--
+
+LOCK TABLES `refund_items` WRITE;
+/*!40000 ALTER TABLE `refund_items` DISABLE KEYS */;
+/*!40000 ALTER TABLE `refund_items` ENABLE KEYS */;
+UNLOCK TABLES;
+
+--
+-- Table structure for table `refunds`
+--
+
+DROP TABLE IF EXISTS `refunds`;
+/*!40101 SET @saved_cs_client     = @@character_set_client */;
+/*!50503 SET character_set_client = utf8mb4 */;
+CREATE TABLE `refunds` (
+  `id` int NOT NULL AUTO_INCREMENT,
+  `order_id` int NOT NULL,
+  `payment_id` int DEFAULT NULL,
+  `requested_by` int DEFAULT NULL,
+  `requester_role` varchar(20) NOT NULL,
+  `reason` varchar(500) NOT NULL,
+  `status` enum('requested','approved','rejected') NOT NULL DEFAULT 'requested',
+  `requested_amount` decimal(10,2) NOT NULL,
+  `approved_amount` decimal(10,2) DEFAULT NULL,
+  `reviewed_by` int DEFAULT NULL,
+  `review_note` varchar(500) DEFAULT NULL,
+  `created_at` timestamp NULL DEFAULT CURRENT_TIMESTAMP,
+  `reviewed_at` timestamp NULL DEFAULT NULL,
+  PRIMARY KEY (`id`),
+  KEY `fk_refunds_order` (`order_id`),
+  KEY `idx_refunds_status` (`status`,`created_at`),
+  KEY `fk_refunds_requested_by` (`requested_by`),
+  KEY `fk_refunds_reviewed_by` (`reviewed_by`),
+  KEY `fk_refunds_payment` (`payment_id`),
+  CONSTRAINT `fk_refunds_order` FOREIGN KEY (`order_id`) REFERENCES `orders` (`id`) ON DELETE CASCADE,
+  CONSTRAINT `fk_refunds_requested_by` FOREIGN KEY (`requested_by`) REFERENCES `users` (`id`) ON DELETE SET NULL,
+  CONSTRAINT `fk_refunds_reviewed_by` FOREIGN KEY (`reviewed_by`) REFERENCES `users` (`id`) ON DELETE SET NULL,
+  CONSTRAINT `fk_refunds_payment` FOREIGN KEY (`payment_id`) REFERENCES `payments` (`id`) ON DELETE SET NULL,
+  CONSTRAINT `refunds_chk_1` CHECK ((`requested_amount` > 0)),
+  CONSTRAINT `refunds_chk_2` CHECK (((`approved_amount` is null) or (`approved_amount` > 0)))
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
+/*!40101 SET character_set_client = @saved_cs_client */;
+
+--
+-- Dumping data for table `refunds`
+--
+
+LOCK TABLES `refunds` WRITE;
+/*!40000 ALTER TABLE `refunds` DISABLE KEYS */;
+/*!40000 ALTER TABLE `refunds` ENABLE KEYS */;
+UNLOCK TABLES;
+
+--
+-- Table structure for table `stores`
+--
+
+DROP TABLE IF EXISTS `stores`;
+/*!40101 SET @saved_cs_client     = @@character_set_client */;
+/*!50503 SET character_set_client = utf8mb4 */;
+CREATE TABLE `stores` (
+  `id` int NOT NULL AUTO_INCREMENT,
+  `name` varchar(255) NOT NULL,
+  `owner_id` int DEFAULT NULL,
+  `address` varchar(255) NOT NULL,
+  `phone` varchar(20) DEFAULT NULL,
+  `latitude` decimal(10,8) NOT NULL,
+  `longitude` decimal(11,8) NOT NULL,
+  `is_active` tinyint(1) NOT NULL DEFAULT '1',
+  `created_at` timestamp NULL DEFAULT CURRENT_TIMESTAMP,
+  `updated_at` timestamp NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+  PRIMARY KEY (`id`),
+  KEY `fk_stores_owner` (`owner_id`),
+  CONSTRAINT `fk_stores_owner` FOREIGN KEY (`owner_id`) REFERENCES `users` (`id`) ON DELETE SET NULL
+) ENGINE=InnoDB AUTO_INCREMENT=2 DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
+/*!40101 SET character_set_client = @saved_cs_client */;
+
+--
+-- Dumping data for table `stores`
+--
+
+LOCK TABLES `stores` WRITE;
+/*!40000 ALTER TABLE `stores` DISABLE KEYS */;
+INSERT INTO `stores` VALUES (1,'Cửa hàng trung tâm',35,'Ha Noi','0000000000',21.02851100,105.80481700,1,'2025-09-24 09:36:35','2025-09-24 09:36:35');
+/*!40000 ALTER TABLE `stores` ENABLE KEYS */;
+UNLOCK TABLES;
+
+--
+-- Table structure for table `user_addresses`
+--
+
+DROP TABLE IF EXISTS `user_addresses`;
+/*!40101 SET @saved_cs_client     = @@character_set_client */;
+/*!50503 SET character_set_client = utf8mb4 */;
+CREATE TABLE `user_addresses` (
+  `id` int NOT NULL AUTO_INCREMENT,
+  `user_id` int NOT NULL,
+  `label` varchar(50) NOT NULL,
+  `street` varchar(255) NOT NULL,
+  `driver_note` varchar(255) NOT NULL DEFAULT '',
+  `latitude` decimal(10,8) NOT NULL,
+  `longitude` decimal(11,8) NOT NULL,
+  `is_default` tinyint(1) NOT NULL DEFAULT '0',
+  `created_at` timestamp NULL DEFAULT CURRENT_TIMESTAMP,
+  `updated_at` timestamp NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+  PRIMARY KEY (`id`),
+  KEY `fk_user_addresses_user` (`user_id`),
+  CONSTRAINT `fk_user_addresses_user` FOREIGN KEY (`user_id`) REFERENCES `users` (`id`) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
+/*!40101 SET character_set_client = @saved_cs_client */;
+
+--
+-- Dumping data for table `user_addresses`
+--
+
+LOCK TABLES `user_addresses` WRITE;
+/*!40000 ALTER TABLE `user_addresses` DISABLE KEYS */;
+/*!40000 ALTER TABLE `user_addresses` ENABLE KEYS */;
+UNLOCK TABLES;
+
 --
 -- Table structure for table `users`
 --
@@ -318,8 +1025,11 @@ CREATE TABLE `users` (
   `reset_otp` varchar(10) DEFAULT NULL,
   `reset_otp_expires_at` timestamp NULL DEFAULT NULL,
   `status` tinyint DEFAULT '0' COMMENT '0=inactive, 1=active, 2=banned, 3=suspended',
+  `store_id` int DEFAULT NULL,
   PRIMARY KEY (`id`),
-  UNIQUE KEY `email` (`email`)
+  UNIQUE KEY `email` (`email`),
+  KEY `fk_users_store` (`store_id`),
+  CONSTRAINT `fk_users_store` FOREIGN KEY (`store_id`) REFERENCES `stores` (`id`) ON DELETE SET NULL
 ) ENGINE=InnoDB AUTO_INCREMENT=47 DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
 /*!40101 SET character_set_client = @saved_cs_client */;
 
@@ -329,7 +1039,7 @@ CREATE TABLE `users` (
 
 LOCK TABLES `users` WRITE;
 /*!40000 ALTER TABLE `users` DISABLE KEYS */;
-INSERT INTO `users` VALUES (35,'Admin','admin@example.com','$2a$10$OeGOOr1OBOm6VmxnCJdbOej0325iqiiSQpMtQdQaGSXF7DbRRcmQe','0000000000','Admin Address','admin','2025-09-24 09:36:35',NULL,NULL,NULL,NULL,1),(36,'Admin','admin@gmail.com','$2a$10$KG.aMLHJSAHMb2QwneUVwOZ2zISBuLm5K.15hTT9O6DC.o6CQmbHy','','','admin','2025-09-24 02:37:46',NULL,NULL,NULL,NULL,1),(37,'User1','user1@gmail.com','$2a$10$s50wUtykgFKNELyjFf2z9.PIO82CteRVDu9ATLmOgj0ns0doMOMqW','0987777777','Ha Noi','customer','2025-09-26 00:40:40','529084','2025-09-26 00:50:40',NULL,NULL,1),(38,'admin01','admin1@gmail.com','$2a$10$o8m4W5wobYd10hBL/uemL.F0OtqY0JKGZk6.0cb3dkiy7M4tpOlNu','0987777777','Ha Noi','admin','2025-09-27 01:53:09',NULL,NULL,NULL,NULL,1),(39,'Shipper1','shipper1@gmail.com','$2a$10$flof0WS10vwudJj7394emO4v4ZgIp9HAxi3Y6Gs0edU4mP11tPs3i','0987876768','Ha Noi','shipper','2025-09-27 10:23:26','563546','2025-09-28 04:21:28',NULL,NULL,1),(42,'Shipper2','shipper2@gmail.com','$2a$10$IImb9LsKaz02AA4L1jPEYuYpaUtYT5G7Ly/V6F2WkfMcdk6KdwG5m','0987654321','Ha Dong','shipper','2025-10-03 01:29:39',NULL,NULL,NULL,NULL,1),(43,'Shipper3','Shipper3@gmail.com','$2a$10$ccyZzkfkYm/wdZ09p3kT4O.lcgHZiRAJNZsI1VKSO6xZX6duyZhFS','0997678942','Hoa Binh','shipper','2025-10-03 01:36:41',NULL,NULL,NULL,NULL,1),(44,'Shipper4','shipper4@gmail.com','$2a$10$0Ujgcug5LjUmDamt1TlcMOx6bxvDec1PYm8uTWFG8lTORESZNRjYq','0988897655','Ha Noi','shipper','2025-10-03 07:37:24',NULL,NULL,NULL,NULL,1),(45,'Duck Anh','darke7824@gmail.com','$2a$10$qwLk/ypvgDZTbQgoIlsXFe6S4vuLx4f7OzoV.RSXIVvnGQyp2PVka','0987777772','Ha Noi','customer','2025-10-03 18:59:44',NULL,NULL,NULL,NULL,1),(46,'Nguyen Duc Anh 02','nguyenduca03@gmail.com','$2a$10$UMqGIm2jB8C5ydFh8daXye2Vxcb.V68IVyTngksy.tG4tB.1/Rd/K','0976673117','Ha Noi','customer','2025-10-03 19:11:30',NULL,NULL,NULL,NULL,1);
+INSERT INTO `users` VALUES (35,'Admin','admin@example.com','$2a$10$OeGOOr1OBOm6VmxnCJdbOej0325iqiiSQpMtQdQaGSXF7DbRRcmQe','0000000000','Admin Address','admin','2025-09-24 09:36:35',NULL,NULL,NULL,NULL,1,NULL),(36,'Admin','admin@gmail.com','$2a$10$KG.aMLHJSAHMb2QwneUVwOZ2zISBuLm5K.15hTT9O6DC.o6CQmbHy','','','admin','2025-09-24 02:37:46',NULL,NULL,NULL,NULL,1,NULL),(37,'User1','user1@gmail.com','$2a$10$s50wUtykgFKNELyjFf2z9.PIO82CteRVDu9ATLmOgj0ns0doMOMqW','0987777777','Ha Noi','customer','2025-09-26 00:40:40','529084','2025-09-26 00:50:40',NULL,NULL,1,NULL),(38,'admin01','admin1@gmail.com','$2a$10$o8m4W5wobYd10hBL/uemL.F0OtqY0JKGZk6.0cb3dkiy7M4tpOlNu','0987777777','Ha Noi','admin','2025-09-27 01:53:09',NULL,NULL,NULL,NULL,1,NULL),(39,'Shipper1','shipper1@gmail.com','$2a$10$flof0WS10vwudJj7394emO4v4ZgIp9HAxi3Y6Gs0edU4mP11tPs3i','0987876768','Ha Noi','shipper','2025-09-27 10:23:26','563546','2025-09-28 04:21:28',NULL,NULL,1,1),(42,'Shipper2','shipper2@gmail.com','$2a$10$IImb9LsKaz02AA4L1jPEYuYpaUtYT5G7Ly/V6F2WkfMcdk6KdwG5m','0987654321','Ha Dong','shipper','2025-10-03 01:29:39',NULL,NULL,NULL,NULL,1,1),(43,'Shipper3','Shipper3@gmail.com','$2a$10$ccyZzkfkYm/wdZ09p3kT4O.lcgHZiRAJNZsI1VKSO6xZX6duyZhFS','0997678942','Hoa Binh','shipper','2025-10-03 01:36:41',NULL,NULL,NULL,NULL,1,1),(44,'Shipper4','shipper4@gmail.com','$2a$10$0Ujgcug5LjUmDamt1TlcMOx6bxvDec1PYm8uTWFG8lTORESZNRjYq','0988897655','Ha Noi','shipper','2025-10-03 07:37:24',NULL,NULL,NULL,NULL,1,1),(45,'Duck Anh','darke7824@gmail.com','$2a$10$qwLk/ypvgDZTbQgoIlsXFe6S4vuLx4f7OzoV.RSXIVvnGQyp2PVka','0987777772','Ha Noi','customer','2025-10-03 18:59:44',NULL,NULL,NULL,NULL,1,NULL),(46,'Nguyen Duc Anh 02','nguyenduca03@gmail.com','$2a$10$UMqGIm2jB8C5ydFh8daXye2Vxcb.V68IVyTngksy.tG4tB.1/Rd/K','0976673117','Ha Noi','customer','2025-10-03 19:11:30',NULL,NULL,NULL,NULL,1,NULL);
 /*!40000 ALTER TABLE `users` ENABLE KEYS */;
 UNLOCK TABLES;
 /*!40103 SET TIME_ZONE=@OLD_TIME_ZONE */;
@@ -0,0 +1,128 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"example.com/delivery-app/models"
+	"github.com/gin-gonic/gin"
+)
+
+type AddressRequest struct {
+	Label      string  `json:"label" binding:"required,max=50"`
+	Street     string  `json:"street" binding:"required,max=255"`
+	DriverNote string  `json:"driver_note" binding:"max=255"`
+	Latitude   float64 `json:"latitude" binding:"required,min=-90,max=90"`
+	Longitude  float64 `json:"longitude" binding:"required,min=-180,max=180"`
+	IsDefault  bool    `json:"is_default"`
+}
+
+func (r *AddressRequest) toAddress(userID int64) *models.UserAddress {
+	return &models.UserAddress{
+		UserID:     userID,
+		Label:      strings.TrimSpace(r.Label),
+		Street:     strings.TrimSpace(r.Street),
+		DriverNote: strings.TrimSpace(r.DriverNote),
+		Latitude:   r.Latitude,
+		Longitude:  r.Longitude,
+		IsDefault:  r.IsDefault,
+	}
+}
+
+func GetAddressesHandler(c *gin.Context, db *sql.DB) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+	addresses, err := models.GetUserAddresses(db, userID.(int64))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get addresses"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"addresses": addresses})
+}
+
+func CreateAddressHandler(c *gin.Context, db *sql.DB) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+	var req AddressRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	address := req.toAddress(userID.(int64))
+	addressID, err := models.CreateUserAddress(db, address)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create address"})
+		return
+	}
+	created, err := models.GetUserAddress(db, userID.(int64), addressID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get address"})
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"message": "Created address successfully", "address": created})
+}
+
+func UpdateAddressHandler(c *gin.Context, db *sql.DB) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+	addressID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid address ID"})
+		return
+	}
+	var req AddressRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	address := req.toAddress(userID.(int64))
+	address.ID = addressID
+	if err := models.UpdateUserAddress(db, address); err != nil {
+		if errors.Is(err, models.ErrAddressNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update address"})
+		return
+	}
+	updated, err := models.GetUserAddress(db, userID.(int64), addressID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get address"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Updated address successfully", "address": updated})
+}
+
+func DeleteAddressHandler(c *gin.Context, db *sql.DB) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+	addressID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid address ID"})
+		return
+	}
+	if err := models.DeleteUserAddress(db, userID.(int64), addressID); err != nil {
+		if errors.Is(err, models.ErrAddressNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete address"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Deleted address successfully"})
+}
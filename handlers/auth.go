@@ -98,6 +98,7 @@ type CreateShipperRequest struct {
 	Password string `json:"password"`
 	Phone    string `json:"phone"`
 	Address  string `json:"address"`
+	StoreID  int64  `json:"store_id"` // store mà shipper giao hàng, 0 = mọi store
 }
 
 func CreateShipper(c *gin.Context, db *sql.DB) {
@@ -116,6 +117,15 @@ func CreateShipper(c *gin.Context, db *sql.DB) {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check email"})
 		return
 	}
+	if req.StoreID != 0 {
+		if _, err := models.GetStoreByID(db, req.StoreID); err == sql.ErrNoRows {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Store not found"})
+			return
+		} else if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get store"})
+			return
+		}
+	}
 
 	// hash password
 	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
@@ -131,6 +141,7 @@ func CreateShipper(c *gin.Context, db *sql.DB) {
 		Phone:    req.Phone,
 		Address:  req.Address,
 		Role:     "shipper",
+		StoreID:  req.StoreID,
 	}
 	tx, err := db.Begin()
 	if err != nil {
@@ -0,0 +1,164 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"example.com/delivery-app/config"
+	"example.com/delivery-app/models"
+	"example.com/delivery-app/payments"
+	"github.com/gin-gonic/gin"
+)
+
+// kích thước ảnh QR (px) và dung lượng tối đa của file sao kê
+const (
+	paymentQRSize      = 320
+	maxStatementSize   = 5 << 20
+	statementFormField = "file"
+)
+
+// mã QR VietQR để khách chuyển khoản cho order chưa thanh toán.
+// ?format=png trả thẳng ảnh, mặc định trả JSON gồm payload và ảnh dạng data URL
+func GetOrderPaymentQRHandler(c *gin.Context, db *sql.DB) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+	role, exists := c.Get("role")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+	orderID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid order id"})
+		return
+	}
+	bank := config.Payment
+	if bank.BankBIN == "" || bank.BankAccountNo == "" {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Bank transfer is not available"})
+		return
+	}
+
+	actor := models.Actor{ID: userID.(int64), Role: role.(string)}
+	amount, err := models.GetOrderAmountDue(db, orderID, actor)
+	if err != nil {
+		switch {
+		case errors.Is(err, models.ErrOrderNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case errors.Is(err, models.ErrOrderAccessDenied):
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		case errors.Is(err, models.ErrOrderNotPayable):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get order"})
+		}
+		return
+	}
+
+	reference := payments.OrderReference(orderID)
+	payload := payments.VietQRPayload(bank.BankBIN, bank.BankAccountNo, amount, reference)
+	png, err := payments.VietQRPNG(payload, paymentQRSize)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate QR code"})
+		return
+	}
+	if c.Query("format") == "png" {
+		c.Data(http.StatusOK, "image/png", png)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"order_id":     orderID,
+		"amount":       amount,
+		"reference":    reference,
+		"bank_bin":     bank.BankBIN,
+		"account_no":   bank.BankAccountNo,
+		"account_name": bank.BankAccountName,
+		"payload":      payload,
+		"qr_image":     "data:image/png;base64," + base64.StdEncoding.EncodeToString(png),
+	})
+}
+
+// kết quả đối soát một dòng sao kê
+type BankTransferMatch struct {
+	payments.BankTransaction
+	OrderID        int64   `json:"order_id,omitempty"`
+	ExpectedAmount float64 `json:"expected_amount,omitempty"`
+	// paid || matched (đối soát thử) || refund_requested || refund_needed (đối soát thử)
+	// || no_reference || order_not_found || not_payable || duplicate || amount_mismatch || error
+	Result string `json:"result"`
+}
+
+// admin tải sao kê ngân hàng (CSV, field "file") để đối soát các khoản chuyển khoản
+// với order đang chờ thanh toán theo mã DH<order_id> trong nội dung.
+// ?dry_run=true chỉ trả kết quả khớp, không cập nhật order
+func ReconcileBankTransfersHandler(c *gin.Context, db *sql.DB) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+	dryRun := c.Query("dry_run") == "true"
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxStatementSize)
+	fileHeader, err := c.FormFile(statementFormField)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Bank statement file is required"})
+		return
+	}
+	file, err := fileHeader.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Can't read bank statement"})
+		return
+	}
+	defer file.Close()
+
+	transactions, err := payments.ParseBankStatement(file)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	actor := models.Actor{ID: userID.(int64), Role: models.RoleAdmin}
+	results := make([]BankTransferMatch, 0, len(transactions))
+	summary := map[string]int{}
+	for _, tx := range transactions {
+		match := BankTransferMatch{BankTransaction: tx}
+		orderID, ok := payments.ParseOrderReference(tx.Description)
+		if !ok {
+			match.Result = "no_reference"
+		} else {
+			match.OrderID = orderID
+			total, err := models.ApplyBankTransfer(db, payments.ProviderBankTransfer, orderID, tx.TransactionID, tx.Amount, actor, !dryRun)
+			match.ExpectedAmount = total
+			var transitionErr *models.TransitionError
+			switch {
+			case err == nil && dryRun:
+				match.Result = "matched"
+			case err == nil:
+				match.Result = "paid"
+			case errors.Is(err, models.ErrPaymentRefundRequired) && dryRun:
+				match.Result = "refund_needed"
+			case errors.Is(err, models.ErrPaymentRefundRequired):
+				match.Result = "refund_requested"
+			case errors.Is(err, models.ErrOrderNotFound):
+				match.Result = "order_not_found"
+			case errors.Is(err, models.ErrOrderNotPayable), errors.As(err, &transitionErr):
+				match.Result = "not_payable"
+			case errors.Is(err, models.ErrPaymentDuplicate):
+				match.Result = "duplicate"
+			case errors.Is(err, models.ErrPaymentAmountMismatch):
+				match.Result = "amount_mismatch"
+			default:
+				match.Result = "error"
+			}
+		}
+		summary[match.Result]++
+		results = append(results, match)
+	}
+	c.JSON(http.StatusOK, gin.H{"dry_run": dryRun, "summary": summary, "transactions": results})
+}
@@ -0,0 +1,455 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"example.com/delivery-app/models"
+	"github.com/gin-gonic/gin"
+)
+
+type CartItemRequest struct {
+	ProductID int64   `json:"product_id" binding:"required"`
+	Quantity  int64   `json:"quantity" binding:"required,gt=0"`
+	OptionIDs []int64 `json:"option_ids"` // option đã chọn (size, topping...)
+	Note      string  `json:"note"`       // ghi chú cho bếp, vd "không hành"
+}
+
+type ReplaceCartRequest struct {
+	Products []CartItemRequest `json:"products" binding:"dive"`
+}
+
+type UpdateCartItemRequest struct {
+	Quantity int64   `json:"quantity" binding:"required,gt=0"`
+	Note     *string `json:"note"` // bỏ trống thì giữ ghi chú cũ, "" để xoá
+}
+
+type CheckoutRequest struct {
+	Latitude            float64 `json:"latitude"`
+	Longitude           float64 `json:"longitude"`
+	AddressID           int64   `json:"address_id"`
+	CouponCode          string  `json:"coupon_code"`
+	DeliverySlotID      int64   `json:"delivery_slot_id"`
+	DeliveryDate        string  `json:"delivery_date"`
+	DeliveryInstruction string  `json:"delivery_instruction"`
+}
+
+// loadCart đọc giỏ hàng và đối chiếu với giá, flash sale, option và tồn kho hiện tại.
+// Giá tính giống buildOrderItems: suất sale dùng chung cho các dòng cùng sản phẩm,
+// tiền option cộng vào đơn giá của dòng.
+func loadCart(db *sql.DB, userID int64) ([]models.CartLine, float64, error) {
+	items, err := models.GetCartItems(db, userID)
+	if err != nil {
+		return nil, 0, err
+	}
+	ids := make([]int64, 0, len(items))
+	requested := make(map[int64]int64)
+	for _, item := range items {
+		if _, ok := requested[item.ProductID]; !ok {
+			ids = append(ids, item.ProductID)
+		}
+		requested[item.ProductID] += item.Quantity
+	}
+	products, err := models.GetStockProducts(db, ids)
+	if err != nil {
+		return nil, 0, err
+	}
+	sales, err := models.GetActiveSales(db, ids, time.Now())
+	if err != nil {
+		return nil, 0, err
+	}
+	options, err := models.GetOptionGroups(db, ids)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	saleLeft := make(map[int64]int64)
+	for id, sale := range sales {
+		saleLeft[id] = sale.Remaining
+	}
+	lines := make([]models.CartLine, 0, len(items))
+	var subtotal float64
+	for _, item := range items {
+		line := models.CartLine{ProductID: item.ProductID, Quantity: item.Quantity, OptionIDs: item.OptionIDs, Options: []models.OrderItemOption{}, Note: item.Note}
+		product, ok := products[item.ProductID]
+		if !ok {
+			line.Status = models.CartLineUnavailable
+			lines = append(lines, line)
+			continue
+		}
+		line.Name = product.Name
+		line.StoreID = product.StoreID
+		line.Available = product.Available()
+
+		selected, delta, err := models.SelectOptions(item.ProductID, options[item.ProductID], item.OptionIDs)
+		if err != nil {
+			line.Status = models.CartLineInvalidOptions
+			lines = append(lines, line)
+			continue
+		}
+		line.Options = selected
+		line.OriginalPrice = unitPrice(product.Price, delta)
+		line.Price = line.OriginalPrice
+
+		// giá sale chỉ tính cho số suất còn lại, phần còn lại tính giá gốc (giống khi đặt hàng)
+		line.LineTotal = float64(item.Quantity) * line.OriginalPrice
+		if sale, ok := sales[item.ProductID]; ok && saleLeft[item.ProductID] > 0 {
+			saleQty := min(item.Quantity, saleLeft[item.ProductID])
+			saleLeft[item.ProductID] -= saleQty
+			line.Price = unitPrice(sale.SalePrice, delta)
+			line.LineTotal = float64(saleQty)*line.Price + float64(item.Quantity-saleQty)*line.OriginalPrice
+		}
+
+		// tồn kho tính trên tổng số lượng của sản phẩm trong giỏ
+		switch {
+		case line.Available == 0:
+			line.Status = models.CartLineOutOfStock
+		case line.Available < requested[item.ProductID]:
+			line.Status = models.CartLineInsufficientStock
+		default:
+			line.Status = models.CartLineOK
+			subtotal += line.LineTotal
+		}
+		lines = append(lines, line)
+	}
+	return lines, subtotal, nil
+}
+
+func respondCart(c *gin.Context, db *sql.DB, userID int64) {
+	lines, subtotal, err := loadCart(db, userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get cart"})
+		return
+	}
+	c.JSON(http.StatusOK, cartBody(lines, subtotal))
+}
+
+func cartBody(lines []models.CartLine, subtotal float64) gin.H {
+	canCheckout := len(lines) > 0
+	for _, line := range lines {
+		if line.Status != models.CartLineOK {
+			canCheckout = false
+		}
+	}
+	return gin.H{
+		"items":        lines,
+		"subtotal":     subtotal,
+		"can_checkout": canCheckout,
+	}
+}
+
+// checkCartProducts: sản phẩm phải tồn tại và cùng store với các sản phẩm khác trong giỏ
+func checkCartProducts(db *sql.DB, productIDs []int64) error {
+	products, err := models.GetStockProducts(db, productIDs)
+	if err != nil {
+		return err
+	}
+	var itemErrs []models.OrderItemError
+	var storeID int64
+	storeSet := false
+	for _, id := range productIDs {
+		product, ok := products[id]
+		if !ok {
+			itemErrs = append(itemErrs, models.OrderItemError{ProductID: id, Reason: models.ItemErrNotFound})
+			continue
+		}
+		if !storeSet {
+			storeID = product.StoreID
+			storeSet = true
+		}
+		if product.StoreID != storeID {
+			itemErrs = append(itemErrs, models.OrderItemError{ProductID: id, Reason: models.ItemErrOtherStore})
+		}
+	}
+	if len(itemErrs) > 0 {
+		return &models.OrderItemsError{Items: itemErrs}
+	}
+	return nil
+}
+
+// checkCartOptions kiểm tra option được chọn của các dòng sắp thêm vào giỏ
+func checkCartOptions(db *sql.DB, items []models.CartItem) error {
+	ids := make([]int64, 0, len(items))
+	for _, item := range items {
+		ids = append(ids, item.ProductID)
+	}
+	groups, err := models.GetOptionGroups(db, ids)
+	if err != nil {
+		return err
+	}
+	var itemErrs []models.OrderItemError
+	for _, item := range items {
+		_, _, err := models.SelectOptions(item.ProductID, groups[item.ProductID], item.OptionIDs)
+		var optErr *models.OptionError
+		if errors.As(err, &optErr) {
+			itemErrs = append(itemErrs, models.OrderItemError{ProductID: item.ProductID, Reason: optErr.Reason, Requested: item.Quantity, GroupID: optErr.GroupID, OptionID: optErr.OptionID})
+		}
+	}
+	if len(itemErrs) > 0 {
+		return &models.OrderItemsError{Items: itemErrs}
+	}
+	return nil
+}
+
+// dòng trong giỏ được chọn theo product_id và ?option_ids=1,2 (bỏ trống = dòng không có option)
+func cartOptionIDsQuery(c *gin.Context) ([]int64, error) {
+	ids := []int64{}
+	v := c.Query("option_ids")
+	if v == "" {
+		return ids, nil
+	}
+	for _, part := range strings.Split(v, ",") {
+		id, err := strconv.ParseInt(strings.TrimSpace(part), 10, 64)
+		if err != nil || id <= 0 {
+			return nil, fmt.Errorf("invalid option_ids %q", v)
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+func GetCartHandler(c *gin.Context, db *sql.DB) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+	respondCart(c, db, userID.(int64))
+}
+
+func ReplaceCartHandler(c *gin.Context, db *sql.DB) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+	var req ReplaceCartRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	items := make([]models.CartItem, 0, len(req.Products))
+	ids := make([]int64, 0, len(req.Products))
+	for i, p := range req.Products {
+		note, err := models.NormalizeItemNote(fmt.Sprintf("products[%d].note", i), p.ProductID, p.Note)
+		if err != nil {
+			respondCreateOrderError(c, err)
+			return
+		}
+		items = append(items, models.CartItem{ProductID: p.ProductID, Quantity: p.Quantity, OptionIDs: p.OptionIDs, Note: note})
+		ids = append(ids, p.ProductID)
+	}
+	if err := checkCartProducts(db, ids); err != nil {
+		respondCreateOrderError(c, err)
+		return
+	}
+	if err := checkCartOptions(db, items); err != nil {
+		respondCreateOrderError(c, err)
+		return
+	}
+	if err := models.ReplaceCart(db, userID.(int64), items); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save cart"})
+		return
+	}
+	respondCart(c, db, userID.(int64))
+}
+
+func ClearCartHandler(c *gin.Context, db *sql.DB) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+	if err := models.ClearCart(db, userID.(int64)); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to clear cart"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Cart cleared"})
+}
+
+func AddCartItemHandler(c *gin.Context, db *sql.DB) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+	var req CartItemRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	current, err := models.GetCartItems(db, userID.(int64))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get cart"})
+		return
+	}
+	ids := []int64{req.ProductID}
+	for _, item := range current {
+		if item.ProductID != req.ProductID {
+			ids = append(ids, item.ProductID)
+		}
+	}
+	if err := checkCartProducts(db, ids); err != nil {
+		respondCreateOrderError(c, err)
+		return
+	}
+	note, err := models.NormalizeItemNote("note", req.ProductID, req.Note)
+	if err != nil {
+		respondCreateOrderError(c, err)
+		return
+	}
+	item := models.CartItem{ProductID: req.ProductID, Quantity: req.Quantity, OptionIDs: req.OptionIDs, Note: note}
+	if err := checkCartOptions(db, []models.CartItem{item}); err != nil {
+		respondCreateOrderError(c, err)
+		return
+	}
+	if err := models.AddCartItem(db, userID.(int64), item); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to add item to cart"})
+		return
+	}
+	respondCart(c, db, userID.(int64))
+}
+
+func UpdateCartItemHandler(c *gin.Context, db *sql.DB) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+	productID, err := strconv.ParseInt(c.Param("product_id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid product ID"})
+		return
+	}
+	optionIDs, err := cartOptionIDsQuery(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	var req UpdateCartItemRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.Note != nil {
+		note, err := models.NormalizeItemNote("note", productID, *req.Note)
+		if err != nil {
+			respondCreateOrderError(c, err)
+			return
+		}
+		req.Note = &note
+	}
+	if err := models.UpdateCartItem(db, userID.(int64), productID, optionIDs, req.Quantity, req.Note); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Product is not in cart"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update cart"})
+		return
+	}
+	respondCart(c, db, userID.(int64))
+}
+
+func RemoveCartItemHandler(c *gin.Context, db *sql.DB) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+	productID, err := strconv.ParseInt(c.Param("product_id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid product ID"})
+		return
+	}
+	optionIDs, err := cartOptionIDsQuery(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := models.RemoveCartItem(db, userID.(int64), productID, optionIDs); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Product is not in cart"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update cart"})
+		return
+	}
+	respondCart(c, db, userID.(int64))
+}
+
+// CheckoutCartHandler tạo order từ giỏ hàng và xoá giỏ trong cùng transaction,
+// giỏ được đọc và khoá trong transaction đó
+func CheckoutCartHandler(c *gin.Context, db *sql.DB) {
+	userIDVal, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+	userID := userIDVal.(int64)
+	var req CheckoutRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	tx, err := db.Begin()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Can't start transaction"})
+		return
+	}
+	defer tx.Rollback()
+
+	cart, err := models.LockCartItemsTx(tx, userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get cart"})
+		return
+	}
+	if len(cart) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "cart is empty"})
+		return
+	}
+	orderReq := models.CreateOrderRequest{
+		Latitude:            req.Latitude,
+		Longitude:           req.Longitude,
+		AddressID:           req.AddressID,
+		CouponCode:          req.CouponCode,
+		DeliverySlotID:      req.DeliverySlotID,
+		DeliveryDate:        req.DeliveryDate,
+		DeliveryInstruction: req.DeliveryInstruction,
+	}
+	for _, item := range cart {
+		orderReq.Products = append(orderReq.Products, models.CreateOrderItemRequest{ProductID: item.ProductID, Quantity: item.Quantity, OptionIDs: item.OptionIDs, Note: item.Note})
+	}
+
+	order, err := newCustomerOrder(db, userID, req.Latitude, req.Longitude, orderReq.Products[0].ProductID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "can't get image_id for thumbnail"})
+		return
+	}
+	orderID, err := createOrderTx(db, tx, order, &orderReq)
+	if err != nil {
+		respondCreateOrderError(c, err)
+		return
+	}
+	if err := models.ClearCartTx(tx, userID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to clear cart"})
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Can't commit transaction"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":      "order created successfully",
+		"order_id":     orderID,
+		"subtotal":     order.Subtotal,
+		"delivery_fee": order.DeliveryFee,
+		"discount":     order.Discount,
+		"total_amount": order.TotalAmount,
+	})
+}
@@ -0,0 +1,147 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"example.com/delivery-app/models"
+	"github.com/gin-gonic/gin"
+)
+
+type CODHandoverRequest struct {
+	Amount float64 `json:"amount" binding:"required,gt=0"`
+	Note   string  `json:"note" binding:"max=255"`
+}
+
+func respondCODError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, models.ErrShipperNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, models.ErrHandoverExceedsBalance):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to process cash ledger"})
+	}
+}
+
+// số dư và lịch sử thu/nộp tiền mặt của một shipper (có phân trang)
+func respondShipperCOD(c *gin.Context, db *sql.DB, shipperID int64) {
+	pageStr := c.DefaultQuery("page", "1")
+	limitStr := c.DefaultQuery("limit", "10")
+
+	page, _ := strconv.Atoi(pageStr)
+	limit, _ := strconv.Atoi(limitStr)
+
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 {
+		limit = 10
+	}
+	balance, err := models.GetCODBalance(db, shipperID)
+	if err != nil {
+		respondCODError(c, err)
+		return
+	}
+	entries, total, err := models.GetCODEntries(db, shipperID, page, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get cash ledger"})
+		return
+	}
+	totalPages := (total + limit - 1) / limit
+	c.JSON(http.StatusOK, gin.H{
+		"balance": balance,
+		"entries": entries,
+		"pagination": gin.H{
+			"page":        page,
+			"limit":       limit,
+			"total":       total,
+			"total_pages": totalPages,
+		},
+	})
+}
+
+// shipper xem số tiền mặt mình đang giữ
+func GetMyCODHandler(c *gin.Context, db *sql.DB) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+	respondShipperCOD(c, db, userID.(int64))
+}
+
+// admin xem sổ tiền mặt của một shipper
+func GetShipperCODHandler(c *gin.Context, db *sql.DB) {
+	shipperID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid shipper ID"})
+		return
+	}
+	respondShipperCOD(c, db, shipperID)
+}
+
+// admin xem số tiền mặt các shipper đang giữ
+func GetCODBalancesHandler(c *gin.Context, db *sql.DB) {
+	pageStr := c.DefaultQuery("page", "1")
+	limitStr := c.DefaultQuery("limit", "10")
+
+	page, _ := strconv.Atoi(pageStr)
+	limit, _ := strconv.Atoi(limitStr)
+
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 {
+		limit = 10
+	}
+	balances, total, err := models.GetCODBalances(db, page, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get cash balances"})
+		return
+	}
+	totalPages := (total + limit - 1) / limit
+	c.JSON(http.StatusOK, gin.H{
+		"balances": balances,
+		"pagination": gin.H{
+			"page":        page,
+			"limit":       limit,
+			"total":       total,
+			"total_pages": totalPages,
+		},
+	})
+}
+
+// admin ghi nhận shipper nộp tiền mặt
+func CreateCODHandoverHandler(c *gin.Context, db *sql.DB) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+	shipperID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid shipper ID"})
+		return
+	}
+	var req CODHandoverRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	actor := models.Actor{ID: userID.(int64), Role: models.RoleAdmin}
+	entry, err := models.AddCODHandover(db, shipperID, req.Amount, actor, strings.TrimSpace(req.Note))
+	if err != nil {
+		respondCODError(c, err)
+		return
+	}
+	balance, err := models.GetCODBalance(db, shipperID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get cash balance"})
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"message": "Recorded handover successfully", "entry": entry, "balance": balance})
+}
@@ -0,0 +1,188 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"example.com/delivery-app/models"
+	"github.com/gin-gonic/gin"
+	"github.com/go-sql-driver/mysql"
+)
+
+type CouponRequest struct {
+	Code          string    `json:"code" binding:"required,max=50"`
+	Description   string    `json:"description" binding:"max=255"`
+	DiscountType  string    `json:"discount_type" binding:"required,oneof=percent fixed"`
+	DiscountValue float64   `json:"discount_value" binding:"required,gt=0"`
+	MinOrderValue float64   `json:"min_order_value" binding:"gte=0"`
+	MaxDiscount   float64   `json:"max_discount" binding:"gte=0"`
+	StartsAt      time.Time `json:"starts_at" binding:"required"`
+	EndsAt        time.Time `json:"ends_at" binding:"required,gtfield=StartsAt"`
+	UsageLimit    int       `json:"usage_limit" binding:"gte=0"`
+	PerUserLimit  int       `json:"per_user_limit" binding:"gte=0"`
+	IsActive      *bool     `json:"is_active"` // mặc định là true
+	ProductIDs    []int64   `json:"product_ids"`
+	Categories    []string  `json:"categories" binding:"dive,max=100"`
+}
+
+func (r *CouponRequest) toCoupon() (*models.Coupon, error) {
+	if r.DiscountType == models.CouponPercent && r.DiscountValue > 100 {
+		return nil, errors.New("percent discount must be at most 100")
+	}
+	active := true
+	if r.IsActive != nil {
+		active = *r.IsActive
+	}
+	categories := []string{}
+	for _, category := range r.Categories {
+		if category = strings.TrimSpace(category); category != "" {
+			categories = append(categories, category)
+		}
+	}
+	return &models.Coupon{
+		Code:          r.Code,
+		Description:   r.Description,
+		DiscountType:  r.DiscountType,
+		DiscountValue: r.DiscountValue,
+		MinOrderValue: r.MinOrderValue,
+		MaxDiscount:   r.MaxDiscount,
+		StartsAt:      r.StartsAt,
+		EndsAt:        r.EndsAt,
+		UsageLimit:    r.UsageLimit,
+		PerUserLimit:  r.PerUserLimit,
+		IsActive:      active,
+		ProductIDs:    r.ProductIDs,
+		Categories:    categories,
+	}, nil
+}
+
+// lỗi khi ghi coupon: trùng mã hoặc sản phẩm không tồn tại thì trả 400
+func respondCouponWriteError(c *gin.Context, err error) {
+	var mysqlErr *mysql.MySQLError
+	if errors.As(err, &mysqlErr) {
+		switch mysqlErr.Number {
+		case 1062:
+			c.JSON(http.StatusConflict, gin.H{"error": "Coupon code already exists"})
+			return
+		case 1452:
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Some products in product_ids do not exist"})
+			return
+		}
+	}
+	if errors.Is(err, sql.ErrNoRows) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Coupon not found"})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save coupon"})
+}
+
+func CreateCouponHandler(c *gin.Context, db *sql.DB) {
+	var req CouponRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	coupon, err := req.toCoupon()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if _, err := models.CreateCoupon(db, coupon); err != nil {
+		respondCouponWriteError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"message": "Created coupon successfully", "coupon": coupon})
+}
+
+func UpdateCouponHandler(c *gin.Context, db *sql.DB) {
+	couponID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid coupon ID"})
+		return
+	}
+	var req CouponRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	coupon, err := req.toCoupon()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	coupon.ID = couponID
+	if err := models.UpdateCoupon(db, coupon); err != nil {
+		respondCouponWriteError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Updated coupon successfully", "coupon": coupon})
+}
+
+func DeactivateCouponHandler(c *gin.Context, db *sql.DB) {
+	couponID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid coupon ID"})
+		return
+	}
+	if err := models.DeactivateCoupon(db, couponID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Coupon not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to deactivate coupon"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Deactivated coupon successfully"})
+}
+
+func GetCouponByIDHandler(c *gin.Context, db *sql.DB) {
+	couponID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid coupon ID"})
+		return
+	}
+	coupon, err := models.GetCouponByID(db, couponID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Coupon not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get coupon"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"coupon": coupon})
+}
+
+func GetAllCouponsHandler(c *gin.Context, db *sql.DB) {
+	pageStr := c.DefaultQuery("page", "1")
+	limitStr := c.DefaultQuery("limit", "10")
+
+	page, _ := strconv.Atoi(pageStr)
+	limit, _ := strconv.Atoi(limitStr)
+
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 {
+		limit = 10
+	}
+	coupons, total, err := models.GetAllCoupons(db, page, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get coupons"})
+		return
+	}
+	totalPages := (total + limit - 1) / limit
+	c.JSON(http.StatusOK, gin.H{
+		"coupons": coupons,
+		"pagination": gin.H{
+			"page":        page,
+			"limit":       limit,
+			"total":       total,
+			"total_pages": totalPages,
+		},
+	})
+}
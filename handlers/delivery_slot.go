@@ -0,0 +1,160 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"example.com/delivery-app/config"
+	"example.com/delivery-app/models"
+	"github.com/gin-gonic/gin"
+)
+
+type DeliverySlotRequest struct {
+	StartTime string `json:"start_time" binding:"required"` // HH:MM
+	EndTime   string `json:"end_time" binding:"required"`   // HH:MM
+	Capacity  int    `json:"capacity" binding:"required,gt=0"`
+	IsActive  *bool  `json:"is_active"` // mặc định là true
+}
+
+func (r *DeliverySlotRequest) toSlot() (*models.DeliverySlot, error) {
+	start, err := time.Parse("15:04", r.StartTime)
+	if err != nil {
+		return nil, errors.New("start_time must be HH:MM")
+	}
+	end, err := time.Parse("15:04", r.EndTime)
+	if err != nil {
+		return nil, errors.New("end_time must be HH:MM")
+	}
+	if !end.After(start) {
+		return nil, errors.New("end_time must be after start_time")
+	}
+	active := true
+	if r.IsActive != nil {
+		active = *r.IsActive
+	}
+	return &models.DeliverySlot{
+		StartTime: r.StartTime,
+		EndTime:   r.EndTime,
+		Capacity:  r.Capacity,
+		IsActive:  active,
+	}, nil
+}
+
+// GetStoreDeliverySlotsHandler: ?date=YYYY-MM-DD (mặc định hôm nay)
+func GetStoreDeliverySlotsHandler(c *gin.Context, db *sql.DB) {
+	storeID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid store ID"})
+		return
+	}
+	now := time.Now().In(config.Location)
+	date := now
+	if dateStr := c.Query("date"); dateStr != "" {
+		date, err = time.ParseInLocation("2006-01-02", dateStr, config.Location)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "date must be YYYY-MM-DD"})
+			return
+		}
+	}
+	slots, err := models.GetSlotAvailability(db, storeID, date)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get delivery slots"})
+		return
+	}
+	// khung giờ đã qua hoặc quá xa thì không đặt được
+	lastDay := now.AddDate(0, 0, config.Schedule.MaxDaysAhead)
+	for i := range slots {
+		if !slots[i].ScheduledFor.After(now) || slots[i].ScheduledFor.After(lastDay) {
+			slots[i].Remaining = 0
+		}
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"date":  date.Format("2006-01-02"),
+		"slots": slots,
+	})
+}
+
+func CreateDeliverySlotHandler(c *gin.Context, db *sql.DB) {
+	storeID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid store ID"})
+		return
+	}
+	var req DeliverySlotRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	slot, err := req.toSlot()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if _, err := models.GetStoreByID(db, storeID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Store not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get store"})
+		return
+	}
+	slot.StoreID = storeID
+	if slot.ID, err = models.CreateDeliverySlot(db, slot); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create delivery slot"})
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"message": "Created delivery slot successfully", "slot": slot})
+}
+
+func UpdateDeliverySlotHandler(c *gin.Context, db *sql.DB) {
+	slotID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid delivery slot ID"})
+		return
+	}
+	var req DeliverySlotRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	slot, err := req.toSlot()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	slot.ID = slotID
+	if err := models.UpdateDeliverySlot(db, slot); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Delivery slot not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update delivery slot"})
+		return
+	}
+	updated, err := models.GetDeliverySlotByID(db, slotID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get delivery slot"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Updated delivery slot successfully", "slot": updated})
+}
+
+func DeactivateDeliverySlotHandler(c *gin.Context, db *sql.DB) {
+	slotID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid delivery slot ID"})
+		return
+	}
+	if err := models.DeactivateDeliverySlot(db, slotID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Delivery slot not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to deactivate delivery slot"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Deactivated delivery slot successfully"})
+}
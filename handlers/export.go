@@ -0,0 +1,247 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/csv"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"example.com/delivery-app/config"
+	"example.com/delivery-app/models"
+	"github.com/gin-gonic/gin"
+	"github.com/xuri/excelize/v2"
+)
+
+// số dòng CSV ghi xong thì đẩy xuống client một lần
+const csvFlushEvery = 500
+
+var (
+	orderExportHeader = []string{
+		"ID", "Ngày đặt", "Khách hàng", "SĐT", "Shipper", "Trạng thái", "Thanh toán", "Địa chỉ giao",
+		"Giao lúc", "Tạm tính", "Phí giao", "Giảm giá", "Tổng tiền", "Cập nhật",
+	}
+	itemExportHeader     = []string{"Order ID", "Item ID", "Product ID", "Sản phẩm", "Số lượng", "Đơn giá", "Thành tiền"}
+	customerExportHeader = []string{"ID", "Tên", "Email", "SĐT", "Địa chỉ", "Trạng thái", "Ngày tạo", "Số đơn", "Đã chi"}
+)
+
+// rowWriter ghi từng dòng của một sheet, dùng chung cho CSV và XLSX
+type rowWriter func(values []interface{}) error
+
+func exportTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.In(config.Location).Format("2006-01-02 15:04:05")
+}
+
+func orderExportRow(o *models.AdminOrderSummary) []interface{} {
+	scheduled := ""
+	if o.ScheduledFor != nil {
+		scheduled = exportTime(*o.ScheduledFor)
+	}
+	return []interface{}{
+		o.ID, exportTime(o.CreatedAt), o.CustomerName, o.CustomerPhone, o.ShipperName, o.OrderStatus, o.PaymentStatus, o.DeliveryAddress,
+		scheduled, o.Subtotal, o.DeliveryFee, o.Discount, o.TotalAmount, exportTime(o.UpdatedAt),
+	}
+}
+
+func itemExportRow(i *models.OrderItemExport) []interface{} {
+	return []interface{}{i.OrderID, i.OrderItemID, i.ProductID, i.ProductName, i.Quantity, i.Price, i.Subtotal}
+}
+
+func customerExportRow(u *models.CustomerExport) []interface{} {
+	return []interface{}{u.ID, u.Name, u.Email, u.Phone, u.Address, u.Status, exportTime(u.CreatedAt), u.OrderCount, u.TotalSpent}
+}
+
+func headerRow(header []string) []interface{} {
+	row := make([]interface{}, len(header))
+	for i, h := range header {
+		row[i] = h
+	}
+	return row
+}
+
+// exportSheet là một sheet trong file export: tiêu đề cột và hàm đổ dữ liệu từng dòng
+type exportSheet struct {
+	name   string
+	header []string
+	fill   func(write rowWriter) error
+}
+
+func orderExportSheets(db *sql.DB, filter models.OrderFilter) []exportSheet {
+	return []exportSheet{
+		{name: "Orders", header: orderExportHeader, fill: func(write rowWriter) error {
+			return models.EachAdminOrder(db, filter, func(o *models.AdminOrderSummary) error {
+				return write(orderExportRow(o))
+			})
+		}},
+		{name: "Items", header: itemExportHeader, fill: func(write rowWriter) error {
+			return models.EachAdminOrderItem(db, filter, func(i *models.OrderItemExport) error {
+				return write(itemExportRow(i))
+			})
+		}},
+	}
+}
+
+func customerExportSheets(db *sql.DB) []exportSheet {
+	return []exportSheet{
+		{name: "Customers", header: customerExportHeader, fill: func(write rowWriter) error {
+			return models.EachCustomer(db, func(u *models.CustomerExport) error {
+				return write(customerExportRow(u))
+			})
+		}},
+	}
+}
+
+// export order theo cùng bộ lọc với /admin/orders, gồm cả đơn hẹn giờ chưa tới lúc xử lý.
+// ?format=xlsx (mặc định, sheet Orders và Items) hoặc ?format=csv&sheet=orders|items
+func ExportOrdersHandler(c *gin.Context, db *sql.DB) {
+	filter, err := parseOrderFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	writeExport(c, "orders", orderExportSheets(db, filter))
+}
+
+// export khách hàng, ?format=xlsx|csv
+func ExportCustomersHandler(c *gin.Context, db *sql.DB) {
+	writeExport(c, "customers", customerExportSheets(db))
+}
+
+func writeExport(c *gin.Context, name string, sheets []exportSheet) {
+	filename := fmt.Sprintf("%s-%s", name, time.Now().In(config.Location).Format("20060102-150405"))
+	switch c.DefaultQuery("format", "xlsx") {
+	case "xlsx":
+		writeXLSX(c, filename, sheets)
+	case "csv":
+		sheet := sheets[0]
+		if v := c.Query("sheet"); v != "" {
+			found := false
+			for _, s := range sheets {
+				if strings.EqualFold(v, s.name) {
+					sheet, found = s, true
+					break
+				}
+			}
+			if !found {
+				c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("invalid sheet %q", v)})
+				return
+			}
+		}
+		writeCSV(c, filename, sheet)
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "format must be xlsx or csv"})
+	}
+}
+
+// XLSX ghi qua StreamWriter (excelize tự đẩy ra file tạm khi lớn) nên không giữ cả bảng trong bộ nhớ
+func writeXLSX(c *gin.Context, filename string, sheets []exportSheet) {
+	f := excelize.NewFile()
+	defer f.Close()
+
+	for i, sheet := range sheets {
+		if i == 0 {
+			if err := f.SetSheetName("Sheet1", sheet.name); err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create export"})
+				return
+			}
+		} else if _, err := f.NewSheet(sheet.name); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create export"})
+			return
+		}
+		sw, err := f.NewStreamWriter(sheet.name)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create export"})
+			return
+		}
+		row := 1
+		write := func(values []interface{}) error {
+			cell, err := excelize.CoordinatesToCellName(1, row)
+			if err != nil {
+				return err
+			}
+			row++
+			return sw.SetRow(cell, values)
+		}
+		if err := write(headerRow(sheet.header)); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create export"})
+			return
+		}
+		if err := sheet.fill(write); err != nil {
+			log.Println("Failed to export", sheet.name, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to export " + strings.ToLower(sheet.name)})
+			return
+		}
+		if err := sw.Flush(); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create export"})
+			return
+		}
+	}
+
+	c.Header("Content-Type", "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet")
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.xlsx"`, filename))
+	c.Status(http.StatusOK)
+	if err := f.Write(c.Writer); err != nil {
+		log.Println("Failed to write xlsx export:", err)
+	}
+}
+
+// CSV ghi thẳng ra response từng dòng, có BOM để Excel đọc đúng tiếng Việt.
+// Lỗi giữa chừng thì header đã gửi rồi nên chỉ ghi log và cắt file.
+func writeCSV(c *gin.Context, filename string, sheet exportSheet) {
+	c.Header("Content-Type", "text/csv; charset=utf-8")
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s-%s.csv"`, filename, strings.ToLower(sheet.name)))
+	c.Status(http.StatusOK)
+	if _, err := c.Writer.WriteString("\ufeff"); err != nil {
+		return
+	}
+
+	w := csv.NewWriter(c.Writer)
+	record := make([]string, len(sheet.header))
+	n := 0
+	write := func(values []interface{}) error {
+		for i, v := range values {
+			record[i] = csvValue(v)
+		}
+		if err := w.Write(record[:len(values)]); err != nil {
+			return err
+		}
+		n++
+		if n%csvFlushEvery == 0 {
+			w.Flush()
+			if err := w.Error(); err != nil {
+				return err
+			}
+			c.Writer.Flush()
+		}
+		return nil
+	}
+	if err := w.Write(sheet.header); err != nil {
+		return
+	}
+	if err := sheet.fill(write); err != nil {
+		log.Println("Failed to export", sheet.name, err)
+	}
+	w.Flush()
+	c.Writer.Flush()
+}
+
+// chuỗi bắt đầu bằng = + - @ thêm dấu ' để Excel không chạy như công thức
+func csvValue(v interface{}) string {
+	switch v := v.(type) {
+	case string:
+		if v != "" && strings.ContainsRune("=+-@\t\r", rune(v[0])) {
+			return "'" + v
+		}
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	default:
+		return fmt.Sprint(v)
+	}
+}
@@ -0,0 +1,166 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"example.com/delivery-app/models"
+	"github.com/gin-gonic/gin"
+	"github.com/go-sql-driver/mysql"
+)
+
+type FlashSaleItemRequest struct {
+	ProductID int64   `json:"product_id" binding:"required"`
+	SalePrice float64 `json:"sale_price" binding:"gte=0"`
+	QtyLimit  int64   `json:"qty_limit" binding:"required,gt=0"`
+}
+
+type FlashSaleRequest struct {
+	Name     string                 `json:"name" binding:"required,max=255"`
+	StartsAt time.Time              `json:"starts_at" binding:"required"`
+	EndsAt   time.Time              `json:"ends_at" binding:"required,gtfield=StartsAt"`
+	IsActive *bool                  `json:"is_active"` // mặc định là true
+	Items    []FlashSaleItemRequest `json:"items" binding:"required,min=1,dive"`
+}
+
+// kiểm tra giá sale thấp hơn giá gốc và không trùng sản phẩm
+func (r *FlashSaleRequest) toFlashSale(db *sql.DB) (*models.FlashSale, error) {
+	seen := make(map[int64]bool)
+	fs := &models.FlashSale{
+		Name:     r.Name,
+		StartsAt: r.StartsAt,
+		EndsAt:   r.EndsAt,
+		IsActive: true,
+	}
+	if r.IsActive != nil {
+		fs.IsActive = *r.IsActive
+	}
+	for _, item := range r.Items {
+		if seen[item.ProductID] {
+			return nil, errors.New("duplicate product in flash sale items")
+		}
+		seen[item.ProductID] = true
+		product, err := models.GetProductByID(db, item.ProductID)
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, errors.New("product " + strconv.FormatInt(item.ProductID, 10) + " not found")
+		}
+		if err != nil {
+			return nil, err
+		}
+		if item.SalePrice >= product.Price {
+			return nil, errors.New("sale price of product " + strconv.FormatInt(item.ProductID, 10) + " must be lower than its price")
+		}
+		fs.Items = append(fs.Items, models.FlashSaleItem{
+			ProductID: item.ProductID,
+			SalePrice: item.SalePrice,
+			QtyLimit:  item.QtyLimit,
+		})
+	}
+	return fs, nil
+}
+
+func CreateFlashSaleHandler(c *gin.Context, db *sql.DB) {
+	var req FlashSaleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	fs, err := req.toFlashSale(db)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if _, err := models.CreateFlashSale(db, fs); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create flash sale"})
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"message": "Created flash sale successfully", "flash_sale": fs})
+}
+
+func UpdateFlashSaleHandler(c *gin.Context, db *sql.DB) {
+	flashSaleID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid flash sale ID"})
+		return
+	}
+	var req FlashSaleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	fs, err := req.toFlashSale(db)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	fs.ID = flashSaleID
+	if err := models.UpdateFlashSale(db, fs); err != nil {
+		var mysqlErr *mysql.MySQLError
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			c.JSON(http.StatusNotFound, gin.H{"error": "Flash sale not found"})
+		case errors.As(err, &mysqlErr) && mysqlErr.Number == 3819:
+			// qty_limit mới nhỏ hơn số đã bán
+			c.JSON(http.StatusBadRequest, gin.H{"error": "qty_limit can't be lower than the quantity already sold"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update flash sale"})
+		}
+		return
+	}
+	updated, err := models.GetFlashSaleByID(db, flashSaleID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get flash sale"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Updated flash sale successfully", "flash_sale": updated})
+}
+
+func DeleteFlashSaleHandler(c *gin.Context, db *sql.DB) {
+	flashSaleID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid flash sale ID"})
+		return
+	}
+	if err := models.DeleteFlashSale(db, flashSaleID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Flash sale not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete flash sale"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Deleted flash sale successfully"})
+}
+
+func GetAllFlashSalesHandler(c *gin.Context, db *sql.DB) {
+	pageStr := c.DefaultQuery("page", "1")
+	limitStr := c.DefaultQuery("limit", "10")
+
+	page, _ := strconv.Atoi(pageStr)
+	limit, _ := strconv.Atoi(limitStr)
+
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 {
+		limit = 10
+	}
+	sales, total, err := models.GetAllFlashSales(db, page, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get flash sales"})
+		return
+	}
+	totalPages := (total + limit - 1) / limit
+	c.JSON(http.StatusOK, gin.H{
+		"flash_sales": sales,
+		"pagination": gin.H{
+			"page":        page,
+			"limit":       limit,
+			"total":       total,
+			"total_pages": totalPages,
+		},
+	})
+}
@@ -0,0 +1,86 @@
+package handlers
+
+import (
+	"bytes"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"example.com/delivery-app/config"
+	"example.com/delivery-app/invoice"
+	"example.com/delivery-app/models"
+	"github.com/gin-gonic/gin"
+)
+
+// hoá đơn của order, ?format=pdf (mặc định) hoặc ?format=html (để gửi email).
+// Quyền xem giống chi tiết order, lần đầu xem order đã thanh toán/đã giao thì cấp số hoá đơn.
+func GetOrderInvoiceHandler(c *gin.Context, db *sql.DB) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+	role, exists := c.Get("role")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+	orderID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid order id"})
+		return
+	}
+	format := c.DefaultQuery("format", "pdf")
+	if format != "pdf" && format != "html" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "format must be pdf or html"})
+		return
+	}
+
+	detail, err := models.GetDetailOrder(db, orderID, userID.(int64), role.(string))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{"error": "order not found"})
+		} else if errors.Is(err, models.ErrOrderAccessDenied) {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get order"})
+		}
+		return
+	}
+	inv, err := models.GetOrCreateInvoice(db, orderID, time.Now().In(config.Location))
+	if err != nil {
+		if errors.Is(err, models.ErrInvoiceNotAvailable) || errors.Is(err, models.ErrInvoiceNotReady) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to issue invoice"})
+		}
+		return
+	}
+	var store *models.Store
+	if detail.Order.StoreID != 0 {
+		if store, err = models.GetStoreByID(db, detail.Order.StoreID); err != nil && err != sql.ErrNoRows {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get store"})
+			return
+		}
+	}
+	doc := invoice.New(inv, detail, store)
+
+	var buf bytes.Buffer
+	if format == "html" {
+		if err := invoice.RenderHTML(&buf, doc); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to render invoice"})
+			return
+		}
+		c.Data(http.StatusOK, "text/html; charset=utf-8", buf.Bytes())
+		return
+	}
+	if err := invoice.RenderPDF(&buf, doc); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to render invoice"})
+		return
+	}
+	c.Header("Content-Disposition", fmt.Sprintf(`inline; filename="%s.pdf"`, inv.Number))
+	c.Data(http.StatusOK, "application/pdf", buf.Bytes())
+}
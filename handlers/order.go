@@ -2,11 +2,15 @@ package handlers
 
 import (
 	"database/sql"
+	"errors"
+	"example.com/delivery-app/config"
 	"example.com/delivery-app/models"
 	"example.com/delivery-app/websocket"
+	"fmt"
 	"github.com/gin-gonic/gin"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 )
 
@@ -27,29 +31,169 @@ type ReceiveOrderRequest struct {
 	OrderID int64
 }
 
-func CreateOrderWithItems(db *sql.DB, order *models.Order, items []models.OrderItem) error {
+// trả lỗi tương ứng khi đổi trạng thái order thất bại
+func respondOrderStatusError(c *gin.Context, err error) {
+	var transitionErr *models.TransitionError
+	switch {
+	case errors.As(err, &transitionErr):
+		c.JSON(http.StatusConflict, gin.H{
+			"error": transitionErr.Error(),
+			"from":  transitionErr.From,
+			"to":    transitionErr.To,
+		})
+	case errors.Is(err, models.ErrOrderNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "order not found"})
+	case errors.Is(err, models.ErrOrderAccessDenied):
+		c.JSON(http.StatusForbidden, gin.H{"error": "This order is not your"})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Can't update this order"})
+	}
+}
+
+// CreateOrderWithItems tạo order trong một transaction: khoá các sản phẩm, kiểm tra tồn kho,
+// lấy giá hiện tại, tính phí giao hàng, giữ hàng (tăng qty_sold) rồi mới ghi order và order_items
+func CreateOrderWithItems(db *sql.DB, order *models.Order, req *models.CreateOrderRequest) (int64, error) {
 	// bắt đầu transaction
 	tx, err := db.Begin()
 	if err != nil {
-		return err
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	orderID, err := createOrderTx(db, tx, order, req)
+	if err != nil {
+		return 0, err
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	order.ID = orderID
+	return orderID, nil
+}
+
+// createOrderTx là phần việc của CreateOrderWithItems trong transaction có sẵn,
+// dùng khi cần ghi thêm dữ liệu khác cùng order (vd: xoá giỏ hàng khi checkout)
+func createOrderTx(db *sql.DB, tx *sql.Tx, order *models.Order, req *models.CreateOrderRequest) (int64, error) {
+	if err := models.NormalizeOrderNotes(req); err != nil {
+		return 0, err
+	}
+	order.DeliveryInstruction = req.DeliveryInstruction
+	productIDs := orderProductIDs(req.Products)
+	products, err := models.LockProductsTx(tx, productIDs)
+	if err != nil {
+		return 0, err
+	}
+	// giá flash sale tại thời điểm đặt, khoá suất sale tới khi commit
+	sales, err := models.LockActiveSalesTx(tx, productIDs, time.Now())
+	if err != nil {
+		return 0, err
+	}
+
+	options, err := models.GetOptionGroups(tx, productIDs)
+	if err != nil {
+		return 0, err
+	}
+
+	// kiểm tra từng sản phẩm
+	items, subtotal, storeID, itemErrs := buildOrderItems(products, sales, options, req.Products)
+	if len(itemErrs) > 0 {
+		return 0, &models.OrderItemsError{Items: itemErrs}
+	}
+	store, err := storeLocation(db, storeID)
+	if err != nil {
+		return 0, err
+	}
+	order.StoreID = storeID
+	// giao tới địa chỉ đã lưu: chép địa chỉ vào order
+	if req.AddressID != 0 {
+		address, err := models.GetUserAddress(db, order.UserID, req.AddressID)
+		if err != nil {
+			return 0, err
+		}
+		order.UseAddress(address)
+	}
+
+	// coupon được khoá tới khi commit để không vượt giới hạn lượt dùng
+	var coupon *models.Coupon
+	var discount float64
+	if req.CouponCode != "" {
+		coupon, discount, err = models.ApplyCouponTx(tx, req.CouponCode, order.UserID, couponLines(products, items), subtotal)
+		if err != nil {
+			return 0, err
+		}
+	}
+	applyBreakdown(order, quoteOrder(store, order.Latitude, order.Longitude, subtotal, discount))
+
+	// đặt giao theo khung giờ: khoá khung giờ tới khi commit để không vượt capacity
+	if req.DeliverySlotID != 0 {
+		date, err := time.ParseInLocation("2006-01-02", req.DeliveryDate, config.Location)
+		if err != nil {
+			return 0, models.ErrInvalidDeliveryDate
+		}
+		scheduledFor, err := models.BookDeliverySlotTx(tx, req.DeliverySlotID, storeID, date, time.Now(), config.Schedule.MaxDaysAhead)
+		if err != nil {
+			return 0, err
+		}
+		order.DeliverySlotID = req.DeliverySlotID
+		order.ScheduledFor = &scheduledFor
 	}
 
 	// tạo order
 	orderID, err := models.AddNewOrderToOrderTx(tx, order)
 	if err != nil {
-		tx.Rollback()
-		return err
+		return 0, err
+	}
+	if coupon != nil {
+		if err := models.RedeemCouponTx(tx, coupon.ID, order.UserID, orderID, order.Discount); err != nil {
+			return 0, err
+		}
 	}
 
-	// tạo order_items
+	// tạo order_items và giữ hàng
 	for _, item := range items {
 		item.OrderID = orderID
 		if err := models.AddNewOrderItemsTx(tx, &item); err != nil {
-			tx.Rollback()
-			return err
+			return 0, err
+		}
+		if err := models.ReserveStockTx(tx, item.ProductID, item.Quantity); err != nil {
+			return 0, err
+		}
+		if item.FlashSaleItemID != 0 {
+			if err := models.ReserveFlashSaleTx(tx, item.FlashSaleItemID, item.Quantity); err != nil {
+				return 0, err
+			}
 		}
 	}
-	return tx.Commit()
+
+	// mốc đầu tiên của timeline
+	actor := models.Actor{ID: order.UserID, Role: models.RoleCustomer}
+	if err := models.AddOrderStatusEventTx(tx, orderID, "order_status", "", order.OrderStatus, actor, "order created"); err != nil {
+		return 0, err
+	}
+	return orderID, nil
+}
+
+// đơn hẹn giờ chỉ hiện cho admin/shipper khi còn ít hơn lead time tới giờ giao
+func scheduleReleaseBefore() time.Time {
+	return time.Now().Add(config.Schedule.LeadTime)
+}
+
+// newCustomerOrder tạo order pending của customer, thumbnail lấy theo sản phẩm đầu tiên
+func newCustomerOrder(db *sql.DB, userID int64, latitude, longitude float64, firstProductID int64) (*models.Order, error) {
+	err, thumbnailID := models.GetImageIDByProductID(db, firstProductID)
+	if err != nil {
+		return nil, err
+	}
+	return &models.Order{
+		UserID:        userID,
+		PaymentStatus: models.PaymentStatusUnpaid,
+		OrderStatus:   models.OrderStatusPending,
+		Latitude:      latitude,
+		Longitude:     longitude,
+		ThumbnailID:   int(thumbnailID),
+		CreatedAt:     time.Now(),
+		UpdatedAt:     time.Now(),
+	}, nil
 }
 func CreateOrderHandler(c *gin.Context, db *sql.DB) {
 	var req models.CreateOrderRequest
@@ -63,44 +207,31 @@ func CreateOrderHandler(c *gin.Context, db *sql.DB) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
-
-	// chuyển từ OrderItemRequest -> OrderItem
-	var items []models.OrderItem
-	var totalAmount float64
-	for _, p := range req.Products {
-		items = append(items, models.OrderItem{
-			ProductID: p.ProductID,
-			Quantity:  p.Quantity,
-			Price:     models.GetPriceProduct(db, p.ProductID), // giả sử bạn tính giá sau, hoặc join từ bảng products
-		})
-		totalAmount += float64(p.Quantity) * models.GetPriceProduct(db, p.ProductID)
+	if len(req.Products) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "order must have at least one product"})
+		return
 	}
-	firstProductID := req.Products[0].ProductID
-	err, thumbnailID := models.GetImageIDByProductID(db, firstProductID)
+
+	order, err := newCustomerOrder(db, userID.(int64), req.Latitude, req.Longitude, req.Products[0].ProductID)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "can't get image_id for thumbnail"})
 		return
 	}
-	order := &models.Order{
-		UserID:        userID.(int64),
-		PaymentStatus: "unpaid",
-		OrderStatus:   "pending",
-		Latitude:      req.Latitude,
-		Longitude:     req.Longitude,
-		TotalAmount:   totalAmount,
-		ThumbnailID:   int(thumbnailID),
-		CreatedAt:     time.Now(),
-		UpdatedAt:     time.Now(),
-	}
 	// gọi transaction
-	if err := CreateOrderWithItems(db, order, items); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	orderID, err := CreateOrderWithItems(db, order, &req)
+	if err != nil {
+		respondCreateOrderError(c, err)
 		return
 	}
 
 	// trả về kết quả
 	c.JSON(http.StatusOK, gin.H{
-		"message": "order created successfully",
+		"message":      "order created successfully",
+		"order_id":     orderID,
+		"subtotal":     order.Subtotal,
+		"delivery_fee": order.DeliveryFee,
+		"discount":     order.Discount,
+		"total_amount": order.TotalAmount,
 	})
 
 }
@@ -150,6 +281,8 @@ func GetOrderDetailHandler(c *gin.Context, db *sql.DB) {
 	if err != nil {
 		if err == sql.ErrNoRows {
 			c.JSON(http.StatusNotFound, gin.H{"error": "order not found"})
+		} else if errors.Is(err, models.ErrOrderAccessDenied) {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
 		} else {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		}
@@ -160,6 +293,53 @@ func GetOrderDetailHandler(c *gin.Context, db *sql.DB) {
 	c.JSON(http.StatusOK, orderDetail)
 }
 
+// lịch sử trạng thái của order
+func GetOrderTimelineHandler(c *gin.Context, db *sql.DB) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+	role, exists := c.Get("role")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	orderID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid order id"})
+		return
+	}
+
+	// CheckOrderAccess không kiểm tra order có tồn tại với admin
+	if _, err := models.GetUserIDFromOrderID(db, orderID); err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, gin.H{"error": "order not found"})
+		return
+	} else if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get order"})
+		return
+	}
+	if err := models.CheckOrderAccess(db, orderID, userID.(int64), role.(string)); err != nil {
+		if errors.Is(err, models.ErrOrderAccessDenied) {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	events, err := models.GetOrderTimeline(db, orderID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get timeline of this order"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"order_id": orderID,
+		"timeline": events,
+	})
+}
+
 // func for shipper
 func ReceiveOrderByShipperHandler(c *gin.Context, db *sql.DB, hub *websocket.Hub) {
 	// Lấy userID từ context
@@ -183,31 +363,25 @@ func ReceiveOrderByShipperHandler(c *gin.Context, db *sql.DB, hub *websocket.Hub
 	}
 	orderID := req.OrderID
 
-	// Lấy order theo ID
-	order, err := models.GetOrderByID(db, orderID)
+	// Nhận đơn (kiểm tra trạng thái và số đơn đang giao trong cùng transaction)
+	result, err := models.ClaimOrder(db, orderID, userID, MaxOrders, scheduleReleaseBefore())
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get order"})
-		return
-	}
-	if order == nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "order not found"})
+		if errors.Is(err, models.ErrOrderNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "order not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update order"})
 		return
 	}
-
-	// Kiểm tra số lượng đơn shipper đang nhận
-	num, err := models.CheckNumberOfOrdersShipper(db, userID)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to check number of orders"})
+	switch result {
+	case models.ClaimAlreadyTaken:
+		c.JSON(http.StatusConflict, gin.H{"error": "order has already been taken by another shipper", "result": result.String()})
 		return
-	}
-	if num >= MaxOrders {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "you have reached the maximum number of orders"})
+	case models.ClaimNotClaimable:
+		c.JSON(http.StatusConflict, gin.H{"error": "order is not ready to be picked up", "result": result.String()})
 		return
-	}
-
-	// Cập nhật shipper cho order
-	if err := models.UpdateShipperForOrder(db, orderID, userID); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update order"})
+	case models.ClaimCapacityReached:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "you have reached the maximum number of orders", "result": result.String()})
 		return
 	}
 
@@ -222,8 +396,12 @@ func ReceiveOrderByShipperHandler(c *gin.Context, db *sql.DB, hub *websocket.Hub
 	// }
 	// hub.SendToUser(order.UserID, &msg)
 
-	// Trả về response thành công
-	c.JSON(http.StatusOK, gin.H{"message": "order received successfully"})
+	// Trả về response thành công kèm địa chỉ giao (đơn đã nhận rồi nên lỗi đọc địa chỉ không trả 500)
+	resp := gin.H{"message": "order received successfully", "result": result.String()}
+	if delivery, err := models.GetOrderForShipper(db, orderID); err == nil {
+		resp["order"] = delivery
+	}
+	c.JSON(http.StatusOK, resp)
 }
 
 func UpdateOrderShipper(c *gin.Context, db *sql.DB) {
@@ -239,12 +417,21 @@ func UpdateOrderShipper(c *gin.Context, db *sql.DB) {
 	}
 	check, err := models.CheckShipperOrder(db, userID.(int64), req.OrderID)
 	if err != nil || check == false {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err})
+		c.JSON(http.StatusForbidden, gin.H{"error": "This order is not your"})
 		return
 	}
-	err = models.UpdateStatusOrder(db, int64(req.OrderID), &req.PaymentStatus, &req.OrderStatus)
+	// bỏ trống trường nào thì giữ nguyên trạng thái đó
+	var paymentStatus, orderStatus *string
+	if req.PaymentStatus != "" {
+		paymentStatus = &req.PaymentStatus
+	}
+	if req.OrderStatus != "" {
+		orderStatus = &req.OrderStatus
+	}
+	actor := models.Actor{ID: userID.(int64), Role: models.RoleShipper}
+	err = models.UpdateStatusOrder(db, req.OrderID, actor, paymentStatus, orderStatus)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Can't update this order"})
+		respondOrderStatusError(c, err)
 		return
 	}
 	c.JSON(http.StatusOK, gin.H{"message": "update successfully"})
@@ -257,18 +444,46 @@ func AcceptOrderAdmin(c *gin.Context, db *sql.DB) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid order id"})
 		return
 	}
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
 
-	OrderStatus := "processing"
+	OrderStatus := models.OrderStatusProcessing
+	actor := models.Actor{ID: userID.(int64), Role: models.RoleAdmin}
 
-	err = models.UpdateStatusOrder(db, orderID, nil, &OrderStatus)
+	err = models.UpdateStatusOrder(db, orderID, actor, nil, &OrderStatus)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Can't update this order"})
+		respondOrderStatusError(c, err)
 		return
 	}
 	c.JSON(http.StatusOK, gin.H{"message": "update successfully"})
 
 }
 
+// admin huỷ order đang pending hoặc processing
+func CancelOrderAdmin(c *gin.Context, db *sql.DB) {
+	orderID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid order id"})
+		return
+	}
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	orderStatus := models.OrderStatusCancelled
+	actor := models.Actor{ID: userID.(int64), Role: models.RoleAdmin}
+	if err := models.UpdateStatusOrder(db, orderID, actor, nil, &orderStatus); err != nil {
+		respondOrderStatusError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Cancelled order successfully"})
+}
+
 // func cancle order by user
 func CancleOrderByUserHandler(c *gin.Context, db *sql.DB) {
 	orderIDstr := c.Param("id")
@@ -289,22 +504,14 @@ func CancleOrderByUserHandler(c *gin.Context, db *sql.DB) {
 		return
 	}
 	if bool == false {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "This order is not your"})
-		return
-	}
-	order, err := models.GetOrderByID(db, orderID)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get status of order"})
+		c.JSON(http.StatusForbidden, gin.H{"error": "This order is not your"})
 		return
 	}
-	if order.OrderStatus != "pending" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "You can't cancel this order, because it's not pending"})
-		return
-	}
-	orderStatus := "cancelled"
-	err = models.UpdateStatusOrder(db, orderID, nil, &orderStatus)
+	orderStatus := models.OrderStatusCancelled
+	actor := models.Actor{ID: userID, Role: models.RoleCustomer}
+	err = models.UpdateStatusOrder(db, orderID, actor, nil, &orderStatus)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update this order"})
+		respondOrderStatusError(c, err)
 		return
 	}
 	c.JSON(http.StatusOK, gin.H{"message": "Cancelled order successfully"})
@@ -327,7 +534,12 @@ func GetOrdersByAdminHandler(c *gin.Context, db *sql.DB) {
 		limit = 10
 	}
 
-	orders, total, err := models.GetAllOrders(db, page, limit)
+	filter, err := parseAdminOrderFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	orders, total, err := models.GetAllOrders(db, filter, page, limit)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -346,6 +558,90 @@ func GetOrdersByAdminHandler(c *gin.Context, db *sql.DB) {
 	})
 }
 
+// đọc bộ lọc danh sách order của admin từ query:
+// order_status, payment_status, from/to (YYYY-MM-DD theo giờ cửa hàng, tính cả ngày to),
+// customer (tên hoặc số điện thoại), shipper_id, min_amount, max_amount,
+// sort (id|created_at|updated_at|total_amount|scheduled_for|customer_name), order (asc|desc)
+func parseOrderFilter(c *gin.Context) (models.OrderFilter, error) {
+	filter := models.OrderFilter{
+		OrderStatus:   c.Query("order_status"),
+		PaymentStatus: c.Query("payment_status"),
+		Customer:      strings.TrimSpace(c.Query("customer")),
+		Sort:          c.DefaultQuery("sort", "id"),
+		Desc:          c.DefaultQuery("order", "desc") == "desc",
+	}
+	switch filter.OrderStatus {
+	case "", models.OrderStatusPending, models.OrderStatusProcessing, models.OrderStatusShipping, models.OrderStatusDelivered, models.OrderStatusCancelled:
+	default:
+		return filter, fmt.Errorf("invalid order_status %q", filter.OrderStatus)
+	}
+	switch filter.PaymentStatus {
+	case "", models.PaymentStatusUnpaid, models.PaymentStatusPaid, models.PaymentStatusPartiallyRefunded, models.PaymentStatusRefunded:
+	default:
+		return filter, fmt.Errorf("invalid payment_status %q", filter.PaymentStatus)
+	}
+	if _, ok := models.OrderSortKeys[filter.Sort]; !ok {
+		return filter, fmt.Errorf("invalid sort %q", filter.Sort)
+	}
+	if o := c.Query("order"); o != "" && o != "asc" && o != "desc" {
+		return filter, fmt.Errorf("order must be asc or desc")
+	}
+	if v := c.Query("from"); v != "" {
+		from, err := time.ParseInLocation("2006-01-02", v, config.Location)
+		if err != nil {
+			return filter, fmt.Errorf("from must be YYYY-MM-DD")
+		}
+		filter.From = &from
+	}
+	if v := c.Query("to"); v != "" {
+		to, err := time.ParseInLocation("2006-01-02", v, config.Location)
+		if err != nil {
+			return filter, fmt.Errorf("to must be YYYY-MM-DD")
+		}
+		to = to.AddDate(0, 0, 1)
+		filter.To = &to
+	}
+	if v := c.Query("shipper_id"); v != "" {
+		id, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || id <= 0 {
+			return filter, fmt.Errorf("invalid shipper_id")
+		}
+		filter.ShipperID = id
+	}
+	var err error
+	if filter.MinAmount, err = parseAmountQuery(c, "min_amount"); err != nil {
+		return filter, err
+	}
+	if filter.MaxAmount, err = parseAmountQuery(c, "max_amount"); err != nil {
+		return filter, err
+	}
+	return filter, nil
+}
+
+// danh sách mặc định của admin là việc cần làm nên ẩn đơn hẹn giờ chưa tới lúc xử lý,
+// có lọc (tìm theo khách, trạng thái, shipper...) thì hiện cả đơn hẹn giờ
+func parseAdminOrderFilter(c *gin.Context) (models.OrderFilter, error) {
+	filter, err := parseOrderFilter(c)
+	if err == nil && filter.IsDefault() {
+		releaseBefore := scheduleReleaseBefore()
+		filter.ReleaseBefore = &releaseBefore
+	}
+	return filter, err
+}
+
+// số tiền trong query, không có thì trả nil
+func parseAmountQuery(c *gin.Context, key string) (*float64, error) {
+	v := c.Query(key)
+	if v == "" {
+		return nil, nil
+	}
+	amount, err := strconv.ParseFloat(v, 64)
+	if err != nil || amount < 0 {
+		return nil, fmt.Errorf("invalid %s", key)
+	}
+	return &amount, nil
+}
+
 // get order by shipper
 func GetOrdersByShipperHandler(c *gin.Context, db *sql.DB) {
 	// Lấy query param
@@ -362,7 +658,18 @@ func GetOrdersByShipperHandler(c *gin.Context, db *sql.DB) {
 		limit = 10
 	}
 
-	orders, total, err := models.GetOrdersByShipper(db, page, limit)
+	shipperID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+	// shipper chỉ thấy đơn của store mình
+	storeID, err := models.GetShipperStoreID(db, shipperID.(int64))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get store of shipper"})
+		return
+	}
+	orders, total, err := models.GetOrdersByShipper(db, storeID, scheduleReleaseBefore(), page, limit)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
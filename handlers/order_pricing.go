@@ -0,0 +1,259 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"math"
+	"net/http"
+	"time"
+
+	"example.com/delivery-app/models"
+	"example.com/delivery-app/pricing"
+	"github.com/gin-gonic/gin"
+)
+
+// danh sách product_id (không trùng) theo thứ tự trong giỏ
+func orderProductIDs(reqItems []models.CreateOrderItemRequest) []int64 {
+	seen := make(map[int64]bool)
+	var ids []int64
+	for _, p := range reqItems {
+		if !seen[p.ProductID] {
+			seen[p.ProductID] = true
+			ids = append(ids, p.ProductID)
+		}
+	}
+	return ids
+}
+
+// buildOrderItems kiểm tra từng dòng trong giỏ với giá và tồn kho hiện tại,
+// trả về các order_item (chưa có order_id), subtotal, store của đơn và lỗi theo từng sản phẩm.
+// Mọi sản phẩm phải thuộc cùng một store (store của sản phẩm đầu tiên).
+// Sản phẩm đang flash sale được tính giá sale tới hết suất còn lại, phần vượt quá tính giá gốc
+// (dòng đó được tách làm 2 order_item). Option đã chọn được cộng vào đơn giá của dòng.
+func buildOrderItems(products map[int64]*models.StockProduct, sales map[int64]*models.ActiveSale, options map[int64][]models.OptionGroup, reqItems []models.CreateOrderItemRequest) ([]models.OrderItem, float64, int64, []models.OrderItemError) {
+	var itemErrs []models.OrderItemError
+	var storeID int64
+	storeSet := false
+	requested := make(map[int64]int64)
+	selected := make([][]models.OrderItemOption, len(reqItems))
+	deltas := make([]float64, len(reqItems))
+	for i, p := range reqItems {
+		if p.Quantity <= 0 {
+			itemErrs = append(itemErrs, models.OrderItemError{ProductID: p.ProductID, Reason: models.ItemErrInvalidQuantity, Requested: p.Quantity})
+			continue
+		}
+		requested[p.ProductID] += p.Quantity
+		if _, ok := products[p.ProductID]; !ok {
+			continue
+		}
+		var err error
+		selected[i], deltas[i], err = models.SelectOptions(p.ProductID, options[p.ProductID], p.OptionIDs)
+		var optErr *models.OptionError
+		if errors.As(err, &optErr) {
+			itemErrs = append(itemErrs, models.OrderItemError{ProductID: p.ProductID, Reason: optErr.Reason, Requested: p.Quantity, GroupID: optErr.GroupID, OptionID: optErr.OptionID})
+		}
+	}
+	for _, id := range orderProductIDs(reqItems) {
+		qty, ok := requested[id]
+		if !ok {
+			continue
+		}
+		product, ok := products[id]
+		if ok && !storeSet {
+			storeID = product.StoreID
+			storeSet = true
+		}
+		switch {
+		case !ok:
+			itemErrs = append(itemErrs, models.OrderItemError{ProductID: id, Reason: models.ItemErrNotFound, Requested: qty})
+		case product.StoreID != storeID:
+			itemErrs = append(itemErrs, models.OrderItemError{ProductID: id, Reason: models.ItemErrOtherStore, Requested: qty})
+		case product.Available() < qty:
+			itemErrs = append(itemErrs, models.OrderItemError{ProductID: id, Reason: models.ItemErrOutOfStock, Requested: qty, Available: product.Available()})
+		}
+	}
+	if len(itemErrs) > 0 {
+		return nil, 0, 0, itemErrs
+	}
+
+	saleLeft := make(map[int64]int64)
+	for id, sale := range sales {
+		saleLeft[id] = sale.Remaining
+	}
+	var items []models.OrderItem
+	var subtotal float64
+	for i, p := range reqItems {
+		qty := p.Quantity
+		if sale, ok := sales[p.ProductID]; ok && saleLeft[p.ProductID] > 0 {
+			saleQty := min(qty, saleLeft[p.ProductID])
+			saleLeft[p.ProductID] -= saleQty
+			price := unitPrice(sale.SalePrice, deltas[i])
+			items = append(items, models.OrderItem{
+				ProductID:       p.ProductID,
+				Quantity:        saleQty,
+				Price:           price,
+				FlashSaleItemID: sale.ItemID,
+				Note:            p.Note,
+				Options:         selected[i],
+			})
+			subtotal += float64(saleQty) * price
+			qty -= saleQty
+		}
+		if qty == 0 {
+			continue
+		}
+		price := unitPrice(products[p.ProductID].Price, deltas[i])
+		items = append(items, models.OrderItem{
+			ProductID: p.ProductID,
+			Quantity:  qty,
+			Price:     price,
+			Note:      p.Note,
+			Options:   selected[i],
+		})
+		subtotal += float64(qty) * price
+	}
+	return items, subtotal, storeID, nil
+}
+
+// đơn giá gồm tiền option, không âm khi option giảm giá (vd size nhỏ)
+func unitPrice(base, delta float64) float64 {
+	return math.Max(math.Round((base+delta)*100)/100, 0)
+}
+
+// các dòng trong giỏ để tính coupon
+func couponLines(products map[int64]*models.StockProduct, items []models.OrderItem) []models.CouponLine {
+	lines := make([]models.CouponLine, 0, len(items))
+	for _, item := range items {
+		lines = append(lines, models.CouponLine{
+			ProductID: item.ProductID,
+			Category:  products[item.ProductID].Category,
+			Amount:    float64(item.Quantity) * item.Price,
+		})
+	}
+	return lines
+}
+
+// vị trí giao hàng đi của store, sản phẩm chưa gán store thì dùng vị trí trong config
+func storeLocation(db *sql.DB, storeID int64) (pricing.Location, error) {
+	if storeID == 0 {
+		return pricing.StoreLocation(), nil
+	}
+	store, err := models.GetStoreByID(db, storeID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return pricing.Location{}, models.ErrStoreUnavailable
+	}
+	if err != nil {
+		return pricing.Location{}, err
+	}
+	if !store.IsActive {
+		return pricing.Location{}, models.ErrStoreUnavailable
+	}
+	return pricing.Location{Latitude: store.Latitude, Longitude: store.Longitude}, nil
+}
+
+// tính phí giao hàng và tổng tiền cho order
+func quoteOrder(store pricing.Location, latitude, longitude, subtotal, discount float64) pricing.Breakdown {
+	dest := pricing.Location{Latitude: latitude, Longitude: longitude}
+	return pricing.Quote(pricing.CurrentSchedule(), store, dest, subtotal, discount)
+}
+
+func applyBreakdown(order *models.Order, b pricing.Breakdown) {
+	order.Subtotal = b.Subtotal
+	order.DeliveryFee = b.DeliveryFee
+	order.Discount = b.Discount
+	order.TotalAmount = b.Total
+}
+
+// báo giá trước khi đặt hàng, không giữ hàng
+func QuoteOrderHandler(c *gin.Context, db *sql.DB) {
+	var req models.CreateOrderRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if len(req.Products) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "order must have at least one product"})
+		return
+	}
+	if err := models.NormalizeOrderNotes(&req); err != nil {
+		respondCreateOrderError(c, err)
+		return
+	}
+
+	productIDs := orderProductIDs(req.Products)
+	products, err := models.GetStockProducts(db, productIDs)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get products"})
+		return
+	}
+	sales, err := models.GetActiveSales(db, productIDs, time.Now())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get flash sales"})
+		return
+	}
+	options, err := models.GetOptionGroups(db, productIDs)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get product options"})
+		return
+	}
+	items, subtotal, storeID, itemErrs := buildOrderItems(products, sales, options, req.Products)
+	if len(itemErrs) > 0 {
+		respondCreateOrderError(c, &models.OrderItemsError{Items: itemErrs})
+		return
+	}
+	store, err := storeLocation(db, storeID)
+	if err != nil {
+		respondCreateOrderError(c, err)
+		return
+	}
+
+	userID, _ := c.Get("userID")
+	if req.AddressID != 0 {
+		address, err := models.GetUserAddress(db, userID.(int64), req.AddressID)
+		if err != nil {
+			respondCreateOrderError(c, err)
+			return
+		}
+		req.Latitude, req.Longitude = address.Latitude, address.Longitude
+	}
+
+	var discount float64
+	if req.CouponCode != "" {
+		_, discount, err = models.ValidateCoupon(db, req.CouponCode, userID.(int64), couponLines(products, items), subtotal)
+		if err != nil {
+			respondCreateOrderError(c, err)
+			return
+		}
+	}
+
+	breakdown := quoteOrder(store, req.Latitude, req.Longitude, subtotal, discount)
+	c.JSON(http.StatusOK, gin.H{
+		"store_id":     storeID,
+		"items":        items,
+		"coupon_code":  models.NormalizeCouponCode(req.CouponCode),
+		"breakdown":    breakdown,
+		"fee_schedule": pricing.CurrentSchedule(),
+	})
+}
+
+// trả lỗi khi không tạo/báo giá được order: lỗi của giỏ hàng trả 400, còn lại 500
+func respondCreateOrderError(c *gin.Context, err error) {
+	var itemsErr *models.OrderItemsError
+	var couponErr *models.CouponError
+	var noteErr *models.NoteError
+	switch {
+	case errors.As(err, &itemsErr):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "some products can't be ordered", "items": itemsErr.Items})
+	case errors.As(err, &noteErr):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "field": noteErr.Field, "max_length": noteErr.Max})
+	case errors.As(err, &couponErr):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "coupon can't be applied", "coupon_code": couponErr.Code, "reason": couponErr.Reason})
+	case errors.Is(err, models.ErrStoreUnavailable), errors.Is(err, models.ErrSlotUnavailable), errors.Is(err, models.ErrInvalidDeliveryDate),
+		errors.Is(err, models.ErrAddressNotFound):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, models.ErrSlotFull):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
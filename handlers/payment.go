@@ -0,0 +1,194 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+
+	"example.com/delivery-app/config"
+	"example.com/delivery-app/models"
+	"example.com/delivery-app/payments"
+	"github.com/gin-gonic/gin"
+)
+
+type CreatePaymentRequest struct {
+	Provider string `json:"provider" binding:"required"`
+}
+
+// customer tạo giao dịch thanh toán online cho order của mình
+func CreatePaymentHandler(c *gin.Context, db *sql.DB) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+	orderID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid order id"})
+		return
+	}
+	var req CreatePaymentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	provider, err := payments.Get(req.Provider)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "providers": payments.Names()})
+		return
+	}
+
+	actor := models.Actor{ID: userID.(int64), Role: models.RoleCustomer}
+	payment, err := models.CreatePendingPayment(db, orderID, actor, provider.Name())
+	if err != nil {
+		switch {
+		case errors.Is(err, models.ErrOrderNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case errors.Is(err, models.ErrOrderAccessDenied):
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		case errors.Is(err, models.ErrOrderNotPayable):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create payment"})
+		}
+		return
+	}
+
+	intent, err := provider.CreateIntent(c.Request.Context(), payments.Intent{
+		PaymentID:   payment.ID,
+		OrderID:     orderID,
+		Amount:      payment.Amount,
+		Description: fmt.Sprintf("Thanh toan don hang DH%d", orderID),
+		ReturnURL:   config.Payment.ReturnURL,
+		ClientIP:    c.ClientIP(),
+	})
+	if err != nil {
+		if err := models.MarkPaymentFailed(db, payment.ID, "create_failed"); err != nil {
+			log.Println("Failed to mark payment as failed:", err)
+		}
+		c.JSON(http.StatusBadGateway, gin.H{"error": "Payment provider is unavailable"})
+		return
+	}
+	if err := models.SetPaymentIntent(db, payment.ID, intent.ProviderRef, intent.PaymentURL); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save payment"})
+		return
+	}
+	payment.ProviderRef = intent.ProviderRef
+	payment.PaymentURL = intent.PaymentURL
+	resp := gin.H{"payment": payment}
+	if intent.Deeplink != "" {
+		resp["deeplink"] = intent.Deeplink
+	}
+	c.JSON(http.StatusCreated, resp)
+}
+
+// các giao dịch của order, giao dịch còn pending thì hỏi lại cổng thanh toán (phòng khi lỡ webhook)
+func GetOrderPaymentsHandler(c *gin.Context, db *sql.DB) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+	role, exists := c.Get("role")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+	orderID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid order id"})
+		return
+	}
+	if err := models.CheckOrderAccess(db, orderID, userID.(int64), role.(string)); err != nil {
+		if errors.Is(err, models.ErrOrderAccessDenied) {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+	list, err := models.GetPaymentsByOrder(db, orderID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get payments"})
+		return
+	}
+	for i, p := range list {
+		if p.Status != models.PaymentPending || p.ProviderRef == "" {
+			continue
+		}
+		provider, err := payments.Get(p.Provider)
+		if err != nil {
+			continue
+		}
+		status, err := provider.QueryStatus(c.Request.Context(), p.ProviderRef)
+		if err != nil || status == payments.StatusPending {
+			continue
+		}
+		updated, err := models.ApplyPaymentResult(db, p.Provider, p.ProviderRef, p.Amount, status, "query:"+status)
+		if err != nil && !errors.Is(err, models.ErrPaymentConfirmed) {
+			log.Println("Failed to apply payment status:", err)
+			continue
+		}
+		list[i] = *updated
+	}
+	c.JSON(http.StatusOK, gin.H{"order_id": orderID, "payments": list})
+}
+
+// cổng thanh toán báo kết quả giao dịch, không cần đăng nhập nhưng phải đúng chữ ký
+func PaymentWebhookHandler(c *gin.Context, db *sql.DB) {
+	provider, err := payments.Get(c.Param("provider"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Can't read request body"})
+		return
+	}
+
+	err = handlePaymentWebhook(c, db, provider, body)
+	if responder, ok := provider.(payments.WebhookResponder); ok {
+		c.JSON(responder.WebhookResponse(err))
+		return
+	}
+	switch {
+	case err == nil, errors.Is(err, payments.ErrAlreadyConfirmed):
+		c.JSON(http.StatusOK, gin.H{"message": "ok"})
+	case errors.Is(err, payments.ErrInvalidSignature):
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+	case errors.Is(err, payments.ErrPaymentNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, payments.ErrAmountMismatch):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to process webhook"})
+	}
+}
+
+// kiểm tra chữ ký rồi ghi kết quả, lỗi của models được đổi sang lỗi của payments
+// để cổng thanh toán trả đúng mã phản hồi
+func handlePaymentWebhook(c *gin.Context, db *sql.DB, provider payments.Provider, body []byte) error {
+	event, err := provider.VerifyWebhook(c.Request, body)
+	if err != nil {
+		return err
+	}
+	_, err = models.ApplyPaymentResult(db, provider.Name(), event.ProviderRef, event.Amount, event.Status, event.ResultCode)
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, models.ErrPaymentNotFound):
+		return payments.ErrPaymentNotFound
+	case errors.Is(err, models.ErrPaymentAmountMismatch):
+		return payments.ErrAmountMismatch
+	case errors.Is(err, models.ErrPaymentConfirmed):
+		return payments.ErrAlreadyConfirmed
+	default:
+		log.Println("Failed to apply payment webhook:", err)
+		return err
+	}
+}
@@ -19,9 +19,11 @@ import (
 type NewProductRequest struct {
 	Name        string  `form:"name" binding:"required"`
 	Description string  `form:"description" binding:"required"`
+	Category    string  `form:"category" binding:"max=100"`
 	Price       float64 `form:"price" binding:"required,gt=0"`
 	QtyInitial  int64   `form:"qty_initial" binding:"required,gte=0"`
 	QtySold     int64   `form:"qty_sold" binding:"gte=0"`
+	StoreID     int64   `form:"store_id" binding:"required"`
 }
 
 // upload image to cloudinary
@@ -58,6 +60,11 @@ func CreateNewProductHandler(c *gin.Context, db *sql.DB) {
 		return
 	}
 
+	if _, err := models.GetStoreByID(db, req.StoreID); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Store not found"})
+		return
+	}
+
 	// Bắt đầu transaction
 	tx, err := db.Begin()
 	if err != nil {
@@ -70,9 +77,11 @@ func CreateNewProductHandler(c *gin.Context, db *sql.DB) {
 	product := models.Product{
 		Name:        req.Name,
 		Description: req.Description,
+		Category:    req.Category,
 		Price:       req.Price,
 		QtyInitial:  req.QtyInitial,
 		QtySold:     req.QtySold,
+		StoreID:     req.StoreID,
 		CreatedAt:   time.Now(),
 	}
 
@@ -137,7 +146,14 @@ func GetProductsHandler(c *gin.Context, db *sql.DB) {
 		limit = 10
 	}
 
-	products, total, err := models.GetProductsPaginated(db, page, limit)
+	// lọc theo store nếu có ?store_id=
+	storeID, err := strconv.ParseInt(c.DefaultQuery("store_id", "0"), 10, 64)
+	if err != nil || storeID < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid store ID"})
+		return
+	}
+
+	products, total, err := models.GetProductsPaginated(db, storeID, page, limit)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -183,21 +199,35 @@ func GetProductByIDHandler(c *gin.Context, db *sql.DB) {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get rate"})
 		return
 	}
+	optionGroups, err := models.GetOptionGroups(db, []int64{id})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get product options"})
+		return
+	}
 	productRes := models.ProductResponse{
-		ID:          product.ID,
-		Name:        product.Name,
-		Description: product.Description,
-		Price:       product.Price,
-		QtyInitial:  product.QtyInitial,
-		QtySold:     product.QtySold,
-		CreatedAt:   product.CreatedAt,
-		Images:      images,
-		AvgRate:     avgRate,
-		ReviewCount: count,
+		ID:           product.ID,
+		Name:         product.Name,
+		Description:  product.Description,
+		Category:     product.Category,
+		Price:        product.Price,
+		QtyInitial:   product.QtyInitial,
+		QtySold:      product.QtySold,
+		StoreID:      product.StoreID,
+		CreatedAt:    product.CreatedAt,
+		Images:       images,
+		AvgRate:      avgRate,
+		ReviewCount:  count,
+		OptionGroups: optionGroups[id],
+	}
+	// giá tại thời điểm xem, có tính flash sale
+	withSale := []models.ProductResponse{productRes}
+	if err := models.ApplyFlashSales(db, withSale, time.Now()); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get flash sale"})
+		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"product": productRes,
+		"product": withSale[0],
 	})
 }
 func DeleteProductHandler(c *gin.Context, db *sql.DB, cld *cloudinary.Cloudinary) {
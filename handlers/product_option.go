@@ -0,0 +1,132 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"example.com/delivery-app/models"
+	"github.com/gin-gonic/gin"
+)
+
+type ProductOptionRequest struct {
+	ID          int64   `json:"id"` // có id thì sửa option cũ, không có thì thêm mới
+	Name        string  `json:"name" binding:"required,max=100"`
+	PriceDelta  float64 `json:"price_delta"`
+	IsAvailable *bool   `json:"is_available"` // mặc định là true
+	SortOrder   int     `json:"sort_order"`
+}
+
+type OptionGroupRequest struct {
+	ID        int64                  `json:"id"`
+	Name      string                 `json:"name" binding:"required,max=100"`
+	MinSelect int                    `json:"min_select" binding:"gte=0"`
+	MaxSelect int                    `json:"max_select" binding:"required,gt=0"`
+	SortOrder int                    `json:"sort_order"`
+	Options   []ProductOptionRequest `json:"options" binding:"required,min=1,dive"`
+}
+
+type ProductOptionsRequest struct {
+	Groups []OptionGroupRequest `json:"groups" binding:"dive"`
+}
+
+// kiểm tra min/max hợp lệ với số option của nhóm và không trùng tên
+func (r *ProductOptionsRequest) toOptionGroups() ([]models.OptionGroup, error) {
+	groups := make([]models.OptionGroup, 0, len(r.Groups))
+	groupNames := make(map[string]bool)
+	for i, g := range r.Groups {
+		name := strings.TrimSpace(g.Name)
+		if name == "" || groupNames[strings.ToLower(name)] {
+			return nil, fmt.Errorf("groups[%d]: name is empty or duplicated", i)
+		}
+		groupNames[strings.ToLower(name)] = true
+		if g.MinSelect > g.MaxSelect {
+			return nil, fmt.Errorf("groups[%d]: min_select can't be greater than max_select", i)
+		}
+		if g.MinSelect > len(g.Options) {
+			return nil, fmt.Errorf("groups[%d]: min_select is greater than the number of options", i)
+		}
+		group := models.OptionGroup{
+			ID:        g.ID,
+			Name:      name,
+			MinSelect: g.MinSelect,
+			MaxSelect: min(g.MaxSelect, len(g.Options)),
+			SortOrder: g.SortOrder,
+		}
+		optionNames := make(map[string]bool)
+		for j, o := range g.Options {
+			optionName := strings.TrimSpace(o.Name)
+			if optionName == "" || optionNames[strings.ToLower(optionName)] {
+				return nil, fmt.Errorf("groups[%d].options[%d]: name is empty or duplicated", i, j)
+			}
+			optionNames[strings.ToLower(optionName)] = true
+			isAvailable := true
+			if o.IsAvailable != nil {
+				isAvailable = *o.IsAvailable
+			}
+			group.Options = append(group.Options, models.ProductOption{
+				ID:          o.ID,
+				Name:        optionName,
+				PriceDelta:  o.PriceDelta,
+				IsAvailable: isAvailable,
+				SortOrder:   o.SortOrder,
+			})
+		}
+		groups = append(groups, group)
+	}
+	return groups, nil
+}
+
+// GetProductOptionsHandler trả về các nhóm option của sản phẩm
+func GetProductOptionsHandler(c *gin.Context, db *sql.DB) {
+	productID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid product ID"})
+		return
+	}
+	groups, err := models.GetOptionGroups(db, []int64{productID})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get product options"})
+		return
+	}
+	list := groups[productID]
+	if list == nil {
+		list = []models.OptionGroup{}
+	}
+	c.JSON(http.StatusOK, gin.H{"product_id": productID, "groups": list})
+}
+
+// UpdateProductOptionsHandler thay toàn bộ nhóm option của sản phẩm (size, topping, mức đường...).
+// Gửi danh sách rỗng để bỏ hết option.
+func UpdateProductOptionsHandler(c *gin.Context, db *sql.DB) {
+	productID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid product ID"})
+		return
+	}
+	var req ProductOptionsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	groups, err := req.toOptionGroups()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := models.ReplaceProductOptions(db, productID, groups); err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			c.JSON(http.StatusNotFound, gin.H{"error": "Product not found"})
+		case errors.Is(err, models.ErrOptionGroupNotFound):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update product options"})
+		}
+		return
+	}
+	GetProductOptionsHandler(c, db)
+}
@@ -0,0 +1,213 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"example.com/delivery-app/models"
+	"github.com/gin-gonic/gin"
+)
+
+type CreateRefundRequest struct {
+	Reason string                     `json:"reason" binding:"required,max=500"`
+	Items  []models.RefundItemRequest `json:"items" binding:"dive"` // bỏ trống = hoàn toàn bộ số tiền còn lại
+}
+
+type ApproveRefundRequest struct {
+	Amount *float64 `json:"amount"` // bỏ trống = hoàn đúng số tiền được yêu cầu
+	Note   string   `json:"note" binding:"max=500"`
+}
+
+type RejectRefundRequest struct {
+	Note string `json:"note" binding:"required,max=500"`
+}
+
+// trả lỗi tương ứng khi tạo/duyệt refund thất bại
+func respondRefundError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, models.ErrOrderNotFound), errors.Is(err, models.ErrRefundNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, models.ErrOrderAccessDenied):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case errors.Is(err, models.ErrRefundPending), errors.Is(err, models.ErrRefundAlreadyReviewed):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, models.ErrRefundNotAllowed), errors.Is(err, models.ErrRefundAmountExceeded), errors.Is(err, models.ErrInvalidRefundItems):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to process refund"})
+	}
+}
+
+// customer (chủ order) hoặc admin mở yêu cầu hoàn tiền
+func CreateRefundHandler(c *gin.Context, db *sql.DB) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+	role, exists := c.Get("role")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+	orderID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid order id"})
+		return
+	}
+	var req CreateRefundRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	reason := strings.TrimSpace(req.Reason)
+	if reason == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "reason is required"})
+		return
+	}
+	actor := models.Actor{ID: userID.(int64), Role: role.(string)}
+	refund, err := models.CreateRefund(db, orderID, actor, reason, req.Items)
+	if err != nil {
+		respondRefundError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"message": "Refund requested successfully", "refund": refund})
+}
+
+func GetOrderRefundsHandler(c *gin.Context, db *sql.DB) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+	role, exists := c.Get("role")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+	orderID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid order id"})
+		return
+	}
+	if err := models.CheckOrderAccess(db, orderID, userID.(int64), role.(string)); err != nil {
+		respondRefundError(c, err)
+		return
+	}
+	refunds, err := models.GetRefundsByOrder(db, orderID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get refunds"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"order_id": orderID, "refunds": refunds})
+}
+
+// admin xem các yêu cầu hoàn tiền, lọc theo ?status=requested|approved|rejected
+func GetRefundsHandler(c *gin.Context, db *sql.DB) {
+	pageStr := c.DefaultQuery("page", "1")
+	limitStr := c.DefaultQuery("limit", "10")
+
+	page, _ := strconv.Atoi(pageStr)
+	limit, _ := strconv.Atoi(limitStr)
+
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 {
+		limit = 10
+	}
+	status := c.Query("status")
+	switch status {
+	case "", models.RefundStatusRequested, models.RefundStatusApproved, models.RefundStatusRejected:
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid refund status"})
+		return
+	}
+	refunds, total, err := models.GetRefunds(db, status, page, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get refunds"})
+		return
+	}
+	totalPages := (total + limit - 1) / limit
+	c.JSON(http.StatusOK, gin.H{
+		"refunds": refunds,
+		"pagination": gin.H{
+			"page":        page,
+			"limit":       limit,
+			"total":       total,
+			"total_pages": totalPages,
+		},
+	})
+}
+
+func GetRefundByIDHandler(c *gin.Context, db *sql.DB) {
+	refundID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid refund ID"})
+		return
+	}
+	refund, err := models.GetRefundByID(db, refundID)
+	if err != nil {
+		respondRefundError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"refund": refund})
+}
+
+func ApproveRefundHandler(c *gin.Context, db *sql.DB) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+	refundID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid refund ID"})
+		return
+	}
+	// body rỗng = duyệt đúng số tiền được yêu cầu, không ghi chú
+	var req ApproveRefundRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	actor := models.Actor{ID: userID.(int64), Role: models.RoleAdmin}
+	if err := models.ApproveRefund(db, refundID, actor, req.Amount, strings.TrimSpace(req.Note)); err != nil {
+		respondRefundError(c, err)
+		return
+	}
+	refund, err := models.GetRefundByID(db, refundID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get refund"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Approved refund successfully", "refund": refund})
+}
+
+func RejectRefundHandler(c *gin.Context, db *sql.DB) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+	refundID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid refund ID"})
+		return
+	}
+	var req RejectRefundRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	actor := models.Actor{ID: userID.(int64), Role: models.RoleAdmin}
+	if err := models.RejectRefund(db, refundID, actor, strings.TrimSpace(req.Note)); err != nil {
+		respondRefundError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Rejected refund successfully"})
+}
@@ -0,0 +1,139 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+
+	"example.com/delivery-app/models"
+	"github.com/gin-gonic/gin"
+)
+
+// ReorderHandler đặt lại order cũ: thay giỏ hàng bằng các sản phẩm của order (kèm option và ghi chú)
+// theo giá và tồn kho hiện tại. Sản phẩm không còn bán/hết hàng/option không còn hợp lệ thì bỏ qua,
+// không đủ hàng thì lấy số còn lại, trả về danh sách đó trong "unavailable"
+// và các dòng đổi giá trong "price_changes".
+func ReorderHandler(c *gin.Context, db *sql.DB) {
+	userIDVal, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+	userID := userIDVal.(int64)
+	orderID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid order ID"})
+		return
+	}
+	isOwner, err := models.CheckOrderUser(db, userID, orderID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check order"})
+		return
+	}
+	if !isOwner {
+		c.JSON(http.StatusForbidden, gin.H{"error": models.ErrOrderAccessDenied.Error()})
+		return
+	}
+
+	items, err := models.GetReorderItems(db, orderID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get order items"})
+		return
+	}
+	ids := make([]int64, 0, len(items))
+	for _, item := range items {
+		ids = append(ids, item.ProductID)
+	}
+	products, err := models.GetStockProducts(db, ids)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get products"})
+		return
+	}
+	options, err := models.GetOptionGroups(db, ids)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get product options"})
+		return
+	}
+
+	unavailable := []models.OrderItemError{}
+	cart := make([]models.CartItem, 0, len(items))
+	oldPrices := make(map[string]float64, len(items))
+	stockLeft := make(map[int64]int64)
+	for _, item := range items {
+		product, ok := products[item.ProductID]
+		if !ok {
+			unavailable = append(unavailable, models.OrderItemError{ProductID: item.ProductID, Reason: models.ItemErrNotFound, Requested: item.Quantity})
+			continue
+		}
+		// option cũ đã bị xoá hoặc không còn hợp lệ thì không tự chọn thay, để khách chọn lại
+		if item.MissingOptions {
+			unavailable = append(unavailable, models.OrderItemError{ProductID: item.ProductID, Reason: models.OptionErrInvalid, Requested: item.Quantity})
+			continue
+		}
+		if _, _, err := models.SelectOptions(item.ProductID, options[item.ProductID], item.OptionIDs); err != nil {
+			optErr := &models.OptionError{Reason: models.OptionErrInvalid}
+			errors.As(err, &optErr)
+			unavailable = append(unavailable, models.OrderItemError{ProductID: item.ProductID, Reason: optErr.Reason, Requested: item.Quantity, GroupID: optErr.GroupID, OptionID: optErr.OptionID})
+			continue
+		}
+
+		// các dòng cùng sản phẩm dùng chung tồn kho
+		if _, ok := stockLeft[item.ProductID]; !ok {
+			stockLeft[item.ProductID] = product.Available()
+		}
+		quantity := item.Quantity
+		if available := stockLeft[item.ProductID]; available < quantity {
+			unavailable = append(unavailable, models.OrderItemError{ProductID: item.ProductID, Reason: models.ItemErrOutOfStock, Requested: item.Quantity, Available: available})
+			quantity = available
+		}
+		if quantity == 0 {
+			continue
+		}
+		stockLeft[item.ProductID] -= quantity
+		cart = append(cart, models.CartItem{ProductID: item.ProductID, Quantity: quantity, OptionIDs: item.OptionIDs, Note: item.Note})
+		oldPrices[reorderKey(item.ProductID, item.OptionIDs)] = item.Price
+	}
+	if len(cart) == 0 {
+		c.JSON(http.StatusConflict, gin.H{
+			"error":       "none of the items in this order are available",
+			"unavailable": unavailable,
+		})
+		return
+	}
+
+	if err := models.ReplaceCart(db, userID, cart); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save cart"})
+		return
+	}
+	lines, subtotal, err := loadCart(db, userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get cart"})
+		return
+	}
+
+	// so đơn giá trung bình hiện tại của từng dòng (đã tính flash sale và option) với giá lúc đặt
+	priceChanges := []models.PriceChange{}
+	for _, line := range lines {
+		oldPrice, ok := oldPrices[reorderKey(line.ProductID, line.OptionIDs)]
+		if !ok || line.Quantity == 0 || line.Status == models.CartLineUnavailable || line.Status == models.CartLineInvalidOptions {
+			continue
+		}
+		newPrice := math.Round(line.LineTotal/float64(line.Quantity)*100) / 100
+		if newPrice != oldPrice {
+			priceChanges = append(priceChanges, models.PriceChange{ProductID: line.ProductID, OptionIDs: line.OptionIDs, Name: line.Name, OldPrice: oldPrice, NewPrice: newPrice})
+		}
+	}
+
+	body := cartBody(lines, subtotal)
+	body["order_id"] = orderID
+	body["unavailable"] = unavailable
+	body["price_changes"] = priceChanges
+	c.JSON(http.StatusOK, body)
+}
+
+// khoá của một dòng giỏ hàng: sản phẩm + bộ option
+func reorderKey(productID int64, optionIDs []int64) string {
+	return strconv.FormatInt(productID, 10) + "|" + models.CartOptionKey(optionIDs)
+}
@@ -0,0 +1,60 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"time"
+
+	"example.com/delivery-app/config"
+	"example.com/delivery-app/models"
+	"github.com/gin-gonic/gin"
+)
+
+// báo cáo doanh thu theo ngày/tuần/tháng cho biểu đồ của admin:
+// ?from=YYYY-MM-DD&to=YYYY-MM-DD (tính cả ngày to, theo giờ cửa hàng)&granularity=day|week|month.
+// Bỏ trống from/to thì lấy 30 ngày, 12 tuần hoặc 12 tháng gần nhất.
+func GetRevenueReportHandler(c *gin.Context, db *sql.DB) {
+	granularity := c.DefaultQuery("granularity", models.GranularityDay)
+	now := time.Now().In(config.Location)
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, config.Location)
+
+	to := today.AddDate(0, 0, 1)
+	if v := c.Query("to"); v != "" {
+		date, err := time.ParseInLocation("2006-01-02", v, config.Location)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "to must be YYYY-MM-DD"})
+			return
+		}
+		to = date.AddDate(0, 0, 1)
+	}
+	var from time.Time
+	if v := c.Query("from"); v != "" {
+		date, err := time.ParseInLocation("2006-01-02", v, config.Location)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "from must be YYYY-MM-DD"})
+			return
+		}
+		from = date
+	} else {
+		switch granularity {
+		case models.GranularityWeek:
+			from = to.AddDate(0, 0, -7*12)
+		case models.GranularityMonth:
+			from = to.AddDate(0, -12, 0)
+		default:
+			from = to.AddDate(0, 0, -30)
+		}
+	}
+
+	report, err := models.GetRevenueReport(db, from, to, granularity, config.Location)
+	if err != nil {
+		if errors.Is(err, models.ErrInvalidReportRange) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get revenue report"})
+		}
+		return
+	}
+	c.JSON(http.StatusOK, report)
+}
@@ -0,0 +1,166 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"example.com/delivery-app/models"
+	"github.com/gin-gonic/gin"
+)
+
+type StoreRequest struct {
+	Name      string  `json:"name" binding:"required"`
+	OwnerID   int64   `json:"owner_id"`
+	Address   string  `json:"address" binding:"required"`
+	Phone     string  `json:"phone"`
+	Latitude  float64 `json:"latitude" binding:"gte=-90,lte=90"`
+	Longitude float64 `json:"longitude" binding:"gte=-180,lte=180"`
+	IsActive  *bool   `json:"is_active"` // mặc định là true
+}
+
+func (r *StoreRequest) toStore() *models.Store {
+	active := true
+	if r.IsActive != nil {
+		active = *r.IsActive
+	}
+	return &models.Store{
+		Name:      r.Name,
+		OwnerID:   r.OwnerID,
+		Address:   r.Address,
+		Phone:     r.Phone,
+		Latitude:  r.Latitude,
+		Longitude: r.Longitude,
+		IsActive:  active,
+	}
+}
+
+func CreateStoreHandler(c *gin.Context, db *sql.DB) {
+	var req StoreRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	store := req.toStore()
+	id, err := models.CreateStore(db, store)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create store"})
+		return
+	}
+	store.ID = id
+	c.JSON(http.StatusCreated, gin.H{"message": "Created new store successfully", "store": store})
+}
+
+func UpdateStoreHandler(c *gin.Context, db *sql.DB) {
+	storeID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid store ID"})
+		return
+	}
+	var req StoreRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	store := req.toStore()
+	store.ID = storeID
+	if err := models.UpdateStore(db, store); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Store not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update store"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Updated store successfully", "store": store})
+}
+
+func DeactivateStoreHandler(c *gin.Context, db *sql.DB) {
+	storeID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid store ID"})
+		return
+	}
+	if err := models.DeactivateStore(db, storeID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Store not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to deactivate store"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Deactivated store successfully"})
+}
+
+func GetAllStoresHandler(c *gin.Context, db *sql.DB) {
+	pageStr := c.DefaultQuery("page", "1")
+	limitStr := c.DefaultQuery("limit", "10")
+
+	page, _ := strconv.Atoi(pageStr)
+	limit, _ := strconv.Atoi(limitStr)
+
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 {
+		limit = 10
+	}
+	stores, total, err := models.GetAllStores(db, page, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get stores"})
+		return
+	}
+	totalPages := (total + limit - 1) / limit
+	c.JSON(http.StatusOK, gin.H{
+		"stores": stores,
+		"pagination": gin.H{
+			"page":        page,
+			"limit":       limit,
+			"total":       total,
+			"total_pages": totalPages,
+		},
+	})
+}
+
+func GetStoreByIDHandler(c *gin.Context, db *sql.DB) {
+	storeID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid store ID"})
+		return
+	}
+	store, err := models.GetStoreByID(db, storeID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Store not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get store"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"store": store})
+}
+
+// GetNearbyStoresHandler: ?lat=&lng=&radius_km= (mặc định 10km)
+func GetNearbyStoresHandler(c *gin.Context, db *sql.DB) {
+	lat, errLat := strconv.ParseFloat(c.Query("lat"), 64)
+	lng, errLng := strconv.ParseFloat(c.Query("lng"), 64)
+	if errLat != nil || errLng != nil || lat < -90 || lat > 90 || lng < -180 || lng > 180 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid lat/lng"})
+		return
+	}
+	radius, err := strconv.ParseFloat(c.DefaultQuery("radius_km", "10"), 64)
+	if err != nil || radius <= 0 {
+		radius = 10
+	}
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	if limit < 1 {
+		limit = 10
+	}
+	stores, err := models.GetNearbyStores(db, lat, lng, radius, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get nearby stores"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"stores": stores})
+}
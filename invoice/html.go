@@ -0,0 +1,60 @@
+package invoice
+
+import (
+	"html/template"
+	"io"
+)
+
+var htmlTemplate = template.Must(template.New("invoice").Funcs(template.FuncMap{
+	"vnd":     FormatVND,
+	"options": ItemOptions,
+}).Parse(`<!DOCTYPE html>
+<html lang="vi">
+<head>
+<meta charset="utf-8">
+<title>Hoá đơn {{.Number}}</title>
+</head>
+<body style="margin:0;padding:24px;background:#f5f5f5;font-family:Arial,Helvetica,sans-serif;color:#222;">
+<table width="100%" cellpadding="0" cellspacing="0" style="max-width:640px;margin:0 auto;background:#fff;padding:24px;">
+<tr><td>
+	<h2 style="margin:0 0 4px;">{{.Seller.Name}}</h2>
+	{{if .Seller.TaxCode}}<div>MST: {{.Seller.TaxCode}}</div>{{end}}
+	{{with .Store}}<div>{{.Name}} - {{.Address}}{{if .Phone}} - ĐT: {{.Phone}}{{end}}</div>{{end}}
+	<h1 style="font-size:20px;text-align:center;margin:24px 0 8px;">HOÁ ĐƠN BÁN HÀNG</h1>
+	<div style="text-align:center;">Số: <strong>{{.Number}}</strong> - Ngày: {{.IssuedDate}}</div>
+
+	<table width="100%" cellpadding="4" cellspacing="0" style="margin-top:16px;">
+		<tr><td>Mã đơn hàng:</td><td>DH{{.Order.ID}} ({{.OrderDate}})</td></tr>
+		<tr><td>Khách hàng:</td><td>{{.Order.UserName}}{{if .Order.Phone}} - {{.Order.Phone}}{{end}}</td></tr>
+		{{if .Order.DeliveryAddress}}<tr><td>Giao tới:</td><td>{{.Order.DeliveryAddress}}</td></tr>{{end}}
+		<tr><td>Thanh toán:</td><td>{{.PaymentStatusLabel}}</td></tr>
+	</table>
+
+	<table width="100%" cellpadding="6" cellspacing="0" style="margin-top:16px;border-collapse:collapse;">
+		<tr style="background:#eee;">
+			<th align="left">Sản phẩm</th><th align="right">SL</th><th align="right">Đơn giá</th><th align="right">Thành tiền</th>
+		</tr>
+		{{range .Items}}
+		<tr style="border-bottom:1px solid #ddd;">
+			<td>{{.ProductName}}{{with options .}}<div style="color:#666;font-size:12px;">{{.}}</div>{{end}}</td><td align="right">{{.Quantity}}</td><td align="right">{{vnd .Price}}</td><td align="right">{{vnd .Subtotal}}</td>
+		</tr>
+		{{end}}
+	</table>
+
+	<table width="100%" cellpadding="4" cellspacing="0" style="margin-top:16px;">
+		<tr><td align="right">Tạm tính:</td><td align="right" width="160">{{vnd .Order.Subtotal}}</td></tr>
+		<tr><td align="right">Phí giao hàng:</td><td align="right">{{vnd .Order.DeliveryFee}}</td></tr>
+		{{if gt .Order.Discount 0.0}}<tr><td align="right">Giảm giá:</td><td align="right">-{{vnd .Order.Discount}}</td></tr>{{end}}
+		<tr><td align="right"><strong>Tổng cộng:</strong></td><td align="right"><strong>{{vnd .Order.TotalAmount}}</strong></td></tr>
+		{{if gt .VATRate 0.0}}<tr><td align="right" style="color:#666;">Trong đó thuế GTGT ({{.VATPercent}}):</td><td align="right" style="color:#666;">{{vnd .VATAmount}}</td></tr>{{end}}
+	</table>
+</td></tr>
+</table>
+</body>
+</html>
+`))
+
+// RenderHTML ghi hoá đơn dạng HTML (style inline để gửi email)
+func RenderHTML(w io.Writer, d *Document) error {
+	return htmlTemplate.Execute(w, d)
+}
@@ -0,0 +1,112 @@
+package invoice
+
+import (
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"example.com/delivery-app/config"
+	"example.com/delivery-app/models"
+)
+
+// Seller là bên bán in ở đầu hoá đơn
+type Seller struct {
+	Name    string
+	TaxCode string
+}
+
+// Document là nội dung một hoá đơn: số hoá đơn, bên bán, cửa hàng và chi tiết order
+type Document struct {
+	Number   string
+	IssuedAt time.Time
+	Seller   Seller
+	Store    *models.Store // nil nếu order không gắn với store
+	Order    models.OrderResponse
+	Items    []models.OrderItemDetailResp
+	VATRate  float64 // thuế GTGT đã gồm trong tổng tiền
+	Location *time.Location
+}
+
+// New ghép hoá đơn từ số hoá đơn đã cấp và chi tiết order, bên bán và thuế lấy từ config
+func New(inv *models.Invoice, detail *models.GetOrderDetailResponse, store *models.Store) *Document {
+	return &Document{
+		Number:   inv.Number,
+		IssuedAt: inv.IssuedAt,
+		Seller:   Seller{Name: config.Invoice.SellerName, TaxCode: config.Invoice.SellerTaxCode},
+		Store:    store,
+		Order:    detail.Order,
+		Items:    detail.OrderItems,
+		VATRate:  config.Invoice.VATRate,
+		Location: config.Location,
+	}
+}
+
+// VATAmount là tiền thuế GTGT nằm trong tổng tiền (giá bán đã gồm thuế)
+func (d *Document) VATAmount() float64 {
+	if d.VATRate <= 0 {
+		return 0
+	}
+	return math.Round(d.Order.TotalAmount * d.VATRate / (1 + d.VATRate))
+}
+
+// VATPercent hiển thị thuế suất, vd "8%"
+func (d *Document) VATPercent() string {
+	return strconv.FormatFloat(d.VATRate*100, 'f', -1, 64) + "%"
+}
+
+func (d *Document) localTime(t time.Time) string {
+	loc := d.Location
+	if loc == nil {
+		loc = time.Local
+	}
+	return t.In(loc).Format("02/01/2006 15:04")
+}
+
+// IssuedDate là ngày giờ lập hoá đơn theo giờ cửa hàng
+func (d *Document) IssuedDate() string { return d.localTime(d.IssuedAt) }
+
+// OrderDate là ngày giờ đặt hàng theo giờ cửa hàng
+func (d *Document) OrderDate() string { return d.localTime(d.Order.CreatedAt) }
+
+// PaymentStatusLabel là trạng thái thanh toán bằng tiếng Việt
+func (d *Document) PaymentStatusLabel() string {
+	switch d.Order.PaymentStatus {
+	case models.PaymentStatusPaid:
+		return "Đã thanh toán"
+	case models.PaymentStatusPartiallyRefunded:
+		return "Đã thanh toán, hoàn tiền một phần"
+	case models.PaymentStatusRefunded:
+		return "Đã hoàn tiền"
+	default:
+		return "Chưa thanh toán"
+	}
+}
+
+// ItemOptions là các option đã chọn của dòng hàng, vd "Size: L, Topping: Trân châu"
+func ItemOptions(item models.OrderItemDetailResp) string {
+	parts := make([]string, 0, len(item.Options))
+	for _, o := range item.Options {
+		parts = append(parts, o.GroupName+": "+o.OptionName)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// FormatVND định dạng số tiền kiểu Việt Nam, vd 150.000 VND
+func FormatVND(v float64) string {
+	n := int64(math.Round(v))
+	sign := ""
+	if n < 0 {
+		sign = "-"
+		n = -n
+	}
+	s := strconv.FormatInt(n, 10)
+	var b strings.Builder
+	for i, r := range s {
+		if i > 0 && (len(s)-i)%3 == 0 {
+			b.WriteByte('.')
+		}
+		b.WriteRune(r)
+	}
+	return sign + b.String() + " VND"
+}
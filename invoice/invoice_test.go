@@ -0,0 +1,76 @@
+package invoice
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"example.com/delivery-app/config"
+	"example.com/delivery-app/models"
+)
+
+func testDocument() *Document {
+	return &Document{
+		Number:   "HD2026-000042",
+		IssuedAt: time.Date(2026, 10, 17, 3, 30, 0, 0, time.UTC),
+		Seller:   Seller{Name: "Delivery App", TaxCode: "0101234567"},
+		Store:    &models.Store{Name: "Cửa hàng Cầu Giấy", Address: "12 Trần Đăng Ninh, Hà Nội"},
+		Order: models.OrderResponse{
+			ID: 42, UserName: "Nguyễn Văn A", Phone: "0912345678", PaymentStatus: models.PaymentStatusPaid,
+			DeliveryAddress: "1 Đại Cồ Việt", Subtotal: 120000, DeliveryFee: 15000, Discount: 5000, TotalAmount: 130000,
+		},
+		Items: []models.OrderItemDetailResp{
+			{ProductName: "Bánh mì đặc biệt", Quantity: 2, Price: 35000, Subtotal: 70000},
+			{ProductName: "Trà đá <chanh>", Quantity: 1, Price: 50000, Subtotal: 50000, Options: []models.OrderItemOption{
+				{GroupName: "Size", OptionName: "L", PriceDelta: 10000},
+				{GroupName: "Topping", OptionName: "Trân châu", PriceDelta: 5000},
+			}},
+		},
+		VATRate:  0.08,
+		Location: time.FixedZone("ICT", 7*60*60),
+	}
+}
+
+func TestFormatVND(t *testing.T) {
+	tests := map[float64]string{0: "0 VND", 999: "999 VND", 150000: "150.000 VND", 1234567.6: "1.234.568 VND", -5000: "-5.000 VND"}
+	for v, want := range tests {
+		if got := FormatVND(v); got != want {
+			t.Errorf("FormatVND(%v) = %q, want %q", v, got, want)
+		}
+	}
+}
+
+func TestRenderHTML(t *testing.T) {
+	var buf bytes.Buffer
+	if err := RenderHTML(&buf, testDocument()); err != nil {
+		t.Fatal(err)
+	}
+	html := buf.String()
+	for _, want := range []string{"HD2026-000042", "17/10/2026 10:30", "Đã thanh toán", "130.000 VND", "Trà đá &lt;chanh&gt;", "Size: L, Topping: Trân châu", "GTGT (8%)", "9.630 VND"} {
+		if !strings.Contains(html, want) {
+			t.Errorf("html does not contain %q", want)
+		}
+	}
+}
+
+func TestRenderPDF(t *testing.T) {
+	oldFontDir := config.Invoice.FontDir
+	t.Cleanup(func() { config.Invoice.FontDir = oldFontDir })
+	for _, fontDir := range []string{"/usr/share/fonts/truetype/dejavu", t.TempDir()} {
+		config.Invoice.FontDir = fontDir
+		var buf bytes.Buffer
+		if err := RenderPDF(&buf, testDocument()); err != nil {
+			t.Fatalf("font dir %s: %v", fontDir, err)
+		}
+		if !bytes.HasPrefix(buf.Bytes(), []byte("%PDF-")) {
+			t.Fatalf("font dir %s: output is not a PDF", fontDir)
+		}
+	}
+}
+
+func TestRemoveDiacritics(t *testing.T) {
+	if got := removeDiacritics("HOÁ ĐƠN Bánh mì đặc biệt"); got != "HOA DON Banh mi dac biet" {
+		t.Errorf("got %q", got)
+	}
+}
@@ -0,0 +1,190 @@
+package invoice
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"example.com/delivery-app/config"
+	"github.com/go-pdf/fpdf"
+	"golang.org/x/text/unicode/norm"
+)
+
+// font Unicode để in tiếng Việt, thiếu font thì dùng Helvetica và bỏ dấu
+const (
+	fontFamily   = "dejavu"
+	fontRegular  = "DejaVuSans.ttf"
+	fontBold     = "DejaVuSans-Bold.ttf"
+	fallbackFont = "Helvetica"
+)
+
+type pdfWriter struct {
+	pdf    *fpdf.Fpdf
+	family string
+	text   func(string) string
+}
+
+func newPDFWriter() *pdfWriter {
+	pdf := fpdf.New("P", "mm", "A4", "")
+	pdf.SetMargins(15, 15, 15)
+	pdf.SetAutoPageBreak(true, 15)
+
+	w := &pdfWriter{pdf: pdf, family: fallbackFont, text: removeDiacritics}
+	regular, err := os.ReadFile(filepath.Join(config.Invoice.FontDir, fontRegular))
+	if err != nil {
+		return w
+	}
+	bold, err := os.ReadFile(filepath.Join(config.Invoice.FontDir, fontBold))
+	if err != nil {
+		bold = regular
+	}
+	pdf.AddUTF8FontFromBytes(fontFamily, "", regular)
+	pdf.AddUTF8FontFromBytes(fontFamily, "B", bold)
+	w.family = fontFamily
+	w.text = func(s string) string { return s }
+	return w
+}
+
+func (w *pdfWriter) font(style string, size float64) {
+	w.pdf.SetFont(w.family, style, size)
+}
+
+func (w *pdfWriter) cell(width, height float64, s, border string, ln int, align string) {
+	w.pdf.CellFormat(width, height, w.text(s), border, ln, align, false, 0, "")
+}
+
+// RenderPDF ghi hoá đơn dạng PDF khổ A4
+func RenderPDF(out io.Writer, d *Document) error {
+	w := newPDFWriter()
+	pdf := w.pdf
+	pdf.AddPage()
+
+	// bên bán
+	w.font("B", 14)
+	w.cell(0, 7, d.Seller.Name, "", 1, "L")
+	w.font("", 10)
+	if d.Seller.TaxCode != "" {
+		w.cell(0, 5, "MST: "+d.Seller.TaxCode, "", 1, "L")
+	}
+	if d.Store != nil {
+		store := d.Store.Name + " - " + d.Store.Address
+		if d.Store.Phone != "" {
+			store += " - ĐT: " + d.Store.Phone
+		}
+		pdf.MultiCell(0, 5, w.text(store), "", "L", false)
+	}
+
+	pdf.Ln(6)
+	w.font("B", 16)
+	w.cell(0, 8, "HOÁ ĐƠN BÁN HÀNG", "", 1, "C")
+	w.font("", 10)
+	w.cell(0, 5, fmt.Sprintf("Số: %s - Ngày: %s", d.Number, d.IssuedDate()), "", 1, "C")
+	pdf.Ln(6)
+
+	// khách hàng
+	customer := d.Order.UserName
+	if d.Order.Phone != "" {
+		customer += " - " + d.Order.Phone
+	}
+	info := [][2]string{
+		{"Mã đơn hàng:", fmt.Sprintf("DH%d (%s)", d.Order.ID, d.OrderDate())},
+		{"Khách hàng:", customer},
+	}
+	if d.Order.DeliveryAddress != "" {
+		info = append(info, [2]string{"Giao tới:", d.Order.DeliveryAddress})
+	}
+	info = append(info, [2]string{"Thanh toán:", d.PaymentStatusLabel()})
+	for _, row := range info {
+		w.cell(35, 6, row[0], "", 0, "L")
+		pdf.MultiCell(0, 6, w.text(row[1]), "", "L", false)
+	}
+	pdf.Ln(4)
+
+	// các sản phẩm
+	widths := []float64{95, 15, 35, 35}
+	w.font("B", 10)
+	pdf.SetFillColor(238, 238, 238)
+	for i, h := range []string{"Sản phẩm", "SL", "Đơn giá", "Thành tiền"} {
+		align := "R"
+		if i == 0 {
+			align = "L"
+		}
+		pdf.CellFormat(widths[i], 7, w.text(h), "B", 0, align, true, 0, "")
+	}
+	pdf.Ln(-1)
+	w.font("", 10)
+	for _, item := range d.Items {
+		// dòng option (nếu có) in nhỏ bên dưới tên sản phẩm, kẻ viền dưới ở dòng cuối
+		options := ItemOptions(item)
+		border := "B"
+		if options != "" {
+			border = ""
+		}
+		w.cell(widths[0], 7, truncate(item.ProductName, 60), border, 0, "L")
+		w.cell(widths[1], 7, strconv.FormatInt(item.Quantity, 10), border, 0, "R")
+		w.cell(widths[2], 7, FormatVND(item.Price), border, 0, "R")
+		w.cell(widths[3], 7, FormatVND(item.Subtotal), border, 1, "R")
+		if options != "" {
+			w.font("", 8)
+			w.cell(widths[0], 5, truncate(options, 90), "B", 0, "L")
+			w.cell(widths[1]+widths[2]+widths[3], 5, "", "B", 1, "R")
+			w.font("", 10)
+		}
+	}
+	pdf.Ln(4)
+
+	// tổng tiền
+	totals := [][2]string{
+		{"Tạm tính:", FormatVND(d.Order.Subtotal)},
+		{"Phí giao hàng:", FormatVND(d.Order.DeliveryFee)},
+	}
+	if d.Order.Discount > 0 {
+		totals = append(totals, [2]string{"Giảm giá:", "-" + FormatVND(d.Order.Discount)})
+	}
+	for _, row := range totals {
+		w.cell(145, 6, row[0], "", 0, "R")
+		w.cell(35, 6, row[1], "", 1, "R")
+	}
+	w.font("B", 11)
+	w.cell(145, 7, "Tổng cộng:", "", 0, "R")
+	w.cell(35, 7, FormatVND(d.Order.TotalAmount), "", 1, "R")
+	if d.VATRate > 0 {
+		w.font("", 9)
+		w.cell(145, 5, fmt.Sprintf("Trong đó thuế GTGT (%s):", d.VATPercent()), "", 0, "R")
+		w.cell(35, 5, FormatVND(d.VATAmount()), "", 1, "R")
+	}
+
+	if err := pdf.Error(); err != nil {
+		return err
+	}
+	return pdf.Output(out)
+}
+
+// cắt tên sản phẩm quá dài cho vừa một dòng
+func truncate(s string, max int) string {
+	r := []rune(s)
+	if len(r) <= max {
+		return s
+	}
+	return string(r[:max-1]) + "…"
+}
+
+// bỏ dấu tiếng Việt cho font Helvetica (chỉ hỗ trợ Latin-1)
+func removeDiacritics(s string) string {
+	s = strings.NewReplacer("đ", "d", "Đ", "D", "…", "...").Replace(s)
+	var b strings.Builder
+	for _, r := range norm.NFD.String(s) {
+		if unicode.Is(unicode.Mn, r) {
+			continue
+		}
+		if r > unicode.MaxASCII {
+			r = '?'
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
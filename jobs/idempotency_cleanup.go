@@ -0,0 +1,26 @@
+package jobs
+
+import (
+	"database/sql"
+	"log"
+	"time"
+
+	"example.com/delivery-app/config"
+	"example.com/delivery-app/models"
+)
+
+// StartIdempotencyCleanUp mỗi giờ xoá các Idempotency-Key đã hết hạn
+func StartIdempotencyCleanUp(db *sql.DB) {
+	cfg := config.Idempotency
+	ticker := time.NewTicker(1 * time.Hour)
+	go func() {
+		for range ticker.C {
+			n, err := models.CleanUpIdempotencyKeys(db, cfg.Retention, cfg.ProcessingTimeout)
+			if err != nil {
+				log.Println("Error cleaning idempotency keys: ", err)
+			} else if n > 0 {
+				log.Printf("Cleaned %d idempotency keys", n)
+			}
+		}
+	}()
+}
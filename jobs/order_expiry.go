@@ -0,0 +1,79 @@
+package jobs
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"time"
+
+	"example.com/delivery-app/config"
+	"example.com/delivery-app/models"
+	"example.com/delivery-app/websocket"
+)
+
+// StartOrderExpiry định kỳ huỷ các order pending chưa thanh toán quá config.OrderExpiry.PendingTimeout.
+// Chạy được trên nhiều instance cùng lúc: mỗi order được khoá và kiểm tra lại trong transaction
+// riêng nên không bị huỷ hai lần. Thông báo websocket chỉ tới được khách đang kết nối vào
+// instance đã huỷ order (Hub nằm trong bộ nhớ), khách khác xem lý do huỷ trong lịch sử trạng thái.
+func StartOrderExpiry(db *sql.DB, hub *websocket.Hub) {
+	cfg := config.OrderExpiry
+	if cfg.PendingTimeout <= 0 || cfg.Interval <= 0 || cfg.BatchSize <= 0 {
+		log.Println("Order expiry is disabled")
+		return
+	}
+	ticker := time.NewTicker(cfg.Interval)
+	go func() {
+		for range ticker.C {
+			n, err := ExpireStaleOrders(db, hub, time.Now())
+			if err != nil {
+				log.Println("Error expiring pending orders: ", err)
+			}
+			if n > 0 {
+				log.Printf("Expired %d pending orders", n)
+			}
+		}
+	}()
+}
+
+// ExpireStaleOrders huỷ các order quá hạn tính tới now, trả về số order đã huỷ
+func ExpireStaleOrders(db *sql.DB, hub *websocket.Hub, now time.Time) (int, error) {
+	cfg := config.OrderExpiry
+	createdBefore := now.Add(-cfg.PendingTimeout)
+	// đơn hẹn giờ chỉ hiện cho admin từ scheduled_for - LeadTime, tính hạn từ lúc đó
+	releasedBefore := createdBefore.Add(config.Schedule.LeadTime)
+	reason := fmt.Sprintf("auto-cancelled: not accepted within %d minutes", int(cfg.PendingTimeout.Minutes()))
+
+	expired := 0
+	for {
+		ids, err := models.GetStalePendingOrderIDs(db, createdBefore, releasedBefore, cfg.BatchSize)
+		if err != nil {
+			return expired, err
+		}
+		failed := false
+		for _, id := range ids {
+			order, err := models.ExpirePendingOrder(db, id, createdBefore, releasedBefore, reason)
+			if err != nil {
+				log.Printf("Failed to expire order %d: %v", id, err)
+				failed = true
+				continue
+			}
+			if order == nil {
+				continue
+			}
+			expired++
+			msg := &websocket.Message{
+				Type:      "order_cancelled",
+				OrderID:   order.ID,
+				Content:   reason,
+				CreatedAt: now,
+			}
+			if err := hub.SendToUser(order.UserID, msg); err != nil {
+				log.Printf("Failed to notify customer %d: %v", order.UserID, err)
+			}
+		}
+		// order lỗi vẫn nằm trong danh sách, để lần quét sau thử lại
+		if failed || len(ids) == 0 || len(ids) < cfg.BatchSize {
+			return expired, nil
+		}
+	}
+}
@@ -0,0 +1,131 @@
+package middleware
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"io"
+	"log"
+	"net/http"
+
+	"example.com/delivery-app/config"
+	"example.com/delivery-app/models"
+	"github.com/gin-gonic/gin"
+)
+
+const IdempotencyHeader = "Idempotency-Key"
+
+// ghi lại response để lưu cho các lần gửi lại
+type responseRecorder struct {
+	gin.ResponseWriter
+	body *bytes.Buffer
+}
+
+func (w *responseRecorder) Write(b []byte) (int, error) {
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *responseRecorder) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
+
+// IdempotencyMiddleware: request có header Idempotency-Key chỉ được xử lý một lần,
+// gửi lại cùng key sẽ nhận lại đúng response cũ, cùng key nhưng khác body thì trả 422.
+// Key đang xử lý quá config.Idempotency.ProcessingTimeout được coi là bỏ dở và cho dùng lại.
+// Phải đặt sau AuthMiddleware vì key được tính theo từng user.
+func IdempotencyMiddleware(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader(IdempotencyHeader)
+		if key == "" {
+			c.Next()
+			return
+		}
+		if len(key) > 255 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Idempotency-Key is too long"})
+			c.Abort()
+			return
+		}
+		userIDVal, exists := c.Get("userID")
+		if !exists {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+			c.Abort()
+			return
+		}
+		userID := userIDVal.(int64)
+
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Can't read request body"})
+			c.Abort()
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		scope := c.Request.Method + " " + c.FullPath()
+		sum := sha256.Sum256(append([]byte(scope+"\n"), body...))
+		requestHash := hex.EncodeToString(sum[:])
+
+		created, err := models.CreateIdempotencyKey(db, userID, key, scope, requestHash, config.Idempotency.ProcessingTimeout)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check Idempotency-Key"})
+			c.Abort()
+			return
+		}
+		if !created {
+			existing, err := models.GetIdempotencyKey(db, userID, key)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check Idempotency-Key"})
+				c.Abort()
+				return
+			}
+			if existing.RequestHash != requestHash {
+				c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "Idempotency-Key was already used with a different request"})
+				c.Abort()
+				return
+			}
+			if existing.Status != models.IdempotencyCompleted {
+				c.JSON(http.StatusConflict, gin.H{"error": "A request with this Idempotency-Key is still being processed"})
+				c.Abort()
+				return
+			}
+			contentType := existing.ContentType
+			if contentType == "" {
+				contentType = "application/json; charset=utf-8"
+			}
+			c.Header("Idempotent-Replayed", "true")
+			c.Data(existing.ResponseCode, contentType, existing.ResponseBody)
+			c.Abort()
+			return
+		}
+
+		// handler panic thì bỏ key để client thử lại, rồi panic tiếp cho Recovery xử lý
+		defer func() {
+			if r := recover(); r != nil {
+				if err := models.DeleteIdempotencyKey(db, userID, key); err != nil {
+					log.Println("Failed to release idempotency key:", err)
+				}
+				panic(r)
+			}
+		}()
+
+		recorder := &responseRecorder{ResponseWriter: c.Writer, body: &bytes.Buffer{}}
+		c.Writer = recorder
+		c.Next()
+
+		status := recorder.Status()
+		if status >= http.StatusInternalServerError {
+			// lỗi phía server: bỏ key để client thử lại
+			if err := models.DeleteIdempotencyKey(db, userID, key); err != nil {
+				log.Println("Failed to release idempotency key:", err)
+			}
+			return
+		}
+		contentType := recorder.Header().Get("Content-Type")
+		if err := models.CompleteIdempotencyKey(db, userID, key, status, contentType, recorder.body.Bytes()); err != nil {
+			log.Println("Failed to save idempotent response:", err)
+		}
+	}
+}
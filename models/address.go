@@ -0,0 +1,188 @@
+package models
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+)
+
+var ErrAddressNotFound = errors.New("address not found")
+
+// UserAddress là địa chỉ giao hàng đã lưu của customer
+type UserAddress struct {
+	ID         int64     `json:"id"`
+	UserID     int64     `json:"user_id"`
+	Label      string    `json:"label"` // vd: Nhà, Công ty
+	Street     string    `json:"street"`
+	DriverNote string    `json:"driver_note"`
+	Latitude   float64   `json:"latitude"`
+	Longitude  float64   `json:"longitude"`
+	IsDefault  bool      `json:"is_default"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+const addressColumns = "id, user_id, label, street, driver_note, latitude, longitude, is_default, created_at, updated_at"
+
+func scanAddress(row rowScanner, a *UserAddress) error {
+	return row.Scan(&a.ID, &a.UserID, &a.Label, &a.Street, &a.DriverNote, &a.Latitude, &a.Longitude, &a.IsDefault, &a.CreatedAt, &a.UpdatedAt)
+}
+
+// GetUserAddresses trả về địa chỉ của user, địa chỉ mặc định đứng đầu
+func GetUserAddresses(db *sql.DB, userID int64) ([]UserAddress, error) {
+	rows, err := db.Query("select "+addressColumns+" from user_addresses where user_id = ? order by is_default desc, id desc", userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	addresses := []UserAddress{}
+	for rows.Next() {
+		var a UserAddress
+		if err := scanAddress(rows, &a); err != nil {
+			return nil, err
+		}
+		addresses = append(addresses, a)
+	}
+	return addresses, rows.Err()
+}
+
+// GetUserAddress lấy địa chỉ của đúng user, không có thì trả ErrAddressNotFound
+func GetUserAddress(db *sql.DB, userID, addressID int64) (*UserAddress, error) {
+	var a UserAddress
+	err := scanAddress(db.QueryRow("select "+addressColumns+" from user_addresses where id = ? and user_id = ?", addressID, userID), &a)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrAddressNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &a, nil
+}
+
+// khoá các địa chỉ của user để đổi địa chỉ mặc định không bị chạy song song
+func lockUserAddressesTx(tx *sql.Tx, userID int64) (int, error) {
+	rows, err := tx.Query("select id from user_addresses where user_id = ? for update", userID)
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+	n := 0
+	for rows.Next() {
+		n++
+	}
+	return n, rows.Err()
+}
+
+func clearDefaultAddressTx(tx *sql.Tx, userID int64) error {
+	_, err := tx.Exec("update user_addresses set is_default = false where user_id = ? and is_default = true", userID)
+	return err
+}
+
+// CreateUserAddress thêm địa chỉ, địa chỉ đầu tiên của user luôn là mặc định
+func CreateUserAddress(db *sql.DB, a *UserAddress) (int64, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	count, err := lockUserAddressesTx(tx, a.UserID)
+	if err != nil {
+		return 0, err
+	}
+	if count == 0 {
+		a.IsDefault = true
+	}
+	if a.IsDefault {
+		if err := clearDefaultAddressTx(tx, a.UserID); err != nil {
+			return 0, err
+		}
+	}
+	query := "insert into user_addresses (user_id, label, street, driver_note, latitude, longitude, is_default) values (?, ?, ?, ?, ?, ?, ?)"
+	result, err := tx.Exec(query, a.UserID, a.Label, a.Street, a.DriverNote, a.Latitude, a.Longitude, a.IsDefault)
+	if err != nil {
+		return 0, err
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+	return id, tx.Commit()
+}
+
+// UpdateUserAddress sửa địa chỉ của user, các order đã đặt giữ nguyên bản sao địa chỉ cũ
+func UpdateUserAddress(db *sql.DB, a *UserAddress) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := lockUserAddressesTx(tx, a.UserID); err != nil {
+		return err
+	}
+	var wasDefault bool
+	err = tx.QueryRow("select is_default from user_addresses where id = ? and user_id = ?", a.ID, a.UserID).Scan(&wasDefault)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrAddressNotFound
+	}
+	if err != nil {
+		return err
+	}
+	// không bỏ mặc định trực tiếp, muốn đổi thì đặt địa chỉ khác làm mặc định
+	if wasDefault {
+		a.IsDefault = true
+	}
+	if a.IsDefault && !wasDefault {
+		if err := clearDefaultAddressTx(tx, a.UserID); err != nil {
+			return err
+		}
+	}
+	query := "update user_addresses set label = ?, street = ?, driver_note = ?, latitude = ?, longitude = ?, is_default = ? where id = ?"
+	if _, err := tx.Exec(query, a.Label, a.Street, a.DriverNote, a.Latitude, a.Longitude, a.IsDefault, a.ID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// DeleteUserAddress xoá địa chỉ, nếu là địa chỉ mặc định thì địa chỉ mới nhất còn lại thành mặc định
+func DeleteUserAddress(db *sql.DB, userID, addressID int64) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := lockUserAddressesTx(tx, userID); err != nil {
+		return err
+	}
+	var wasDefault bool
+	err = tx.QueryRow("select is_default from user_addresses where id = ? and user_id = ?", addressID, userID).Scan(&wasDefault)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrAddressNotFound
+	}
+	if err != nil {
+		return err
+	}
+	if _, err := tx.Exec("delete from user_addresses where id = ?", addressID); err != nil {
+		return err
+	}
+	if wasDefault {
+		query := "update user_addresses set is_default = true where user_id = ? order by id desc limit 1"
+		if _, err := tx.Exec(query, userID); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// UseAddress chép địa chỉ vào order, sửa/xoá địa chỉ sau này không làm đổi order
+func (o *Order) UseAddress(a *UserAddress) {
+	o.AddressID = a.ID
+	o.AddressLabel = a.Label
+	o.DeliveryAddress = a.Street
+	o.DriverNote = a.DriverNote
+	o.Latitude = a.Latitude
+	o.Longitude = a.Longitude
+}
@@ -0,0 +1,173 @@
+package models
+
+import (
+	"database/sql"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// trạng thái của một dòng trong giỏ khi đối chiếu với giá và tồn kho hiện tại
+const (
+	CartLineOK                = "ok"
+	CartLineUnavailable       = "unavailable"        // sản phẩm đã bị xoá
+	CartLineOutOfStock        = "out_of_stock"       // hết hàng
+	CartLineInsufficientStock = "insufficient_stock" // còn ít hơn số lượng trong giỏ
+	CartLineInvalidOptions    = "invalid_options"    // option đã chọn không còn hợp lệ (bị xoá, tạm hết, nhóm đổi min/max)
+)
+
+// CartItem là một dòng trong giỏ, cùng sản phẩm nhưng khác option là hai dòng khác nhau
+type CartItem struct {
+	ProductID int64     `json:"product_id"`
+	Quantity  int64     `json:"quantity"`
+	OptionIDs []int64   `json:"option_ids"`
+	Note      string    `json:"note"` // ghi chú cho bếp, vd "không hành"
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// CartLine là một dòng trong giỏ đã đối chiếu với giá và tồn kho tại thời điểm đọc
+type CartLine struct {
+	ProductID     int64             `json:"product_id"`
+	Name          string            `json:"name"`
+	Quantity      int64             `json:"quantity"`
+	OptionIDs     []int64           `json:"option_ids"`
+	Options       []OrderItemOption `json:"options"`
+	Note          string            `json:"note"`
+	Price         float64           `json:"price"`          // đơn giá đang bán (đã tính flash sale và option)
+	OriginalPrice float64           `json:"original_price"` // đơn giá gốc (đã tính option)
+	Available     int64             `json:"available"`
+	StoreID       int64             `json:"store_id"`
+	LineTotal     float64           `json:"line_total"`
+	Status        string            `json:"status"`
+}
+
+// CartOptionKey là khoá của bộ option trong giỏ: id đã sắp xếp, nối bằng dấu phẩy ("" = không có option)
+func CartOptionKey(optionIDs []int64) string {
+	ids := append([]int64(nil), optionIDs...)
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	parts := make([]string, len(ids))
+	for i, id := range ids {
+		parts[i] = strconv.FormatInt(id, 10)
+	}
+	return strings.Join(parts, ",")
+}
+
+func parseCartOptionKey(key string) []int64 {
+	ids := []int64{}
+	if key == "" {
+		return ids
+	}
+	for _, part := range strings.Split(key, ",") {
+		if id, err := strconv.ParseInt(part, 10, 64); err == nil {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+func GetCartItems(db *sql.DB, userID int64) ([]CartItem, error) {
+	return loadCartItems(db, userID, false)
+}
+
+// LockCartItemsTx đọc giỏ hàng và khoá các dòng (kể cả chặn thêm dòng mới) tới khi tx kết thúc,
+// dùng khi checkout để không xoá mất dòng được thêm/sửa giữa lúc đọc và lúc xoá giỏ
+func LockCartItemsTx(tx *sql.Tx, userID int64) ([]CartItem, error) {
+	return loadCartItems(tx, userID, true)
+}
+
+func loadCartItems(q queryer, userID int64, forUpdate bool) ([]CartItem, error) {
+	query := "select product_id, quantity, option_key, coalesce(note, ''), updated_at from cart_items where user_id = ? order by id"
+	if forUpdate {
+		query += " for update"
+	}
+	rows, err := q.Query(query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := []CartItem{}
+	for rows.Next() {
+		var item CartItem
+		var optionKey string
+		if err := rows.Scan(&item.ProductID, &item.Quantity, &optionKey, &item.Note, &item.UpdatedAt); err != nil {
+			return nil, err
+		}
+		item.OptionIDs = parseCartOptionKey(optionKey)
+		items = append(items, item)
+	}
+	return items, rows.Err()
+}
+
+// thêm dòng vào giỏ, đã có dòng cùng sản phẩm và bộ option thì cộng dồn số lượng,
+// ghi chú mới (nếu có) thay ghi chú cũ
+const addCartItemQuery = `
+	insert into cart_items (user_id, product_id, quantity, option_key, note) values (?, ?, ?, ?, ?)
+	on duplicate key update quantity = quantity + values(quantity), note = coalesce(values(note), note)`
+
+// AddCartItem thêm sản phẩm (với bộ option và ghi chú) vào giỏ
+func AddCartItem(db *sql.DB, userID int64, item CartItem) error {
+	_, err := db.Exec(addCartItemQuery, userID, item.ProductID, item.Quantity, CartOptionKey(item.OptionIDs), nullString(item.Note))
+	return err
+}
+
+// UpdateCartItem đổi số lượng của dòng sản phẩm + bộ option đã có trong giỏ,
+// note = nil thì giữ ghi chú cũ, "" thì xoá ghi chú
+func UpdateCartItem(db *sql.DB, userID, productID int64, optionIDs []int64, quantity int64, note *string) error {
+	var exists int64
+	err := db.QueryRow("select id from cart_items where user_id = ? and product_id = ? and option_key = ?", userID, productID, CartOptionKey(optionIDs)).Scan(&exists)
+	if err != nil {
+		return err
+	}
+	if note == nil {
+		_, err = db.Exec("update cart_items set quantity = ? where id = ?", quantity, exists)
+	} else {
+		_, err = db.Exec("update cart_items set quantity = ?, note = ? where id = ?", quantity, nullString(*note), exists)
+	}
+	return err
+}
+
+func RemoveCartItem(db *sql.DB, userID, productID int64, optionIDs []int64) error {
+	result, err := db.Exec("delete from cart_items where user_id = ? and product_id = ? and option_key = ?", userID, productID, CartOptionKey(optionIDs))
+	if err != nil {
+		return err
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// ReplaceCart thay toàn bộ giỏ hàng của user
+func ReplaceCart(db *sql.DB, userID int64, items []CartItem) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := ClearCartTx(tx, userID); err != nil {
+		return err
+	}
+	for _, item := range items {
+		if _, err := tx.Exec(addCartItemQuery, userID, item.ProductID, item.Quantity, CartOptionKey(item.OptionIDs), nullString(item.Note)); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func ClearCartTx(tx *sql.Tx, userID int64) error {
+	_, err := tx.Exec("delete from cart_items where user_id = ?", userID)
+	return err
+}
+
+func ClearCart(db *sql.DB, userID int64) error {
+	_, err := db.Exec("delete from cart_items where user_id = ?", userID)
+	return err
+}
@@ -0,0 +1,25 @@
+package models
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCartOptionKey(t *testing.T) {
+	if got := CartOptionKey(nil); got != "" {
+		t.Errorf("CartOptionKey(nil) = %q, want empty", got)
+	}
+	ids := []int64{12, 3, 7}
+	if got := CartOptionKey(ids); got != "3,7,12" {
+		t.Errorf("CartOptionKey = %q, want 3,7,12", got)
+	}
+	if !reflect.DeepEqual(ids, []int64{12, 3, 7}) {
+		t.Errorf("CartOptionKey modified its input: %v", ids)
+	}
+	if got := parseCartOptionKey("3,7,12"); !reflect.DeepEqual(got, []int64{3, 7, 12}) {
+		t.Errorf("parseCartOptionKey = %v", got)
+	}
+	if got := parseCartOptionKey(""); len(got) != 0 || got == nil {
+		t.Errorf("parseCartOptionKey(\"\") = %#v, want empty slice", got)
+	}
+}
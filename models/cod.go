@@ -0,0 +1,197 @@
+package models
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+)
+
+// loại bút toán trong sổ tiền mặt COD của shipper
+const (
+	CODCollection = "collection" // shipper thu tiền mặt khi giao đơn
+	CODHandover   = "handover"   // shipper nộp tiền mặt cho cửa hàng
+)
+
+var (
+	ErrShipperNotFound        = errors.New("shipper not found")
+	ErrHandoverExceedsBalance = errors.New("handover amount must be greater than 0 and not exceed the shipper's outstanding cash")
+)
+
+// CODEntry là một bút toán thu/nộp tiền mặt của shipper
+type CODEntry struct {
+	ID         int64     `json:"id"`
+	ShipperID  int64     `json:"shipper_id"`
+	OrderID    *int64    `json:"order_id"`
+	Type       string    `json:"type"` // collection || handover
+	Amount     float64   `json:"amount"`
+	Note       string    `json:"note"`
+	RecordedBy *int64    `json:"recorded_by"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// CODBalance là số tiền mặt shipper đang giữ = đã thu - đã nộp
+type CODBalance struct {
+	ShipperID   int64   `json:"shipper_id"`
+	ShipperName string  `json:"shipper_name"`
+	Phone       string  `json:"phone"`
+	Collected   float64 `json:"collected"`
+	HandedOver  float64 `json:"handed_over"`
+	Outstanding float64 `json:"outstanding"`
+}
+
+const codEntryColumns = "id, shipper_id, order_id, type, amount, coalesce(note, ''), recorded_by, created_at"
+
+func scanCODEntry(row rowScanner, e *CODEntry) error {
+	var orderID, recordedBy sql.NullInt64
+	if err := row.Scan(&e.ID, &e.ShipperID, &orderID, &e.Type, &e.Amount, &e.Note, &recordedBy, &e.CreatedAt); err != nil {
+		return err
+	}
+	if orderID.Valid {
+		e.OrderID = &orderID.Int64
+	}
+	if recordedBy.Valid {
+		e.RecordedBy = &recordedBy.Int64
+	}
+	return nil
+}
+
+// addCODCollectionTx ghi khoản tiền mặt shipper thu của order (order đã được khoá),
+// unique order_id đảm bảo mỗi order chỉ ghi một lần
+func addCODCollectionTx(tx *sql.Tx, shipperID, orderID int64, amount float64) error {
+	_, err := tx.Exec(
+		"insert into cod_entries (shipper_id, order_id, type, amount, recorded_by) values (?, ?, ?, ?, ?)",
+		shipperID, orderID, CODCollection, amount, shipperID,
+	)
+	return err
+}
+
+// lockShipperTx khoá user của shipper để các lần ghi nộp tiền của cùng shipper chạy lần lượt
+func lockShipperTx(tx *sql.Tx, shipperID int64) error {
+	var id int64
+	err := tx.QueryRow("select id from users where id = ? and role = 'shipper' for update", shipperID).Scan(&id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrShipperNotFound
+	}
+	return err
+}
+
+func getCODBalance(q queryer, shipperID int64) (collected, handedOver float64, err error) {
+	err = q.QueryRow(
+		`select coalesce(sum(case when type = ? then amount end), 0), coalesce(sum(case when type = ? then amount end), 0)
+		from cod_entries where shipper_id = ?`,
+		CODCollection, CODHandover, shipperID,
+	).Scan(&collected, &handedOver)
+	return collected, handedOver, err
+}
+
+// AddCODHandover ghi lần shipper nộp tiền mặt, không được nộp quá số tiền đang giữ
+func AddCODHandover(db *sql.DB, shipperID int64, amount float64, actor Actor, note string) (*CODEntry, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if err := lockShipperTx(tx, shipperID); err != nil {
+		return nil, err
+	}
+	collected, handedOver, err := getCODBalance(tx, shipperID)
+	if err != nil {
+		return nil, err
+	}
+	amount = roundMoney(amount)
+	if amount <= 0 || amount > roundMoney(collected-handedOver) {
+		return nil, ErrHandoverExceedsBalance
+	}
+	result, err := tx.Exec(
+		"insert into cod_entries (shipper_id, type, amount, note, recorded_by) values (?, ?, ?, ?, ?)",
+		shipperID, CODHandover, amount, note, actor.ID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, err
+	}
+	var e CODEntry
+	if err := scanCODEntry(tx.QueryRow("select "+codEntryColumns+" from cod_entries where id = ?", id), &e); err != nil {
+		return nil, err
+	}
+	return &e, tx.Commit()
+}
+
+// GetCODBalance trả số dư tiền mặt của một shipper
+func GetCODBalance(db *sql.DB, shipperID int64) (*CODBalance, error) {
+	b := CODBalance{ShipperID: shipperID}
+	err := db.QueryRow("select name, coalesce(phone, '') from users where id = ? and role = 'shipper'", shipperID).Scan(&b.ShipperName, &b.Phone)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrShipperNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if b.Collected, b.HandedOver, err = getCODBalance(db, shipperID); err != nil {
+		return nil, err
+	}
+	b.Outstanding = roundMoney(b.Collected - b.HandedOver)
+	return &b, nil
+}
+
+// GetCODBalances cho admin: số dư của tất cả shipper, shipper giữ nhiều tiền nhất lên đầu
+func GetCODBalances(db *sql.DB, page, limit int) ([]CODBalance, int, error) {
+	offset := (page - 1) * limit
+	var total int
+	if err := db.QueryRow("select count(*) from users where role = 'shipper'").Scan(&total); err != nil {
+		return nil, 0, err
+	}
+	query := `select u.id, u.name, coalesce(u.phone, ''),
+		coalesce(sum(case when c.type = ? then c.amount end), 0) as collected,
+		coalesce(sum(case when c.type = ? then c.amount end), 0) as handed_over,
+		coalesce(sum(case when c.type = ? then c.amount else -c.amount end), 0) as outstanding
+		from users u left join cod_entries c on c.shipper_id = u.id
+		where u.role = 'shipper'
+		group by u.id, u.name, u.phone
+		order by outstanding desc, u.id
+		limit ? offset ?`
+	rows, err := db.Query(query, CODCollection, CODHandover, CODCollection, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	balances := []CODBalance{}
+	for rows.Next() {
+		var b CODBalance
+		if err := rows.Scan(&b.ShipperID, &b.ShipperName, &b.Phone, &b.Collected, &b.HandedOver, &b.Outstanding); err != nil {
+			return nil, 0, err
+		}
+		balances = append(balances, b)
+	}
+	return balances, total, rows.Err()
+}
+
+// GetCODEntries là lịch sử thu/nộp tiền của shipper, mới nhất trước
+func GetCODEntries(db *sql.DB, shipperID int64, page, limit int) ([]CODEntry, int, error) {
+	offset := (page - 1) * limit
+	var total int
+	if err := db.QueryRow("select count(*) from cod_entries where shipper_id = ?", shipperID).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+	query := "select " + codEntryColumns + " from cod_entries where shipper_id = ? order by id desc limit ? offset ?"
+	rows, err := db.Query(query, shipperID, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	entries := []CODEntry{}
+	for rows.Next() {
+		var e CODEntry
+		if err := scanCODEntry(rows, &e); err != nil {
+			return nil, 0, err
+		}
+		entries = append(entries, e)
+	}
+	return entries, total, rows.Err()
+}
@@ -0,0 +1,403 @@
+package models
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+)
+
+const (
+	CouponPercent = "percent"
+	CouponFixed   = "fixed"
+)
+
+// lý do coupon không dùng được cho đơn
+const (
+	CouponErrNotFound      = "not_found"
+	CouponErrInactive      = "inactive"
+	CouponErrNotStarted    = "not_started"
+	CouponErrExpired       = "expired"
+	CouponErrMinOrder      = "min_order_value"
+	CouponErrUsageLimit    = "usage_limit_reached"
+	CouponErrPerUserLimit  = "per_user_limit_reached"
+	CouponErrNotApplicable = "not_applicable"
+)
+
+// CouponError: coupon hợp lệ về cú pháp nhưng không áp dụng được cho đơn
+type CouponError struct {
+	Code   string
+	Reason string
+}
+
+func (e *CouponError) Error() string {
+	return fmt.Sprintf("coupon %s: %s", e.Code, e.Reason)
+}
+
+type Coupon struct {
+	ID            int64     `json:"id"`
+	Code          string    `json:"code"`
+	Description   string    `json:"description"`
+	DiscountType  string    `json:"discount_type"` // percent || fixed
+	DiscountValue float64   `json:"discount_value"`
+	MinOrderValue float64   `json:"min_order_value"`
+	MaxDiscount   float64   `json:"max_discount"` // 0 = không giới hạn
+	StartsAt      time.Time `json:"starts_at"`
+	EndsAt        time.Time `json:"ends_at"`
+	UsageLimit    int       `json:"usage_limit"`    // 0 = không giới hạn
+	PerUserLimit  int       `json:"per_user_limit"` // 0 = không giới hạn
+	UsedCount     int       `json:"used_count"`
+	IsActive      bool      `json:"is_active"`
+	ProductIDs    []int64   `json:"product_ids"` // rỗng = mọi sản phẩm
+	Categories    []string  `json:"categories"`  // rỗng = mọi danh mục
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// CouponLine là một dòng trong giỏ dùng để tính giảm giá
+type CouponLine struct {
+	ProductID int64
+	Category  string
+	Amount    float64 // giá * số lượng
+}
+
+// NormalizeCouponCode: mã coupon không phân biệt hoa thường và khoảng trắng
+func NormalizeCouponCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+func (c *Coupon) restricted() bool {
+	return len(c.ProductIDs) > 0 || len(c.Categories) > 0
+}
+
+// sản phẩm thuộc danh sách sản phẩm hoặc danh mục được áp dụng
+func (c *Coupon) appliesTo(line CouponLine) bool {
+	if !c.restricted() {
+		return true
+	}
+	for _, id := range c.ProductIDs {
+		if id == line.ProductID {
+			return true
+		}
+	}
+	for _, category := range c.Categories {
+		if line.Category != "" && strings.EqualFold(category, line.Category) {
+			return true
+		}
+	}
+	return false
+}
+
+// Discount tính số tiền được giảm cho giỏ hàng tại thời điểm now,
+// chưa kiểm tra số lượt dùng (xem applyCoupon)
+func (c *Coupon) Discount(lines []CouponLine, subtotal float64, now time.Time) (float64, error) {
+	switch {
+	case !c.IsActive:
+		return 0, &CouponError{Code: c.Code, Reason: CouponErrInactive}
+	case now.Before(c.StartsAt):
+		return 0, &CouponError{Code: c.Code, Reason: CouponErrNotStarted}
+	case !now.Before(c.EndsAt):
+		return 0, &CouponError{Code: c.Code, Reason: CouponErrExpired}
+	case subtotal < c.MinOrderValue:
+		return 0, &CouponError{Code: c.Code, Reason: CouponErrMinOrder}
+	}
+
+	// chỉ giảm trên phần tiền của các sản phẩm được áp dụng
+	var eligible float64
+	for _, line := range lines {
+		if c.appliesTo(line) {
+			eligible += line.Amount
+		}
+	}
+	if eligible <= 0 {
+		return 0, &CouponError{Code: c.Code, Reason: CouponErrNotApplicable}
+	}
+
+	discount := c.DiscountValue
+	if c.DiscountType == CouponPercent {
+		discount = math.Round(eligible * c.DiscountValue / 100)
+	}
+	if c.MaxDiscount > 0 && discount > c.MaxDiscount {
+		discount = c.MaxDiscount
+	}
+	if discount > eligible {
+		discount = eligible
+	}
+	return discount, nil
+}
+
+const couponColumns = `id, code, coalesce(description, ''), discount_type, discount_value, min_order_value,
+	coalesce(max_discount, 0), starts_at, ends_at, coalesce(usage_limit, 0), coalesce(per_user_limit, 0),
+	used_count, is_active, created_at`
+
+func scanCoupon(row rowScanner, c *Coupon) error {
+	return row.Scan(&c.ID, &c.Code, &c.Description, &c.DiscountType, &c.DiscountValue, &c.MinOrderValue,
+		&c.MaxDiscount, &c.StartsAt, &c.EndsAt, &c.UsageLimit, &c.PerUserLimit,
+		&c.UsedCount, &c.IsActive, &c.CreatedAt)
+}
+
+// đọc danh sách sản phẩm/danh mục được áp dụng của coupon
+func loadCouponRestrictions(q queryer, c *Coupon) error {
+	c.ProductIDs = []int64{}
+	c.Categories = []string{}
+	rows, err := q.Query("select product_id from coupon_products where coupon_id = ? order by product_id", c.ID)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return err
+		}
+		c.ProductIDs = append(c.ProductIDs, id)
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	catRows, err := q.Query("select category from coupon_categories where coupon_id = ? order by category", c.ID)
+	if err != nil {
+		return err
+	}
+	defer catRows.Close()
+	for catRows.Next() {
+		var category string
+		if err := catRows.Scan(&category); err != nil {
+			return err
+		}
+		c.Categories = append(c.Categories, category)
+	}
+	return catRows.Err()
+}
+
+func getCouponByCode(q queryer, code string, forUpdate bool) (*Coupon, error) {
+	code = NormalizeCouponCode(code)
+	query := "select " + couponColumns + " from coupons where code = ?"
+	if forUpdate {
+		query += " for update"
+	}
+	var c Coupon
+	err := scanCoupon(q.QueryRow(query, code), &c)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, &CouponError{Code: code, Reason: CouponErrNotFound}
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := loadCouponRestrictions(q, &c); err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+// applyCoupon kiểm tra coupon với giỏ hàng và số lượt đã dùng, trả về số tiền giảm
+func applyCoupon(q queryer, code string, userID int64, lines []CouponLine, subtotal float64, forUpdate bool) (*Coupon, float64, error) {
+	c, err := getCouponByCode(q, code, forUpdate)
+	if err != nil {
+		return nil, 0, err
+	}
+	discount, err := c.Discount(lines, subtotal, time.Now())
+	if err != nil {
+		return nil, 0, err
+	}
+	if c.UsageLimit > 0 && c.UsedCount >= c.UsageLimit {
+		return nil, 0, &CouponError{Code: c.Code, Reason: CouponErrUsageLimit}
+	}
+	if c.PerUserLimit > 0 {
+		var used int
+		err := q.QueryRow("select count(*) from coupon_redemptions where coupon_id = ? and user_id = ?", c.ID, userID).Scan(&used)
+		if err != nil {
+			return nil, 0, err
+		}
+		if used >= c.PerUserLimit {
+			return nil, 0, &CouponError{Code: c.Code, Reason: CouponErrPerUserLimit}
+		}
+	}
+	return c, discount, nil
+}
+
+// ValidateCoupon kiểm tra coupon để báo giá, không khoá và không ghi nhận lượt dùng
+func ValidateCoupon(db *sql.DB, code string, userID int64, lines []CouponLine, subtotal float64) (*Coupon, float64, error) {
+	return applyCoupon(db, code, userID, lines, subtotal, false)
+}
+
+// ApplyCouponTx khoá dòng coupon (FOR UPDATE) rồi mới kiểm tra lượt dùng,
+// các đơn dùng cùng coupon sẽ chạy lần lượt nên không vượt giới hạn được.
+// Phải gọi RedeemCouponTx trong cùng transaction.
+func ApplyCouponTx(tx *sql.Tx, code string, userID int64, lines []CouponLine, subtotal float64) (*Coupon, float64, error) {
+	return applyCoupon(tx, code, userID, lines, subtotal, true)
+}
+
+// RedeemCouponTx ghi nhận lượt dùng coupon cho order
+func RedeemCouponTx(tx *sql.Tx, couponID, userID, orderID int64, discount float64) error {
+	query := "insert into coupon_redemptions (coupon_id, user_id, order_id, discount) values (?, ?, ?, ?)"
+	if _, err := tx.Exec(query, couponID, userID, orderID, discount); err != nil {
+		return err
+	}
+	_, err := tx.Exec("update coupons set used_count = used_count + 1 where id = ?", couponID)
+	return err
+}
+
+// ReleaseCouponTx trả lại lượt dùng coupon khi order bị huỷ
+func ReleaseCouponTx(tx *sql.Tx, orderID int64) error {
+	query := `
+		update coupons c
+		join coupon_redemptions r on r.coupon_id = c.id
+		set c.used_count = greatest(c.used_count - 1, 0)
+		where r.order_id = ?`
+	if _, err := tx.Exec(query, orderID); err != nil {
+		return err
+	}
+	_, err := tx.Exec("delete from coupon_redemptions where order_id = ?", orderID)
+	return err
+}
+
+func nullIfZeroFloat(v float64) sql.NullFloat64 {
+	return sql.NullFloat64{Float64: v, Valid: v != 0}
+}
+
+func nullIfZeroInt(v int) sql.NullInt64 {
+	return sql.NullInt64{Int64: int64(v), Valid: v != 0}
+}
+
+// ghi lại danh sách sản phẩm/danh mục áp dụng của coupon
+func replaceCouponRestrictionsTx(tx *sql.Tx, c *Coupon) error {
+	if _, err := tx.Exec("delete from coupon_products where coupon_id = ?", c.ID); err != nil {
+		return err
+	}
+	if _, err := tx.Exec("delete from coupon_categories where coupon_id = ?", c.ID); err != nil {
+		return err
+	}
+	for _, id := range c.ProductIDs {
+		if _, err := tx.Exec("insert ignore into coupon_products (coupon_id, product_id) values (?, ?)", c.ID, id); err != nil {
+			return err
+		}
+	}
+	for _, category := range c.Categories {
+		if _, err := tx.Exec("insert ignore into coupon_categories (coupon_id, category) values (?, ?)", c.ID, category); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func CreateCoupon(db *sql.DB, c *Coupon) (int64, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	c.Code = NormalizeCouponCode(c.Code)
+	query := `
+		insert into coupons (code, description, discount_type, discount_value, min_order_value, max_discount,
+			starts_at, ends_at, usage_limit, per_user_limit, is_active)
+		values (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+	result, err := tx.Exec(query, c.Code, c.Description, c.DiscountType, c.DiscountValue, c.MinOrderValue,
+		nullIfZeroFloat(c.MaxDiscount), c.StartsAt, c.EndsAt, nullIfZeroInt(c.UsageLimit), nullIfZeroInt(c.PerUserLimit), c.IsActive)
+	if err != nil {
+		return 0, err
+	}
+	c.ID, err = result.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+	if err := replaceCouponRestrictionsTx(tx, c); err != nil {
+		return 0, err
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	return c.ID, nil
+}
+
+// UpdateCoupon sửa coupon, không đổi used_count
+func UpdateCoupon(db *sql.DB, c *Coupon) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var exists int64
+	if err := tx.QueryRow("select id from coupons where id = ? for update", c.ID).Scan(&exists); err != nil {
+		return err
+	}
+	c.Code = NormalizeCouponCode(c.Code)
+	query := `
+		update coupons set code = ?, description = ?, discount_type = ?, discount_value = ?, min_order_value = ?,
+			max_discount = ?, starts_at = ?, ends_at = ?, usage_limit = ?, per_user_limit = ?, is_active = ?
+		where id = ?`
+	_, err = tx.Exec(query, c.Code, c.Description, c.DiscountType, c.DiscountValue, c.MinOrderValue,
+		nullIfZeroFloat(c.MaxDiscount), c.StartsAt, c.EndsAt, nullIfZeroInt(c.UsageLimit), nullIfZeroInt(c.PerUserLimit), c.IsActive, c.ID)
+	if err != nil {
+		return err
+	}
+	if err := replaceCouponRestrictionsTx(tx, c); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// DeactivateCoupon ngừng coupon, giữ lại lịch sử dùng
+func DeactivateCoupon(db *sql.DB, couponID int64) error {
+	result, err := db.Exec("update coupons set is_active = false where id = ?", couponID)
+	if err != nil {
+		return err
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		if _, err := GetCouponByID(db, couponID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func GetCouponByID(db *sql.DB, couponID int64) (*Coupon, error) {
+	var c Coupon
+	if err := scanCoupon(db.QueryRow("select "+couponColumns+" from coupons where id = ?", couponID), &c); err != nil {
+		return nil, err
+	}
+	if err := loadCouponRestrictions(db, &c); err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+func GetAllCoupons(db *sql.DB, page, limit int) ([]Coupon, int, error) {
+	offset := (page - 1) * limit
+	var total int
+	if err := db.QueryRow("select count(*) from coupons").Scan(&total); err != nil {
+		return nil, 0, err
+	}
+	rows, err := db.Query("select "+couponColumns+" from coupons order by id desc limit ? offset ?", limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	coupons := []Coupon{}
+	for rows.Next() {
+		var c Coupon
+		if err := scanCoupon(rows, &c); err != nil {
+			return nil, 0, err
+		}
+		coupons = append(coupons, c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, err
+	}
+	rows.Close()
+	for i := range coupons {
+		if err := loadCouponRestrictions(db, &coupons[i]); err != nil {
+			return nil, 0, err
+		}
+	}
+	return coupons, total, nil
+}
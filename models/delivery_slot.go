@@ -0,0 +1,173 @@
+package models
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+)
+
+var (
+	ErrSlotUnavailable     = errors.New("delivery slot is not available")
+	ErrSlotFull            = errors.New("delivery slot is full")
+	ErrInvalidDeliveryDate = errors.New("delivery time must be in the future and within the booking window")
+)
+
+// DeliverySlot là khung giờ giao lặp lại mỗi ngày của store, giờ theo múi giờ của store
+type DeliverySlot struct {
+	ID        int64  `json:"id"`
+	StoreID   int64  `json:"store_id"`
+	StartTime string `json:"start_time"` // HH:MM
+	EndTime   string `json:"end_time"`   // HH:MM
+	Capacity  int    `json:"capacity"`
+	IsActive  bool   `json:"is_active"`
+}
+
+// SlotAvailability là số chỗ còn lại của khung giờ trong một ngày
+type SlotAvailability struct {
+	DeliverySlot
+	ScheduledFor time.Time `json:"scheduled_for"`
+	Booked       int       `json:"booked"`
+	Remaining    int       `json:"remaining"`
+}
+
+// StartOn là thời điểm bắt đầu khung giờ trong ngày date (date đã ở đúng múi giờ)
+func (s *DeliverySlot) StartOn(date time.Time) (time.Time, error) {
+	start, err := time.Parse("15:04", s.StartTime)
+	if err != nil {
+		return time.Time{}, err
+	}
+	y, m, d := date.Date()
+	return time.Date(y, m, d, start.Hour(), start.Minute(), 0, 0, date.Location()), nil
+}
+
+const slotColumns = "id, store_id, time_format(start_time, '%H:%i'), time_format(end_time, '%H:%i'), capacity, is_active"
+
+func scanSlot(row rowScanner, s *DeliverySlot) error {
+	return row.Scan(&s.ID, &s.StoreID, &s.StartTime, &s.EndTime, &s.Capacity, &s.IsActive)
+}
+
+func CreateDeliverySlot(db *sql.DB, s *DeliverySlot) (int64, error) {
+	query := "insert into delivery_slots (store_id, start_time, end_time, capacity, is_active) values (?, ?, ?, ?, ?)"
+	result, err := db.Exec(query, s.StoreID, s.StartTime, s.EndTime, s.Capacity, s.IsActive)
+	if err != nil {
+		return 0, err
+	}
+	return result.LastInsertId()
+}
+
+func UpdateDeliverySlot(db *sql.DB, s *DeliverySlot) error {
+	query := "update delivery_slots set start_time = ?, end_time = ?, capacity = ?, is_active = ? where id = ?"
+	result, err := db.Exec(query, s.StartTime, s.EndTime, s.Capacity, s.IsActive, s.ID)
+	if err != nil {
+		return err
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		if _, err := GetDeliverySlotByID(db, s.ID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// DeactivateDeliverySlot ngừng nhận đơn vào khung giờ, các đơn đã đặt vẫn giữ nguyên
+func DeactivateDeliverySlot(db *sql.DB, slotID int64) error {
+	result, err := db.Exec("update delivery_slots set is_active = false where id = ?", slotID)
+	if err != nil {
+		return err
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		if _, err := GetDeliverySlotByID(db, slotID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func GetDeliverySlotByID(db *sql.DB, slotID int64) (*DeliverySlot, error) {
+	var s DeliverySlot
+	if err := scanSlot(db.QueryRow("select "+slotColumns+" from delivery_slots where id = ?", slotID), &s); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// GetSlotAvailability lấy các khung giờ đang mở của store trong ngày date kèm số chỗ còn lại
+func GetSlotAvailability(db *sql.DB, storeID int64, date time.Time) ([]SlotAvailability, error) {
+	rows, err := db.Query("select "+slotColumns+" from delivery_slots where store_id = ? and is_active = true order by start_time", storeID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	slots := []SlotAvailability{}
+	for rows.Next() {
+		var s SlotAvailability
+		if err := scanSlot(rows, &s.DeliverySlot); err != nil {
+			return nil, err
+		}
+		slots = append(slots, s)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	rows.Close()
+
+	for i := range slots {
+		if slots[i].ScheduledFor, err = slots[i].StartOn(date); err != nil {
+			return nil, err
+		}
+		if slots[i].Booked, err = countSlotBookings(db, slots[i].ID, slots[i].ScheduledFor); err != nil {
+			return nil, err
+		}
+		slots[i].Remaining = max(slots[i].Capacity-slots[i].Booked, 0)
+	}
+	return slots, nil
+}
+
+// số đơn (chưa huỷ) đã đặt vào khung giờ tại thời điểm scheduledFor
+func countSlotBookings(q queryer, slotID int64, scheduledFor time.Time) (int, error) {
+	var booked int
+	query := "select count(*) from orders where delivery_slot_id = ? and scheduled_for = ? and order_status <> ?"
+	err := q.QueryRow(query, slotID, scheduledFor, OrderStatusCancelled).Scan(&booked)
+	return booked, err
+}
+
+// BookDeliverySlotTx khoá khung giờ (FOR UPDATE) rồi đếm số đơn đã đặt,
+// các đơn đặt cùng khung giờ chạy lần lượt nên không vượt capacity.
+// Trả về thời điểm bắt đầu khung giờ để ghi vào orders.scheduled_for.
+func BookDeliverySlotTx(tx *sql.Tx, slotID, storeID int64, date time.Time, now time.Time, maxDaysAhead int) (time.Time, error) {
+	var s DeliverySlot
+	err := scanSlot(tx.QueryRow("select "+slotColumns+" from delivery_slots where id = ? for update", slotID), &s)
+	if errors.Is(err, sql.ErrNoRows) {
+		return time.Time{}, ErrSlotUnavailable
+	}
+	if err != nil {
+		return time.Time{}, err
+	}
+	if !s.IsActive || s.StoreID != storeID {
+		return time.Time{}, ErrSlotUnavailable
+	}
+	scheduledFor, err := s.StartOn(date)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if !scheduledFor.After(now) || scheduledFor.After(now.AddDate(0, 0, maxDaysAhead)) {
+		return time.Time{}, ErrInvalidDeliveryDate
+	}
+	booked, err := countSlotBookings(tx, s.ID, scheduledFor)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if booked >= s.Capacity {
+		return time.Time{}, ErrSlotFull
+	}
+	return scheduledFor, nil
+}
@@ -0,0 +1,112 @@
+package models
+
+import (
+	"database/sql"
+	"time"
+)
+
+// OrderItemExport là một dòng sản phẩm trong file export order
+type OrderItemExport struct {
+	OrderID     int64
+	OrderItemID int64
+	ProductID   int64
+	ProductName string
+	Quantity    int64
+	Price       float64
+	Subtotal    float64
+}
+
+// CustomerExport là một khách hàng trong file export, kèm số đơn và tổng tiền đã thanh toán
+type CustomerExport struct {
+	ID         int64
+	Name       string
+	Email      string
+	Phone      string
+	Address    string
+	Status     int
+	CreatedAt  time.Time
+	OrderCount int64
+	TotalSpent float64
+}
+
+// EachAdminOrder duyệt lần lượt các order theo filter (không phân trang),
+// fn trả lỗi thì dừng. Dùng cho export để không phải nạp hết vào bộ nhớ.
+func EachAdminOrder(db *sql.DB, filter OrderFilter, fn func(*AdminOrderSummary) error) error {
+	where, args := filter.where()
+	rows, err := db.Query(adminOrderSelect+where+filter.orderBy(), args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var resp AdminOrderSummary
+		if err := scanAdminOrder(rows, &resp); err != nil {
+			return err
+		}
+		if err := fn(&resp); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// EachAdminOrderItem duyệt các sản phẩm của những order khớp filter, cùng thứ tự với EachAdminOrder
+func EachAdminOrderItem(db *sql.DB, filter OrderFilter, fn func(*OrderItemExport) error) error {
+	where, args := filter.where()
+	query := `select oi.order_id, oi.id, oi.product_id, p.name, oi.quantity, oi.price
+		from order_items oi
+		join orders o on oi.order_id = o.id
+		join users u on o.user_id = u.id
+		join Products p on oi.product_id = p.id` + where + filter.orderBy() + ", oi.id"
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var item OrderItemExport
+		if err := rows.Scan(&item.OrderID, &item.OrderItemID, &item.ProductID, &item.ProductName, &item.Quantity, &item.Price); err != nil {
+			return err
+		}
+		item.Subtotal = roundMoney(item.Price * float64(item.Quantity))
+		if err := fn(&item); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// EachCustomer duyệt toàn bộ khách hàng theo id, giống danh sách /admin/customers
+func EachCustomer(db *sql.DB, fn func(*CustomerExport) error) error {
+	query := `select u.id, u.name, u.email, coalesce(u.phone, ''), coalesce(u.address, ''), coalesce(u.status, 0), u.created_at,
+		       coalesce(t.order_count, 0), coalesce(t.total_spent, 0)
+		from users u
+		left join (
+			select user_id, count(*) as order_count,
+			       sum(case when payment_status in ('paid', 'partially_refunded') then total_amount else 0 end) as total_spent
+			from orders
+			group by user_id
+		) t on t.user_id = u.id
+		where u.role = 'customer'
+		order by u.id`
+	rows, err := db.Query(query)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var c CustomerExport
+		var createdAt sql.NullTime
+		if err := rows.Scan(&c.ID, &c.Name, &c.Email, &c.Phone, &c.Address, &c.Status, &createdAt, &c.OrderCount, &c.TotalSpent); err != nil {
+			return err
+		}
+		c.CreatedAt = createdAt.Time
+		if err := fn(&c); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
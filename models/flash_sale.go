@@ -0,0 +1,319 @@
+package models
+
+import (
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+)
+
+var ErrFlashSaleSoldOut = errors.New("flash sale quantity is sold out")
+
+type FlashSale struct {
+	ID        int64           `json:"id"`
+	Name      string          `json:"name"`
+	StartsAt  time.Time       `json:"starts_at"`
+	EndsAt    time.Time       `json:"ends_at"`
+	IsActive  bool            `json:"is_active"`
+	Items     []FlashSaleItem `json:"items"`
+	CreatedAt time.Time       `json:"created_at"`
+}
+
+type FlashSaleItem struct {
+	ID          int64   `json:"id"`
+	FlashSaleID int64   `json:"flash_sale_id"`
+	ProductID   int64   `json:"product_id"`
+	SalePrice   float64 `json:"sale_price"`
+	QtyLimit    int64   `json:"qty_limit"`
+	QtySold     int64   `json:"qty_sold"`
+}
+
+// ActiveSale là giá flash sale đang áp dụng cho một sản phẩm
+type ActiveSale struct {
+	ItemID      int64
+	FlashSaleID int64
+	ProductID   int64
+	SalePrice   float64
+	Remaining   int64
+	EndsAt      time.Time
+}
+
+// ProductFlashSale là phần flash sale hiển thị trong product response
+type ProductFlashSale struct {
+	FlashSaleID      int64     `json:"flash_sale_id"`
+	SalePrice        float64   `json:"sale_price"`
+	QtyRemaining     int64     `json:"qty_remaining"`
+	EndsAt           time.Time `json:"ends_at"`
+	SecondsRemaining int64     `json:"seconds_remaining"`
+}
+
+func loadActiveSales(q queryer, productIDs []int64, now time.Time, forUpdate bool) (map[int64]*ActiveSale, error) {
+	sales := make(map[int64]*ActiveSale)
+	if len(productIDs) == 0 {
+		return sales, nil
+	}
+	placeholders := strings.TrimSuffix(strings.Repeat("?,", len(productIDs)), ",")
+	// sản phẩm nằm trong nhiều flash sale cùng lúc thì lấy giá thấp nhất
+	query := `
+		select fi.id, fi.flash_sale_id, fi.product_id, fi.sale_price, fi.qty_limit - fi.qty_sold, fs.ends_at
+		from flash_sale_items fi
+		join flash_sales fs on fs.id = fi.flash_sale_id
+		where fs.is_active = true and fs.starts_at <= ? and fs.ends_at > ?
+		  and fi.qty_sold < fi.qty_limit
+		  and fi.product_id in (` + placeholders + `)
+		order by fi.product_id, fi.sale_price, fi.id`
+	if forUpdate {
+		query += " for update of fi"
+	}
+	args := []interface{}{now, now}
+	for _, id := range productIDs {
+		args = append(args, id)
+	}
+
+	rows, err := q.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var s ActiveSale
+		if err := rows.Scan(&s.ItemID, &s.FlashSaleID, &s.ProductID, &s.SalePrice, &s.Remaining, &s.EndsAt); err != nil {
+			return nil, err
+		}
+		if _, ok := sales[s.ProductID]; !ok {
+			sales[s.ProductID] = &s
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return sales, nil
+}
+
+// GetActiveSales lấy giá flash sale đang chạy của các sản phẩm, không khoá
+func GetActiveSales(db *sql.DB, productIDs []int64, now time.Time) (map[int64]*ActiveSale, error) {
+	return loadActiveSales(db, productIDs, now, false)
+}
+
+// LockActiveSalesTx như GetActiveSales nhưng khoá dòng flash_sale_items tới khi commit
+func LockActiveSalesTx(tx *sql.Tx, productIDs []int64, now time.Time) (map[int64]*ActiveSale, error) {
+	return loadActiveSales(tx, productIDs, now, true)
+}
+
+// ApplyFlashSales đổi price của sản phẩm sang giá flash sale nếu đang có,
+// giá gốc giữ ở OriginalPrice
+func ApplyFlashSales(db *sql.DB, products []ProductResponse, now time.Time) error {
+	ids := make([]int64, 0, len(products))
+	for i := range products {
+		products[i].OriginalPrice = products[i].Price
+		ids = append(ids, products[i].ID)
+	}
+	sales, err := GetActiveSales(db, ids, now)
+	if err != nil {
+		return err
+	}
+	for i := range products {
+		sale, ok := sales[products[i].ID]
+		if !ok {
+			continue
+		}
+		products[i].Price = sale.SalePrice
+		products[i].FlashSale = &ProductFlashSale{
+			FlashSaleID:      sale.FlashSaleID,
+			SalePrice:        sale.SalePrice,
+			QtyRemaining:     sale.Remaining,
+			EndsAt:           sale.EndsAt,
+			SecondsRemaining: int64(sale.EndsAt.Sub(now).Seconds()),
+		}
+	}
+	return nil
+}
+
+// ReserveFlashSaleTx tăng qty_sold của flash sale, chỉ thành công khi còn suất
+func ReserveFlashSaleTx(tx *sql.Tx, itemID int64, quantity int64) error {
+	query := "update flash_sale_items set qty_sold = qty_sold + ? where id = ? and qty_limit - qty_sold >= ?"
+	result, err := tx.Exec(query, quantity, itemID, quantity)
+	if err != nil {
+		return err
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrFlashSaleSoldOut
+	}
+	return nil
+}
+
+// ReleaseFlashSaleTx trả lại suất flash sale của order (khi huỷ đơn)
+func ReleaseFlashSaleTx(tx *sql.Tx, orderID int64) error {
+	query := `
+		update flash_sale_items fi
+		join (select flash_sale_item_id, sum(quantity) as qty from order_items
+		      where order_id = ? and flash_sale_item_id is not null group by flash_sale_item_id) oi
+		  on fi.id = oi.flash_sale_item_id
+		set fi.qty_sold = greatest(fi.qty_sold - oi.qty, 0)`
+	_, err := tx.Exec(query, orderID)
+	return err
+}
+
+func insertFlashSaleItemsTx(tx *sql.Tx, fs *FlashSale) error {
+	for i := range fs.Items {
+		item := &fs.Items[i]
+		item.FlashSaleID = fs.ID
+		query := "insert into flash_sale_items (flash_sale_id, product_id, sale_price, qty_limit) values (?, ?, ?, ?)"
+		result, err := tx.Exec(query, fs.ID, item.ProductID, item.SalePrice, item.QtyLimit)
+		if err != nil {
+			return err
+		}
+		if item.ID, err = result.LastInsertId(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func CreateFlashSale(db *sql.DB, fs *FlashSale) (int64, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	query := "insert into flash_sales (name, starts_at, ends_at, is_active) values (?, ?, ?, ?)"
+	result, err := tx.Exec(query, fs.Name, fs.StartsAt, fs.EndsAt, fs.IsActive)
+	if err != nil {
+		return 0, err
+	}
+	if fs.ID, err = result.LastInsertId(); err != nil {
+		return 0, err
+	}
+	if err := insertFlashSaleItemsTx(tx, fs); err != nil {
+		return 0, err
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	return fs.ID, nil
+}
+
+// UpdateFlashSale sửa thời gian và danh sách sản phẩm,
+// sản phẩm còn trong danh sách giữ nguyên số lượng đã bán
+func UpdateFlashSale(db *sql.DB, fs *FlashSale) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var exists int64
+	if err := tx.QueryRow("select id from flash_sales where id = ? for update", fs.ID).Scan(&exists); err != nil {
+		return err
+	}
+	query := "update flash_sales set name = ?, starts_at = ?, ends_at = ?, is_active = ? where id = ?"
+	if _, err := tx.Exec(query, fs.Name, fs.StartsAt, fs.EndsAt, fs.IsActive, fs.ID); err != nil {
+		return err
+	}
+
+	keep := make([]interface{}, 0, len(fs.Items)+1)
+	keep = append(keep, fs.ID)
+	for i := range fs.Items {
+		item := &fs.Items[i]
+		item.FlashSaleID = fs.ID
+		upsert := `
+			insert into flash_sale_items (flash_sale_id, product_id, sale_price, qty_limit) values (?, ?, ?, ?)
+			on duplicate key update sale_price = values(sale_price), qty_limit = values(qty_limit)`
+		if _, err := tx.Exec(upsert, fs.ID, item.ProductID, item.SalePrice, item.QtyLimit); err != nil {
+			return err
+		}
+		keep = append(keep, item.ProductID)
+	}
+	del := "delete from flash_sale_items where flash_sale_id = ?"
+	if len(fs.Items) > 0 {
+		del += " and product_id not in (" + strings.TrimSuffix(strings.Repeat("?,", len(fs.Items)), ",") + ")"
+	}
+	if _, err := tx.Exec(del, keep...); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func DeleteFlashSale(db *sql.DB, flashSaleID int64) error {
+	result, err := db.Exec("delete from flash_sales where id = ?", flashSaleID)
+	if err != nil {
+		return err
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+func getFlashSaleItems(db *sql.DB, flashSaleID int64) ([]FlashSaleItem, error) {
+	query := "select id, flash_sale_id, product_id, sale_price, qty_limit, qty_sold from flash_sale_items where flash_sale_id = ? order by id"
+	rows, err := db.Query(query, flashSaleID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []FlashSaleItem{}
+	for rows.Next() {
+		var item FlashSaleItem
+		if err := rows.Scan(&item.ID, &item.FlashSaleID, &item.ProductID, &item.SalePrice, &item.QtyLimit, &item.QtySold); err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+	return items, rows.Err()
+}
+
+func GetFlashSaleByID(db *sql.DB, flashSaleID int64) (*FlashSale, error) {
+	var fs FlashSale
+	query := "select id, name, starts_at, ends_at, is_active, created_at from flash_sales where id = ?"
+	err := db.QueryRow(query, flashSaleID).Scan(&fs.ID, &fs.Name, &fs.StartsAt, &fs.EndsAt, &fs.IsActive, &fs.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	if fs.Items, err = getFlashSaleItems(db, fs.ID); err != nil {
+		return nil, err
+	}
+	return &fs, nil
+}
+
+func GetAllFlashSales(db *sql.DB, page, limit int) ([]FlashSale, int, error) {
+	offset := (page - 1) * limit
+	var total int
+	if err := db.QueryRow("select count(*) from flash_sales").Scan(&total); err != nil {
+		return nil, 0, err
+	}
+	query := "select id, name, starts_at, ends_at, is_active, created_at from flash_sales order by starts_at desc limit ? offset ?"
+	rows, err := db.Query(query, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	sales := []FlashSale{}
+	for rows.Next() {
+		var fs FlashSale
+		if err := rows.Scan(&fs.ID, &fs.Name, &fs.StartsAt, &fs.EndsAt, &fs.IsActive, &fs.CreatedAt); err != nil {
+			return nil, 0, err
+		}
+		sales = append(sales, fs)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, err
+	}
+	rows.Close()
+	for i := range sales {
+		if sales[i].Items, err = getFlashSaleItems(db, sales[i].ID); err != nil {
+			return nil, 0, err
+		}
+	}
+	return sales, total, nil
+}
@@ -0,0 +1,87 @@
+package models
+
+import (
+	"database/sql"
+	"time"
+)
+
+const (
+	IdempotencyProcessing = "processing"
+	IdempotencyCompleted  = "completed"
+)
+
+// IdempotencyKey lưu kết quả của một request có header Idempotency-Key
+type IdempotencyKey struct {
+	ID           int64
+	UserID       int64
+	Key          string
+	Scope        string
+	RequestHash  string
+	Status       string
+	ResponseCode int
+	ContentType  string
+	ResponseBody []byte
+}
+
+// CreateIdempotencyKey giữ chỗ cho key, trả về false nếu key đã tồn tại.
+// Key còn processing quá staleAfter (request trước bị panic hoặc server chết) thì được giữ lại từ đầu.
+func CreateIdempotencyKey(db *sql.DB, userID int64, key, scope, requestHash string, staleAfter time.Duration) (bool, error) {
+	_, err := db.Exec(`delete from idempotency_keys
+		where user_id = ? and idem_key = ? and status = ? and updated_at < now() - interval ? second`,
+		userID, key, IdempotencyProcessing, int64(staleAfter.Seconds()))
+	if err != nil {
+		return false, err
+	}
+	query := `insert ignore into idempotency_keys (user_id, idem_key, scope, request_hash, status) values (?, ?, ?, ?, ?)`
+	result, err := db.Exec(query, userID, key, scope, requestHash, IdempotencyProcessing)
+	if err != nil {
+		return false, err
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return n == 1, nil
+}
+
+func GetIdempotencyKey(db *sql.DB, userID int64, key string) (*IdempotencyKey, error) {
+	query := `select id, user_id, idem_key, scope, request_hash, status, response_code, coalesce(content_type, ''), response_body
+		from idempotency_keys where user_id = ? and idem_key = ?`
+	var (
+		k    IdempotencyKey
+		code sql.NullInt64
+		body sql.NullString
+	)
+	err := db.QueryRow(query, userID, key).Scan(&k.ID, &k.UserID, &k.Key, &k.Scope, &k.RequestHash, &k.Status, &code, &k.ContentType, &body)
+	if err != nil {
+		return nil, err
+	}
+	k.ResponseCode = int(code.Int64)
+	k.ResponseBody = []byte(body.String)
+	return &k, nil
+}
+
+// CompleteIdempotencyKey lưu response (kèm Content-Type) để trả lại khi client gửi lại cùng key
+func CompleteIdempotencyKey(db *sql.DB, userID int64, key string, code int, contentType string, body []byte) error {
+	query := `update idempotency_keys set status = ?, response_code = ?, content_type = ?, response_body = ? where user_id = ? and idem_key = ?`
+	_, err := db.Exec(query, IdempotencyCompleted, code, contentType, string(body), userID, key)
+	return err
+}
+
+// DeleteIdempotencyKey xoá key (khi request lỗi server) để client có thể thử lại
+func DeleteIdempotencyKey(db *sql.DB, userID int64, key string) error {
+	_, err := db.Exec(`delete from idempotency_keys where user_id = ? and idem_key = ?`, userID, key)
+	return err
+}
+
+// CleanUpIdempotencyKeys xoá key đã lưu quá retention và key processing bị bỏ dở quá staleAfter,
+// trả về số key đã xoá
+func CleanUpIdempotencyKeys(db *sql.DB, retention, staleAfter time.Duration) (int64, error) {
+	result, err := db.Exec(`delete from idempotency_keys
+		where updated_at < now() - interval ? second or (status = ? and updated_at < now() - interval ? second)`,
+		int64(retention.Seconds()), IdempotencyProcessing, int64(staleAfter.Seconds()))
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
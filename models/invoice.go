@@ -0,0 +1,104 @@
+package models
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+var (
+	ErrInvoiceNotAvailable = errors.New("cancelled orders have no invoice")
+	ErrInvoiceNotReady     = errors.New("invoice is issued once the order is paid or delivered")
+)
+
+// Invoice là số hoá đơn đã cấp cho order, nội dung hoá đơn lấy từ order
+type Invoice struct {
+	ID       int64     `json:"id"`
+	OrderID  int64     `json:"order_id"`
+	Number   string    `json:"invoice_no"`
+	IssuedAt time.Time `json:"issued_at"`
+}
+
+func getInvoiceByOrder(q queryer, orderID int64) (*Invoice, error) {
+	var inv Invoice
+	err := q.QueryRow("select id, order_id, invoice_no, issued_at from invoices where order_id = ?", orderID).
+		Scan(&inv.ID, &inv.OrderID, &inv.Number, &inv.IssuedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &inv, nil
+}
+
+// chỉ cấp số hoá đơn cho order đã thanh toán hoặc đã giao, để order chờ rồi bị huỷ không giữ số
+func canIssueInvoice(paymentStatus, orderStatus string) bool {
+	switch {
+	case orderStatus == OrderStatusCancelled:
+		return false
+	case orderStatus == OrderStatusDelivered:
+		return true
+	}
+	return paymentStatus == PaymentStatusPaid || paymentStatus == PaymentStatusPartiallyRefunded || paymentStatus == PaymentStatusRefunded
+}
+
+// GetOrCreateInvoice trả hoá đơn của order, lần đầu thì cấp số mới. Số hoá đơn tăng
+// liên tục theo từng năm (HD2026-000001, ...), cấp trong transaction nên không bị nhảy số.
+// Order chưa thanh toán và chưa giao trả ErrInvoiceNotReady.
+// now là thời điểm cấp theo giờ cửa hàng.
+func GetOrCreateInvoice(db *sql.DB, orderID int64, now time.Time) (*Invoice, error) {
+	inv, err := getInvoiceByOrder(db, orderID)
+	if err == nil {
+		return inv, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	// khoá order để hai request cùng lúc không cấp hai số cho một order
+	st, err := lockOrderTx(tx, orderID)
+	if err != nil {
+		return nil, err
+	}
+	inv, err = getInvoiceByOrder(tx, orderID)
+	if err == nil {
+		return inv, tx.Commit()
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+	if st.OrderStatus == OrderStatusCancelled {
+		return nil, ErrInvoiceNotAvailable
+	}
+	if !canIssueInvoice(st.PaymentStatus, st.OrderStatus) {
+		return nil, ErrInvoiceNotReady
+	}
+
+	year := now.Year()
+	_, err = tx.Exec("insert into invoice_sequences (year, last_number) values (?, 1) on duplicate key update last_number = last_number + 1", year)
+	if err != nil {
+		return nil, err
+	}
+	var seq int64
+	if err := tx.QueryRow("select last_number from invoice_sequences where year = ?", year).Scan(&seq); err != nil {
+		return nil, err
+	}
+	number := fmt.Sprintf("HD%d-%06d", year, seq)
+	result, err := tx.Exec("insert into invoices (order_id, invoice_no, issued_at) values (?, ?, ?)", orderID, number, now)
+	if err != nil {
+		return nil, err
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return &Invoice{ID: id, OrderID: orderID, Number: number, IssuedAt: now}, nil
+}
@@ -0,0 +1,23 @@
+package models
+
+import "testing"
+
+func TestCanIssueInvoice(t *testing.T) {
+	tests := []struct {
+		paymentStatus string
+		orderStatus   string
+		want          bool
+	}{
+		{PaymentStatusUnpaid, OrderStatusPending, false},
+		{PaymentStatusUnpaid, OrderStatusShipping, false},
+		{PaymentStatusUnpaid, OrderStatusDelivered, true}, // COD đã giao
+		{PaymentStatusPaid, OrderStatusPending, true},
+		{PaymentStatusPartiallyRefunded, OrderStatusDelivered, true},
+		{PaymentStatusPaid, OrderStatusCancelled, false},
+	}
+	for _, tt := range tests {
+		if got := canIssueInvoice(tt.paymentStatus, tt.orderStatus); got != tt.want {
+			t.Errorf("canIssueInvoice(%q, %q) = %v, want %v", tt.paymentStatus, tt.orderStatus, got, tt.want)
+		}
+	}
+}
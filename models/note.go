@@ -0,0 +1,82 @@
+package models
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// độ dài tối đa (số ký tự) của ghi chú
+const (
+	MaxItemNoteLength            = 200 // ghi chú món, vd "không hành"
+	MaxDeliveryInstructionLength = 500 // dặn shipper, vd "gọi khi tới cổng"
+)
+
+// NoteError được trả về khi ghi chú dài quá giới hạn
+type NoteError struct {
+	Field     string // delivery_instruction || products[i].note
+	Max       int
+	ProductID int64 // 0 với ghi chú của order
+}
+
+func (e *NoteError) Error() string {
+	return fmt.Sprintf("%s must be at most %d characters", e.Field, e.Max)
+}
+
+// CleanNote chuẩn hoá ghi chú do khách nhập: NFC, bỏ ký tự điều khiển và ký tự ẩn
+// (zero-width, đảo chiều văn bản), gộp khoảng trắng/xuống dòng thành một dấu cách.
+// Nội dung vẫn được escape khi hiển thị (HTML hoá đơn, frontend).
+func CleanNote(s string) string {
+	s = norm.NFC.String(strings.ToValidUTF8(s, ""))
+	var b strings.Builder
+	space := false
+	for _, r := range s {
+		switch {
+		case unicode.IsSpace(r):
+			space = true
+			continue
+		case unicode.IsControl(r), unicode.Is(unicode.Cf, r):
+			continue
+		}
+		if space && b.Len() > 0 {
+			b.WriteByte(' ')
+		}
+		space = false
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// NormalizeOrderNotes làm sạch ghi chú của order và từng sản phẩm trong req, kiểm tra độ dài
+func NormalizeOrderNotes(req *CreateOrderRequest) error {
+	req.DeliveryInstruction = CleanNote(req.DeliveryInstruction)
+	if utf8.RuneCountInString(req.DeliveryInstruction) > MaxDeliveryInstructionLength {
+		return &NoteError{Field: "delivery_instruction", Max: MaxDeliveryInstructionLength}
+	}
+	for i := range req.Products {
+		note, err := NormalizeItemNote(fmt.Sprintf("products[%d].note", i), req.Products[i].ProductID, req.Products[i].Note)
+		if err != nil {
+			return err
+		}
+		req.Products[i].Note = note
+	}
+	return nil
+}
+
+// NormalizeItemNote làm sạch ghi chú của một sản phẩm (trong đơn hoặc giỏ hàng), kiểm tra độ dài
+func NormalizeItemNote(field string, productID int64, note string) (string, error) {
+	note = CleanNote(note)
+	if utf8.RuneCountInString(note) > MaxItemNoteLength {
+		return "", &NoteError{Field: field, Max: MaxItemNoteLength, ProductID: productID}
+	}
+	return note, nil
+}
+
+// chuỗi rỗng lưu NULL
+func nullString(s string) sql.NullString {
+	return sql.NullString{String: s, Valid: s != ""}
+}
@@ -0,0 +1,60 @@
+package models
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestCleanNote(t *testing.T) {
+	cases := []struct {
+		in   string
+		want string
+	}{
+		{"  không hành  ", "không hành"},
+		{"gọi\r\nkhi tới\t\tcổng", "gọi khi tới cổng"},
+		{"ít\u200b đá\u202e", "ít đá"},
+		{"bell\x07 \x00ok", "bell ok"},
+		{"Kho\u0061\u0301ng", "Khoáng"}, // tổ hợp dấu -> NFC
+		{"\xff<b>cay</b>", "<b>cay</b>"},
+		{" \n\t ", ""},
+	}
+	for _, c := range cases {
+		if got := CleanNote(c.in); got != c.want {
+			t.Errorf("CleanNote(%q) = %q, want %q", c.in, got, c.want)
+		}
+	}
+}
+
+func TestNormalizeOrderNotes(t *testing.T) {
+	req := &CreateOrderRequest{
+		DeliveryInstruction: "  gọi khi tới cổng ",
+		Products: []CreateOrderItemRequest{
+			{ProductID: 1, Quantity: 1, Note: " không   hành"},
+			{ProductID: 2, Quantity: 1},
+		},
+	}
+	if err := NormalizeOrderNotes(req); err != nil {
+		t.Fatal(err)
+	}
+	if req.DeliveryInstruction != "gọi khi tới cổng" || req.Products[0].Note != "không hành" || req.Products[1].Note != "" {
+		t.Fatalf("notes not cleaned: %+v", req)
+	}
+
+	// giới hạn tính theo ký tự, không theo byte
+	req.Products[1].Note = strings.Repeat("ă", MaxItemNoteLength)
+	if err := NormalizeOrderNotes(req); err != nil {
+		t.Fatalf("note of %d characters should be accepted: %v", MaxItemNoteLength, err)
+	}
+	req.Products[1].Note += "a"
+	var noteErr *NoteError
+	if err := NormalizeOrderNotes(req); !errors.As(err, &noteErr) || noteErr.ProductID != 2 || noteErr.Field != "products[1].note" {
+		t.Fatalf("want NoteError for product 2, got %v", err)
+	}
+
+	req.Products[1].Note = ""
+	req.DeliveryInstruction = strings.Repeat("x", MaxDeliveryInstructionLength+1)
+	if err := NormalizeOrderNotes(req); !errors.As(err, &noteErr) || noteErr.Field != "delivery_instruction" {
+		t.Fatalf("want NoteError for delivery_instruction, got %v", err)
+	}
+}
@@ -2,56 +2,89 @@ package models
 
 import (
 	"database/sql"
-	"errors"
 	"fmt"
+	"strings"
 	"time"
 )
 
 type Order struct {
-	ID            int64     `json:"id"`
-	UserID        int64     `json:"user_id"`
-	ShipperID     int64     `json:"shipper_id"`
-	PaymentStatus string    `json:"payment_status"` // unpaid || paid || refund
-	OrderStatus   string    `json:"order_status"`   // pending || processing ||shipped || delivered || canceled
-	Latitude      float64   `json:"latitude"`
-	Longitude     float64   `json:"longitude"`
-	TotalAmount   float64   `json:"total_amount"`
-	ThumbnailID   int       `json:"thumbnail_id"`
-	CreatedAt     time.Time `json:"created_at"`
-	UpdatedAt     time.Time `json:"updated_at"`
+	ID                  int64      `json:"id"`
+	UserID              int64      `json:"user_id"`
+	ShipperID           int64      `json:"shipper_id"`
+	StoreID             int64      `json:"store_id"`
+	PaymentStatus       string     `json:"payment_status"` // unpaid || paid || partially_refunded || refunded
+	OrderStatus         string     `json:"order_status"`   // pending || processing || shipping || delivered || cancelled
+	Latitude            float64    `json:"latitude"`
+	Longitude           float64    `json:"longitude"`
+	AddressID           int64      `json:"address_id"` // 0 = nhập toạ độ trực tiếp
+	AddressLabel        string     `json:"address_label"`
+	DeliveryAddress     string     `json:"delivery_address"` // bản sao địa chỉ lúc đặt hàng
+	DriverNote          string     `json:"driver_note"`
+	DeliveryInstruction string     `json:"delivery_instruction"` // dặn shipper cho riêng order này, driver_note là ghi chú theo địa chỉ
+	Subtotal            float64    `json:"subtotal"`
+	DeliveryFee         float64    `json:"delivery_fee"`
+	Discount            float64    `json:"discount"`
+	TotalAmount         float64    `json:"total_amount"`
+	DeliverySlotID      int64      `json:"delivery_slot_id"` // 0 = giao ngay
+	ScheduledFor        *time.Time `json:"scheduled_for"`    // giờ bắt đầu khung giờ giao, nil = giao ngay
+	ThumbnailID         int        `json:"thumbnail_id"`
+	CreatedAt           time.Time  `json:"created_at"`
+	UpdatedAt           time.Time  `json:"updated_at"`
 }
 type OrderResponse struct {
-	ID            int64     `json:"id"`
-	UserID        int64     `json:"user_id"`
-	UserName      string    `json:"user_name"`
-	Phone         string    `json:"phone"`
-	ShipperID     int64     `json:"shipper_id"`
-	PaymentStatus string    `json:"payment_status"` // unpaid || paid || refund
-	OrderStatus   string    `json:"order_status"`   // pending || processing ||shipped || delivered || canceled
-	Latitude      float64   `json:"latitude"`
-	Longitude     float64   `json:"longitude"`
-	TotalAmount   float64   `json:"total_amount"`
-	ThumbnailID   int       `json:"thumbnail_id"`
-	CreatedAt     time.Time `json:"created_at"`
-	UpdatedAt     time.Time `json:"updated_at"`
+	ID                  int64      `json:"id"`
+	UserID              int64      `json:"user_id"`
+	UserName            string     `json:"user_name"`
+	Phone               string     `json:"phone"`
+	ShipperID           int64      `json:"shipper_id"`
+	StoreID             int64      `json:"store_id"`
+	PaymentStatus       string     `json:"payment_status"` // unpaid || paid || partially_refunded || refunded
+	OrderStatus         string     `json:"order_status"`   // pending || processing || shipping || delivered || cancelled
+	Latitude            float64    `json:"latitude"`
+	Longitude           float64    `json:"longitude"`
+	AddressID           int64      `json:"address_id"` // 0 = nhập toạ độ trực tiếp
+	AddressLabel        string     `json:"address_label"`
+	DeliveryAddress     string     `json:"delivery_address"` // bản sao địa chỉ lúc đặt hàng
+	DriverNote          string     `json:"driver_note"`
+	DeliveryInstruction string     `json:"delivery_instruction"` // dặn shipper cho riêng order này, driver_note là ghi chú theo địa chỉ
+	Subtotal            float64    `json:"subtotal"`
+	DeliveryFee         float64    `json:"delivery_fee"`
+	Discount            float64    `json:"discount"`
+	TotalAmount         float64    `json:"total_amount"`
+	DeliverySlotID      int64      `json:"delivery_slot_id"` // 0 = giao ngay
+	ScheduledFor        *time.Time `json:"scheduled_for"`    // giờ bắt đầu khung giờ giao, nil = giao ngay
+	ThumbnailID         int        `json:"thumbnail_id"`
+	CreatedAt           time.Time  `json:"created_at"`
+	UpdatedAt           time.Time  `json:"updated_at"`
 }
 
 type OrderItem struct {
-	ID        int64   `json:"id"`
-	OrderID   int64   `json:"order_id"`
-	ProductID int64   `json:"product_id"`
-	Quantity  int64   `json:"quantity"`
-	Price     float64 `json:"price"`
+	ID              int64   `json:"id"`
+	OrderID         int64   `json:"order_id"`
+	ProductID       int64   `json:"product_id"`
+	Quantity        int64   `json:"quantity"`
+	Price           float64 `json:"price"`
+	FlashSaleItemID int64   `json:"flash_sale_item_id,omitempty"` // 0 = giá thường
+	Note            string  `json:"note,omitempty"`
+	// option đã chọn, Price đã gồm tiền option
+	Options []OrderItemOption `json:"options,omitempty"`
 }
 
 type CreateOrderRequest struct {
-	Latitude  float64                  `json:"latitude"`
-	Longitude float64                  `json:"longitude"`
-	Products  []CreateOrderItemRequest `json:"products"`
+	Latitude            float64                  `json:"latitude"`
+	Longitude           float64                  `json:"longitude"`
+	AddressID           int64                    `json:"address_id"` // địa chỉ đã lưu, có thì bỏ qua latitude/longitude
+	Products            []CreateOrderItemRequest `json:"products"`
+	CouponCode          string                   `json:"coupon_code"`
+	DeliverySlotID      int64                    `json:"delivery_slot_id"` // 0 = giao ngay
+	DeliveryDate        string                   `json:"delivery_date"`    // YYYY-MM-DD, bắt buộc khi có delivery_slot_id
+	DeliveryInstruction string                   `json:"delivery_instruction"`
 }
 type CreateOrderItemRequest struct {
-	ProductID int64 `json:"product_id"`
-	Quantity  int64 `json:"quantity"`
+	ProductID int64   `json:"product_id"`
+	Quantity  int64   `json:"quantity"`
+	Note      string  `json:"note"`       // ghi chú cho bếp, vd "không hành"
+	OptionIDs []int64 `json:"option_ids"` // option đã chọn (size, topping...)
 }
 type OrderSummaryResponse struct {
 	Order
@@ -65,20 +98,29 @@ type GetOrderDetailResponse struct {
 	OrderItems []OrderItemDetailResp `json:"items"`
 }
 type OrderForShipper struct {
-	OrderID int64
-	Address string
+	OrderID             int64   `json:"order_id"`
+	Address             string  `json:"address"`
+	Label               string  `json:"label"`
+	DriverNote          string  `json:"driver_note"`
+	DeliveryInstruction string  `json:"delivery_instruction"`
+	Latitude            float64 `json:"latitude"`
+	Longitude           float64 `json:"longitude"`
 }
 type GetOrdersForShipperRes struct {
 	Orders []Order
 }
 
 type OrderItemDetailResp struct {
+	OrderItemID  int64   `json:"order_item_id"`
 	ProductID    int64   `json:"product_id"`
 	ProductName  string  `json:"product_name"`
 	ProductImage string  `json:"product_image"`
 	Quantity     int64   `json:"quantity"`
 	Price        float64 `json:"price"`
 	Subtotal     float64 `json:"subtotal"`
+	Note         string  `json:"note"`
+	// option đã chọn, Price đã gồm tiền option
+	Options []OrderItemOption `json:"options"`
 }
 type ReceiveOrderRequest struct {
 	OrderID int64 `json:"order_id"`
@@ -97,7 +139,10 @@ func GetNumberAndRevenueOfOrders(db *sql.DB) (int64, float64, error) {
 	if err != nil {
 		return 0, 0, err
 	}
-	query2 := "select coalesce(sum(total_amount), 0) from orders where payment_status = 'paid'"
+	// doanh thu = tiền đã thu trừ tiền đã hoàn (hoàn giao dịch thu thừa không nằm trong tổng tiền order)
+	query2 := `select
+		(select coalesce(sum(total_amount), 0) from orders where payment_status in ('paid', 'partially_refunded', 'refunded')) -
+		(select coalesce(sum(approved_amount), 0) from refunds where status = 'approved' and payment_id is null)`
 	err = db.QueryRow(query2).Scan(&revenue)
 	if err != nil {
 		return 0, 0, err
@@ -117,71 +162,215 @@ func CheckOrderUser(db *sql.DB, userID, orderID int64) (bool, error) {
 	return true, nil
 }
 func AddNewOrderToOrderTx(tx *sql.Tx, order *Order) (int64, error) {
-	query := "insert into orders (user_id, payment_status, order_status, latitude, longitude, total_amount, thumbnail_id, created_at, updated_at) values (?, ?, ?, ?, ?, ?, ?,?, ?)"
-	result, err := tx.Exec(query, order.UserID, order.PaymentStatus, order.OrderStatus, order.Latitude, order.Longitude, order.TotalAmount, order.ThumbnailID, order.CreatedAt, order.UpdatedAt)
+	query := "insert into orders (user_id, store_id, payment_status, order_status, latitude, longitude, address_id, address_label, delivery_address, driver_note, delivery_instruction, subtotal, delivery_fee, discount, total_amount, delivery_slot_id, scheduled_for, thumbnail_id, created_at, updated_at) values (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?,?, ?)"
+	var storeID sql.NullInt64
+	if order.StoreID != 0 {
+		storeID = sql.NullInt64{Int64: order.StoreID, Valid: true}
+	}
+	var slotID sql.NullInt64
+	if order.DeliverySlotID != 0 {
+		slotID = sql.NullInt64{Int64: order.DeliverySlotID, Valid: true}
+	}
+	var addressID sql.NullInt64
+	if order.AddressID != 0 {
+		addressID = sql.NullInt64{Int64: order.AddressID, Valid: true}
+	}
+	result, err := tx.Exec(query, order.UserID, storeID, order.PaymentStatus, order.OrderStatus, order.Latitude, order.Longitude, addressID, order.AddressLabel, order.DeliveryAddress, order.DriverNote, nullString(order.DeliveryInstruction), order.Subtotal, order.DeliveryFee, order.Discount, order.TotalAmount, slotID, order.ScheduledFor, order.ThumbnailID, order.CreatedAt, order.UpdatedAt)
 	if err != nil {
 		return 0, err
 	}
 	return result.LastInsertId()
 }
 func AddNewOrderItemsTx(tx *sql.Tx, orderItem *OrderItem) error {
-	query := "insert into order_items (order_id, product_id, quantity, price) values (?, ?, ?, ?)"
-	_, err := tx.Exec(query, orderItem.OrderID, orderItem.ProductID, orderItem.Quantity, orderItem.Price)
-	return err
+	query := "insert into order_items (order_id, product_id, quantity, price, flash_sale_item_id, note) values (?, ?, ?, ?, ?, ?)"
+	var flashSaleItemID sql.NullInt64
+	if orderItem.FlashSaleItemID != 0 {
+		flashSaleItemID = sql.NullInt64{Int64: orderItem.FlashSaleItemID, Valid: true}
+	}
+	result, err := tx.Exec(query, orderItem.OrderID, orderItem.ProductID, orderItem.Quantity, orderItem.Price, flashSaleItemID, nullString(orderItem.Note))
+	if err != nil {
+		return err
+	}
+	if orderItem.ID, err = result.LastInsertId(); err != nil {
+		return err
+	}
+	return addOrderItemOptionsTx(tx, orderItem.ID, orderItem.Options)
+}
+
+// OrderFilter là bộ lọc danh sách order của admin, trường rỗng/nil thì không lọc
+type OrderFilter struct {
+	OrderStatus   string
+	PaymentStatus string
+	From          *time.Time // created_at >= From
+	To            *time.Time // created_at < To
+	Customer      string     // một phần tên hoặc số điện thoại của khách
+	ShipperID     int64
+	MinAmount     *float64
+	MaxAmount     *float64
+	Sort          string // một key trong OrderSortKeys, rỗng = id
+	Desc          bool
+	// khác nil thì đơn hẹn giờ chỉ hiện khi scheduled_for <= ReleaseBefore (danh sách việc cần làm),
+	// nil thì lấy cả đơn hẹn giờ chưa tới lúc xử lý (tìm kiếm, export)
+	ReleaseBefore *time.Time
+}
+
+// IsDefault cho biết filter không có điều kiện lọc nào (sắp xếp không tính)
+func (f OrderFilter) IsDefault() bool {
+	return f.OrderStatus == "" && f.PaymentStatus == "" && f.From == nil && f.To == nil && f.Customer == "" &&
+		f.ShipperID == 0 && f.MinAmount == nil && f.MaxAmount == nil
+}
+
+// OrderSortKeys là các cột được phép sắp xếp, tên cột không bao giờ lấy từ request
+var OrderSortKeys = map[string]string{
+	"id":            "o.id",
+	"created_at":    "o.created_at",
+	"updated_at":    "o.updated_at",
+	"total_amount":  "o.total_amount",
+	"scheduled_for": "o.scheduled_for",
+	"customer_name": "u.name",
+}
+
+// thoát ký tự đại diện của LIKE
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
+// where trả điều kiện và tham số tương ứng, chỉ ghép các đoạn SQL cố định
+func (f OrderFilter) where() (string, []interface{}) {
+	conds := []string{}
+	args := []interface{}{}
+	if f.ReleaseBefore != nil {
+		conds = append(conds, "(o.scheduled_for is null or o.scheduled_for <= ?)")
+		args = append(args, *f.ReleaseBefore)
+	}
+	if f.OrderStatus != "" {
+		conds = append(conds, "o.order_status = ?")
+		args = append(args, f.OrderStatus)
+	}
+	if f.PaymentStatus != "" {
+		conds = append(conds, "o.payment_status = ?")
+		args = append(args, f.PaymentStatus)
+	}
+	if f.From != nil {
+		conds = append(conds, "o.created_at >= ?")
+		args = append(args, *f.From)
+	}
+	if f.To != nil {
+		conds = append(conds, "o.created_at < ?")
+		args = append(args, *f.To)
+	}
+	if f.Customer != "" {
+		pattern := "%" + likeEscaper.Replace(f.Customer) + "%"
+		conds = append(conds, "(u.name like ? or u.phone like ?)")
+		args = append(args, pattern, pattern)
+	}
+	if f.ShipperID != 0 {
+		conds = append(conds, "o.shipper_id = ?")
+		args = append(args, f.ShipperID)
+	}
+	if f.MinAmount != nil {
+		conds = append(conds, "o.total_amount >= ?")
+		args = append(args, *f.MinAmount)
+	}
+	if f.MaxAmount != nil {
+		conds = append(conds, "o.total_amount <= ?")
+		args = append(args, *f.MaxAmount)
+	}
+	if len(conds) == 0 {
+		return "", args
+	}
+	return " where " + strings.Join(conds, " and "), args
 }
 
-// func GetAllOrder by admin
-func GetAllOrders(db *sql.DB, page, limit int) ([]OrderSummaryResponse, int, error) {
+func (f OrderFilter) orderBy() string {
+	column, ok := OrderSortKeys[f.Sort]
+	if !ok {
+		column = "o.id"
+	}
+	dir := " asc"
+	if f.Desc {
+		dir = " desc"
+	}
+	return " order by " + column + dir + ", o.id" + dir
+}
+
+// AdminOrderSummary là một dòng trong danh sách order của admin, kèm tên khách và shipper
+type AdminOrderSummary struct {
+	OrderSummaryResponse
+	CustomerName  string `json:"customer_name"`
+	CustomerPhone string `json:"customer_phone"`
+	ShipperName   string `json:"shipper_name"` // rỗng = chưa có shipper
+}
+
+const adminOrderSelect = `SELECT o.id, o.user_id, coalesce(o.shipper_id, 0), coalesce(o.store_id, 0), o.payment_status, o.order_status,
+		       o.latitude, o.longitude, coalesce(o.address_id, 0), o.address_label, o.delivery_address, o.driver_note, o.subtotal, o.delivery_fee, o.discount, o.total_amount,
+		       coalesce(o.delivery_slot_id, 0), o.scheduled_for,
+		       o.thumbnail_id, o.created_at, o.updated_at,
+		       i.url AS thumbnail, u.name, coalesce(u.phone, ''), coalesce(s.name, '')
+		FROM orders o
+		JOIN users u ON o.user_id = u.id
+		LEFT JOIN users s ON o.shipper_id = s.id
+		LEFT JOIN Images i ON o.thumbnail_id = i.id`
+
+func scanAdminOrder(row rowScanner, resp *AdminOrderSummary) error {
+	var thumbnail sql.NullString
+	order := &resp.Order
+	err := row.Scan(
+		&order.ID,
+		&order.UserID,
+		&order.ShipperID,
+		&order.StoreID,
+		&order.PaymentStatus,
+		&order.OrderStatus,
+		&order.Latitude,
+		&order.Longitude,
+		&order.AddressID,
+		&order.AddressLabel,
+		&order.DeliveryAddress,
+		&order.DriverNote,
+		&order.Subtotal,
+		&order.DeliveryFee,
+		&order.Discount,
+		&order.TotalAmount,
+		&order.DeliverySlotID,
+		&order.ScheduledFor,
+		&order.ThumbnailID,
+		&order.CreatedAt,
+		&order.UpdatedAt,
+		&thumbnail,
+		&resp.CustomerName,
+		&resp.CustomerPhone,
+		&resp.ShipperName,
+	)
+	if err != nil {
+		return err
+	}
+	if thumbnail.Valid {
+		resp.Thumbnail = thumbnail.String
+	}
+	return nil
+}
+
+// func GetAllOrder by admin, lọc và sắp xếp theo filter
+func GetAllOrders(db *sql.DB, filter OrderFilter, page, limit int) ([]AdminOrderSummary, int, error) {
 	offset := (page - 1) * limit
+	where, args := filter.where()
 	var total int
-	err := db.QueryRow("select count(*) from orders").Scan(&total)
+	err := db.QueryRow("select count(*) from orders o join users u on o.user_id = u.id"+where, args...).Scan(&total)
 	if err != nil {
 		return nil, 0, err
 	}
-	query := `SELECT o.id, o.user_id, o.payment_status, o.order_status,
-		       o.latitude, o.longitude, o.total_amount, 
-		       o.thumbnail_id, o.created_at, o.updated_at,
-		       i.url AS thumbnail
-		FROM orders o
-		LEFT JOIN Images i ON o.thumbnail_id = i.id
-		ORDER BY o.id DESC limit ? offset ?`
-
-	rows, err := db.Query(query, limit, offset)
+	query := adminOrderSelect + where + filter.orderBy() + " limit ? offset ?"
+	rows, err := db.Query(query, append(args, limit, offset)...)
 	if err != nil {
 		return nil, 0, err
 	}
 	defer rows.Close()
 
-	var orders []OrderSummaryResponse
+	orders := []AdminOrderSummary{}
 	for rows.Next() {
-		var order Order
-		var thumbnail sql.NullString
-
-		err := rows.Scan(
-			&order.ID,
-			&order.UserID,
-			&order.PaymentStatus,
-			&order.OrderStatus,
-			&order.Latitude,
-			&order.Longitude,
-			&order.TotalAmount,
-			&order.ThumbnailID,
-			&order.CreatedAt,
-			&order.UpdatedAt,
-			&thumbnail,
-		)
-		if err != nil {
+		var resp AdminOrderSummary
+		if err := scanAdminOrder(rows, &resp); err != nil {
 			return nil, 0, err
 		}
-
-		resp := OrderSummaryResponse{
-			Order:     order,
-			Thumbnail: "",
-		}
-		if thumbnail.Valid {
-			resp.Thumbnail = thumbnail.String
-		}
-
 		orders = append(orders, resp)
 	}
 
@@ -192,24 +381,29 @@ func GetAllOrders(db *sql.DB, page, limit int) ([]OrderSummaryResponse, int, err
 	return orders, total, nil
 }
 
-// get all order by shipper
-func GetOrdersByShipper(db *sql.DB, page, limit int) ([]OrderSummaryResponse, int, error) {
+// get all order by shipper, storeID = 0 thì lấy đơn của mọi store,
+// đơn hẹn giờ chỉ hiện khi scheduled_for <= releaseBefore
+func GetOrdersByShipper(db *sql.DB, storeID int64, releaseBefore time.Time, page, limit int) ([]OrderSummaryResponse, int, error) {
 	offset := (page - 1) * limit
 	var total int
-	err := db.QueryRow("select count(*) from orders").Scan(&total)
+	countQuery := `select count(*) from orders
+		where order_status = "processing" and (? = 0 or store_id = ?) and (scheduled_for is null or scheduled_for <= ?)`
+	err := db.QueryRow(countQuery, storeID, storeID, releaseBefore).Scan(&total)
 	if err != nil {
 		return nil, 0, err
 	}
-	query := `SELECT o.id, o.payment_status, o.order_status, 
-		       o.latitude, o.longitude, o.total_amount, 
+	query := `SELECT o.id, coalesce(o.store_id, 0), o.payment_status, o.order_status, 
+		       o.latitude, o.longitude, coalesce(o.address_id, 0), o.address_label, o.delivery_address, o.driver_note, o.subtotal, o.delivery_fee, o.discount, o.total_amount,
+		       coalesce(o.delivery_slot_id, 0), o.scheduled_for,
 		       o.thumbnail_id, o.created_at, o.updated_at,
 		       i.url AS thumbnail
 		FROM orders o
 		LEFT JOIN Images i ON o.thumbnail_id = i.id
-		where o.order_status = "processing"
+		where o.order_status = "processing" and (? = 0 or o.store_id = ?)
+		  and (o.scheduled_for is null or o.scheduled_for <= ?)
 		ORDER BY o.id DESC limit ? offset ?`
 
-	rows, err := db.Query(query, limit, offset)
+	rows, err := db.Query(query, storeID, storeID, releaseBefore, limit, offset)
 	if err != nil {
 		return nil, 0, err
 	}
@@ -222,11 +416,21 @@ func GetOrdersByShipper(db *sql.DB, page, limit int) ([]OrderSummaryResponse, in
 
 		err := rows.Scan(
 			&order.ID,
+			&order.StoreID,
 			&order.PaymentStatus,
 			&order.OrderStatus,
 			&order.Latitude,
 			&order.Longitude,
+			&order.AddressID,
+			&order.AddressLabel,
+			&order.DeliveryAddress,
+			&order.DriverNote,
+			&order.Subtotal,
+			&order.DeliveryFee,
+			&order.Discount,
 			&order.TotalAmount,
+			&order.DeliverySlotID,
+			&order.ScheduledFor,
 			&order.ThumbnailID,
 			&order.CreatedAt,
 			&order.UpdatedAt,
@@ -260,8 +464,9 @@ func GetReceivedOrdersByShipper(db *sql.DB, shipperID int64, page, limit int) ([
 	if err != nil {
 		return nil, 0, err
 	}
-	query := `SELECT o.id, o.user_id, o.shipper_id, o.payment_status, o.order_status, 
-		       o.latitude, o.longitude, o.total_amount, 
+	query := `SELECT o.id, o.user_id, o.shipper_id, coalesce(o.store_id, 0), o.payment_status, o.order_status, 
+		       o.latitude, o.longitude, coalesce(o.address_id, 0), o.address_label, o.delivery_address, o.driver_note, o.subtotal, o.delivery_fee, o.discount, o.total_amount,
+		       coalesce(o.delivery_slot_id, 0), o.scheduled_for,
 		       o.thumbnail_id, o.created_at, o.updated_at,
 		       i.url AS thumbnail
 		FROM orders o
@@ -284,11 +489,21 @@ func GetReceivedOrdersByShipper(db *sql.DB, shipperID int64, page, limit int) ([
 			&order.ID,
 			&order.UserID,
 			&order.ShipperID,
+			&order.StoreID,
 			&order.PaymentStatus,
 			&order.OrderStatus,
 			&order.Latitude,
 			&order.Longitude,
+			&order.AddressID,
+			&order.AddressLabel,
+			&order.DeliveryAddress,
+			&order.DriverNote,
+			&order.Subtotal,
+			&order.DeliveryFee,
+			&order.Discount,
 			&order.TotalAmount,
+			&order.DeliverySlotID,
+			&order.ScheduledFor,
 			&order.ThumbnailID,
 			&order.CreatedAt,
 			&order.UpdatedAt,
@@ -318,8 +533,9 @@ func GetReceivedOrdersByShipper(db *sql.DB, shipperID int64, page, limit int) ([
 
 func GetOrderByID(db *sql.DB, orderID int64) (*Order, error) {
 	query := `
-		SELECT id, user_id , payment_status, order_status,
-		       latitude, longitude, total_amount, thumbnail_id, created_at, updated_at
+		SELECT id, user_id, coalesce(store_id, 0), payment_status, order_status,
+		       latitude, longitude, coalesce(address_id, 0), address_label, delivery_address, driver_note, subtotal, delivery_fee, discount, total_amount, coalesce(delivery_slot_id, 0), scheduled_for,
+		       thumbnail_id, created_at, updated_at
 		FROM orders
 		WHERE id = ?
 	`
@@ -330,11 +546,21 @@ func GetOrderByID(db *sql.DB, orderID int64) (*Order, error) {
 	err := row.Scan(
 		&o.ID,
 		&o.UserID,
+		&o.StoreID,
 		&o.PaymentStatus,
 		&o.OrderStatus,
 		&o.Latitude,
 		&o.Longitude,
+		&o.AddressID,
+		&o.AddressLabel,
+		&o.DeliveryAddress,
+		&o.DriverNote,
+		&o.Subtotal,
+		&o.DeliveryFee,
+		&o.Discount,
 		&o.TotalAmount,
+		&o.DeliverySlotID,
+		&o.ScheduledFor,
 		&o.ThumbnailID,
 		&o.CreatedAt,
 		&o.UpdatedAt,
@@ -354,8 +580,9 @@ func GetOrderByID(db *sql.DB, orderID int64) (*Order, error) {
 
 func GetOrdersByUserID(db *sql.DB, userID int64) ([]OrderSummaryResponse, error) {
 	query := `
-		SELECT o.id, o.user_id, o.payment_status, o.order_status,
-		       o.latitude, o.longitude, o.total_amount, 
+		SELECT o.id, o.user_id, coalesce(o.store_id, 0), o.payment_status, o.order_status,
+		       o.latitude, o.longitude, coalesce(o.address_id, 0), o.address_label, o.delivery_address, o.driver_note, o.subtotal, o.delivery_fee, o.discount, o.total_amount,
+		       coalesce(o.delivery_slot_id, 0), o.scheduled_for,
 		       o.thumbnail_id, o.created_at, o.updated_at,
 		       i.url AS thumbnail
 		FROM orders o
@@ -378,11 +605,21 @@ func GetOrdersByUserID(db *sql.DB, userID int64) ([]OrderSummaryResponse, error)
 		err := rows.Scan(
 			&order.ID,
 			&order.UserID,
+			&order.StoreID,
 			&order.PaymentStatus,
 			&order.OrderStatus,
 			&order.Latitude,
 			&order.Longitude,
+			&order.AddressID,
+			&order.AddressLabel,
+			&order.DeliveryAddress,
+			&order.DriverNote,
+			&order.Subtotal,
+			&order.DeliveryFee,
+			&order.Discount,
 			&order.TotalAmount,
+			&order.DeliverySlotID,
+			&order.ScheduledFor,
 			&order.ThumbnailID,
 			&order.CreatedAt,
 			&order.UpdatedAt,
@@ -409,34 +646,54 @@ func GetOrdersByUserID(db *sql.DB, userID int64) ([]OrderSummaryResponse, error)
 
 	return orders, nil
 }
-func GetDetailOrder(db *sql.DB, orderID int64, userID int64, role string) (*GetOrderDetailResponse, error) {
-	var order OrderResponse
+
+// CheckOrderAccess: admin xem được mọi order, customer và shipper chỉ xem order của mình
+func CheckOrderAccess(db *sql.DB, orderID int64, userID int64, role string) error {
 	if role == "customer" || role == "shipper" {
 		var exists bool
 		err := db.QueryRow(
 			"select exists (select 1 from orders where (id = ? and (user_id = ? or shipper_id = ?)))", orderID, userID, userID,
 		).Scan(&exists)
 		if err != nil {
-			return nil, err
+			return err
 		}
 		if !exists {
 			// Không có order thuộc về user hoặc shipper này
-			return nil, errors.New("unauthorized: you can't access this order")
+			return ErrOrderAccessDenied
 		}
 	}
+	return nil
+}
 
-	orderQuery := `select o.id, o.user_id, u.name, u.phone, payment_status, order_status, latitude, longitude, total_amount, thumbnail_id, o.created_at, o.updated_at from orders o join users u on o.user_id = u.id  where o.id = ? `
+func GetDetailOrder(db *sql.DB, orderID int64, userID int64, role string) (*GetOrderDetailResponse, error) {
+	var order OrderResponse
+	if err := CheckOrderAccess(db, orderID, userID, role); err != nil {
+		return nil, err
+	}
+
+	orderQuery := `select o.id, o.user_id, u.name, u.phone, coalesce(o.store_id, 0), payment_status, order_status, latitude, longitude, coalesce(address_id, 0), address_label, delivery_address, driver_note, coalesce(delivery_instruction, ''), subtotal, delivery_fee, discount, total_amount, coalesce(delivery_slot_id, 0), scheduled_for, thumbnail_id, o.created_at, o.updated_at from orders o join users u on o.user_id = u.id  where o.id = ? `
 
 	err := db.QueryRow(orderQuery, orderID).Scan(
 		&order.ID,
 		&order.UserID,
 		&order.UserName,
 		&order.Phone,
+		&order.StoreID,
 		&order.PaymentStatus,
 		&order.OrderStatus,
 		&order.Latitude,
 		&order.Longitude,
+		&order.AddressID,
+		&order.AddressLabel,
+		&order.DeliveryAddress,
+		&order.DriverNote,
+		&order.DeliveryInstruction,
+		&order.Subtotal,
+		&order.DeliveryFee,
+		&order.Discount,
 		&order.TotalAmount,
+		&order.DeliverySlotID,
+		&order.ScheduledFor,
 		&order.ThumbnailID,
 		&order.CreatedAt,
 		&order.UpdatedAt,
@@ -448,10 +705,12 @@ func GetDetailOrder(db *sql.DB, orderID int64, userID int64, role string) (*GetO
 	// --- Lấy chi tiết từng order_item ---
 	itemQuery := `
 		SELECT 
+			o.id,
 			o.product_id, 
 			p.name, 
 			o.quantity, 
 			o.price,
+			coalesce(o.note, ''),
 			(SELECT url 
 			 FROM Images i 
 			 JOIN ProductImages pi ON i.id = pi.image_id 
@@ -471,10 +730,12 @@ func GetDetailOrder(db *sql.DB, orderID int64, userID int64, role string) (*GetO
 	for rows.Next() {
 		var item OrderItemDetailResp
 		err := rows.Scan(
+			&item.OrderItemID,
 			&item.ProductID,
 			&item.ProductName,
 			&item.Quantity,
 			&item.Price,
+			&item.Note,
 			&item.ProductImage,
 		)
 		if err != nil {
@@ -483,6 +744,20 @@ func GetDetailOrder(db *sql.DB, orderID int64, userID int64, role string) (*GetO
 		item.Subtotal = float64(item.Quantity) * item.Price
 		items = append(items, item)
 	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	options, err := getOrderItemOptions(db, orderID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range items {
+		items[i].Options = options[items[i].OrderItemID]
+		if items[i].Options == nil {
+			items[i].Options = []OrderItemOption{}
+		}
+	}
 
 	resp := &GetOrderDetailResponse{
 		Order:      order,
@@ -512,33 +787,28 @@ func CheckShipperOrder(db *sql.DB, shipperID int64, orderID int64) (bool, error)
 	return true, nil
 }
 
-func UpdateStatusOrder(db *sql.DB, orderID int64, paymentStatus *string, orderStatus *string) error {
-	query := "update orders set "
-	args := []interface{}{}
-
-	if paymentStatus != nil {
-		query += "payment_status = ?"
-		args = append(args, *paymentStatus)
-	}
-	if orderStatus != nil {
-		if len(args) > 0 {
-			query += ", "
-		}
-		query += "order_status = ?"
-		args = append(args, *orderStatus)
+func UpdateStatusOrder(db *sql.DB, orderID int64, actor Actor, paymentStatus *string, orderStatus *string) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
 	}
+	defer tx.Rollback()
 
-	query += " where id = ?"
-	args = append(args, orderID)
-
-	_, err := db.Exec(query, args...)
-	return err
+	if err := UpdateStatusOrderTx(tx, orderID, actor, paymentStatus, orderStatus); err != nil {
+		return err
+	}
+	return tx.Commit()
 }
 
-func UpdateShipperForOrder(db *sql.DB, orderID int64, shipperID int64) error {
-	query := "update orders set shipper_id = ?, order_status = 'shipping' where id = ? and order_status= 'processing' "
-	_, err := db.Exec(query, shipperID, orderID)
-	return err
+// GetOrderForShipper lấy địa chỉ giao của order (bản sao lúc đặt hàng)
+func GetOrderForShipper(db *sql.DB, orderID int64) (*OrderForShipper, error) {
+	var o OrderForShipper
+	query := "select id, delivery_address, address_label, driver_note, coalesce(delivery_instruction, ''), latitude, longitude from orders where id = ?"
+	err := db.QueryRow(query, orderID).Scan(&o.OrderID, &o.Address, &o.Label, &o.DriverNote, &o.DeliveryInstruction, &o.Latitude, &o.Longitude)
+	if err != nil {
+		return nil, err
+	}
+	return &o, nil
 }
 
 // func check current numbers of orders of shipper
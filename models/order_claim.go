@@ -0,0 +1,103 @@
+package models
+
+import (
+	"database/sql"
+	"time"
+)
+
+// ClaimResult là kết quả khi shipper nhận một order
+type ClaimResult int
+
+const (
+	ClaimOK              ClaimResult = iota // shipper nhận đơn thành công
+	ClaimAlreadyTaken                       // đơn đã được shipper khác nhận
+	ClaimNotClaimable                       // đơn không ở trạng thái chờ giao (processing), thuộc store khác hoặc chưa tới giờ giao
+	ClaimCapacityReached                    // shipper đã đủ số đơn đang giao
+)
+
+func (r ClaimResult) String() string {
+	switch r {
+	case ClaimOK:
+		return "claimed"
+	case ClaimAlreadyTaken:
+		return "already_taken"
+	case ClaimNotClaimable:
+		return "not_claimable"
+	case ClaimCapacityReached:
+		return "capacity_reached"
+	}
+	return "unknown"
+}
+
+// ClaimOrder cho shipper nhận order một cách nguyên tử.
+// Khoá dòng users của shipper để các lần nhận đơn của cùng shipper chạy tuần tự
+// (không vượt maxOrders), rồi khoá dòng order để chỉ một shipper nhận được đơn.
+// Đơn hẹn giờ có scheduled_for sau releaseBefore chưa được nhận.
+func ClaimOrder(db *sql.DB, orderID int64, shipperID int64, maxOrders int, releaseBefore time.Time) (ClaimResult, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	var shipperStoreID int64
+	err = tx.QueryRow("select coalesce(store_id, 0) from users where id = ? for update", shipperID).Scan(&shipperStoreID)
+	if err != nil {
+		return 0, err
+	}
+
+	st, err := lockOrderTx(tx, orderID)
+	if err != nil {
+		return 0, err
+	}
+	if st.ShipperID.Valid {
+		if st.ShipperID.Int64 == shipperID && st.OrderStatus == OrderStatusShipping {
+			// shipper gửi lại yêu cầu cho đơn mình đã nhận
+			return ClaimOK, nil
+		}
+		return ClaimAlreadyTaken, nil
+	}
+	if !CanTransitionOrder(RoleShipper, st.OrderStatus, OrderStatusShipping) {
+		return ClaimNotClaimable, nil
+	}
+	// shipper thuộc store nào chỉ nhận đơn của store đó
+	if shipperStoreID != 0 && st.StoreID != 0 && shipperStoreID != st.StoreID {
+		return ClaimNotClaimable, nil
+	}
+	if st.ScheduledFor != nil && st.ScheduledFor.After(releaseBefore) {
+		return ClaimNotClaimable, nil
+	}
+
+	var active int
+	query := "select count(*) from orders where shipper_id = ? and order_status = ?"
+	if err := tx.QueryRow(query, shipperID, OrderStatusShipping).Scan(&active); err != nil {
+		return 0, err
+	}
+	if active >= maxOrders {
+		return ClaimCapacityReached, nil
+	}
+
+	result, err := tx.Exec(
+		"update orders set shipper_id = ?, order_status = ? where id = ? and order_status = ? and shipper_id is null",
+		shipperID, OrderStatusShipping, orderID, OrderStatusProcessing,
+	)
+	if err != nil {
+		return 0, err
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+	if n == 0 {
+		return ClaimAlreadyTaken, nil
+	}
+
+	actor := Actor{ID: shipperID, Role: RoleShipper}
+	if err := AddOrderStatusEventTx(tx, orderID, "order_status", st.OrderStatus, OrderStatusShipping, actor, ""); err != nil {
+		return 0, err
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	return ClaimOK, nil
+}
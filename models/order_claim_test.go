@@ -0,0 +1,170 @@
+package models
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	_ "github.com/go-sql-driver/mysql"
+)
+
+// Test chạy trên MySQL thật đã nạp database/schema.sql, ví dụ:
+// TEST_DB_DSN="root:pass@tcp(127.0.0.1:3306)/DeliveryAppTest?parseTime=true" go test ./models
+func openTestDB(t *testing.T) *sql.DB {
+	t.Helper()
+	dsn := os.Getenv("TEST_DB_DSN")
+	if dsn == "" {
+		t.Skip("TEST_DB_DSN not set, skipping MySQL integration test")
+	}
+	db, err := sql.Open("mysql", dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Ping(); err != nil {
+		t.Fatal(err)
+	}
+	db.SetMaxOpenConns(50)
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func createTestUser(t *testing.T, db *sql.DB, role string) int64 {
+	t.Helper()
+	email := fmt.Sprintf("claim-test-%s-%d@example.com", role, time.Now().UnixNano())
+	result, err := db.Exec("insert into users (name, email, password, role, status) values (?, ?, ?, ?, 1)", "claim test", email, "x", role)
+	if err != nil {
+		t.Fatal(err)
+	}
+	id, _ := result.LastInsertId()
+	t.Cleanup(func() { db.Exec("delete from users where id = ?", id) })
+	return id
+}
+
+func createTestOrder(t *testing.T, db *sql.DB, userID int64, status string) int64 {
+	t.Helper()
+	result, err := db.Exec("insert into orders (user_id, payment_status, order_status, latitude, longitude, total_amount) values (?, 'unpaid', ?, 21.0285, 105.8048, 10000)", userID, status)
+	if err != nil {
+		t.Fatal(err)
+	}
+	id, _ := result.LastInsertId()
+	t.Cleanup(func() { db.Exec("delete from orders where id = ?", id) })
+	return id
+}
+
+func countResults(results []ClaimResult) map[ClaimResult]int {
+	counts := make(map[ClaimResult]int)
+	for _, r := range results {
+		counts[r]++
+	}
+	return counts
+}
+
+func TestClaimOrderParallelShippersOneWinner(t *testing.T) {
+	db := openTestDB(t)
+	customerID := createTestUser(t, db, RoleCustomer)
+	orderID := createTestOrder(t, db, customerID, OrderStatusProcessing)
+
+	const n = 10
+	shippers := make([]int64, n)
+	for i := range shippers {
+		shippers[i] = createTestUser(t, db, RoleShipper)
+	}
+
+	results := make([]ClaimResult, n)
+	errs := make([]error, n)
+	var wg sync.WaitGroup
+	start := make(chan struct{})
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			<-start
+			results[i], errs[i] = ClaimOrder(db, orderID, shippers[i], 10, time.Now())
+		}(i)
+	}
+	close(start)
+	wg.Wait()
+
+	for i, err := range errs {
+		if err != nil {
+			t.Fatalf("shipper %d: %v", shippers[i], err)
+		}
+	}
+	counts := countResults(results)
+	if counts[ClaimOK] != 1 || counts[ClaimAlreadyTaken] != n-1 {
+		t.Fatalf("want 1 claimed and %d already taken, got %v", n-1, counts)
+	}
+
+	var winner int64
+	var status string
+	if err := db.QueryRow("select shipper_id, order_status from orders where id = ?", orderID).Scan(&winner, &status); err != nil {
+		t.Fatal(err)
+	}
+	if status != OrderStatusShipping || shippers[indexOf(results, ClaimOK)] != winner {
+		t.Fatalf("order assigned to %d with status %s", winner, status)
+	}
+}
+
+func TestClaimOrderParallelRespectsCapacity(t *testing.T) {
+	db := openTestDB(t)
+	customerID := createTestUser(t, db, RoleCustomer)
+	shipperID := createTestUser(t, db, RoleShipper)
+
+	const n, maxOrders = 6, 2
+	orders := make([]int64, n)
+	for i := range orders {
+		orders[i] = createTestOrder(t, db, customerID, OrderStatusProcessing)
+	}
+
+	results := make([]ClaimResult, n)
+	errs := make([]error, n)
+	var wg sync.WaitGroup
+	start := make(chan struct{})
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			<-start
+			results[i], errs[i] = ClaimOrder(db, orders[i], shipperID, maxOrders, time.Now())
+		}(i)
+	}
+	close(start)
+	wg.Wait()
+
+	for i, err := range errs {
+		if err != nil {
+			t.Fatalf("order %d: %v", orders[i], err)
+		}
+	}
+	counts := countResults(results)
+	if counts[ClaimOK] != maxOrders || counts[ClaimCapacityReached] != n-maxOrders {
+		t.Fatalf("want %d claimed and %d capacity reached, got %v", maxOrders, n-maxOrders, counts)
+	}
+}
+
+func TestClaimOrderNotClaimable(t *testing.T) {
+	db := openTestDB(t)
+	customerID := createTestUser(t, db, RoleCustomer)
+	shipperID := createTestUser(t, db, RoleShipper)
+	orderID := createTestOrder(t, db, customerID, OrderStatusPending)
+
+	result, err := ClaimOrder(db, orderID, shipperID, 10, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result != ClaimNotClaimable {
+		t.Fatalf("want %s, got %s", ClaimNotClaimable, result)
+	}
+}
+
+func indexOf(results []ClaimResult, want ClaimResult) int {
+	for i, r := range results {
+		if r == want {
+			return i
+		}
+	}
+	return -1
+}
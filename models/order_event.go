@@ -0,0 +1,88 @@
+package models
+
+import (
+	"database/sql"
+	"time"
+)
+
+// OrderStatusEvent là một lần thay đổi order_status hoặc payment_status của order
+type OrderStatusEvent struct {
+	ID         int64     `json:"id"`
+	OrderID    int64     `json:"order_id"`
+	Field      string    `json:"field"` // order_status || payment_status
+	FromStatus string    `json:"from_status"`
+	ToStatus   string    `json:"to_status"`
+	ActorID    int64     `json:"actor_id"`
+	ActorName  string    `json:"actor_name"`
+	ActorRole  string    `json:"actor_role"`
+	Note       string    `json:"note"`
+	CreatedAt  time.Time `json:"created_at"`
+	// số giây order nằm ở trạng thái trước đó (cùng field)
+	DurationSeconds int64 `json:"duration_seconds"`
+}
+
+// AddOrderStatusEventTx ghi lại một lần đổi trạng thái, phải gọi trong cùng tx với lệnh update
+func AddOrderStatusEventTx(tx *sql.Tx, orderID int64, field, from, to string, actor Actor, note string) error {
+	query := `insert into order_status_events (order_id, field, from_status, to_status, actor_id, actor_role, note)
+		values (?, ?, ?, ?, ?, ?, ?)`
+	var fromStatus, noteVal sql.NullString
+	if from != "" {
+		fromStatus = sql.NullString{String: from, Valid: true}
+	}
+	if note != "" {
+		noteVal = sql.NullString{String: note, Valid: true}
+	}
+	var actorID sql.NullInt64
+	if actor.ID != 0 {
+		actorID = sql.NullInt64{Int64: actor.ID, Valid: true}
+	}
+	_, err := tx.Exec(query, orderID, field, fromStatus, to, actorID, actor.Role, noteVal)
+	return err
+}
+
+// GetOrderTimeline lấy lịch sử trạng thái của order theo thứ tự thời gian
+func GetOrderTimeline(db *sql.DB, orderID int64) ([]OrderStatusEvent, error) {
+	query := `
+		SELECT e.id, e.order_id, e.field, e.from_status, e.to_status,
+		       e.actor_id, u.name, e.actor_role, e.note, e.created_at
+		FROM order_status_events e
+		LEFT JOIN users u ON e.actor_id = u.id
+		WHERE e.order_id = ?
+		ORDER BY e.created_at, e.id`
+
+	rows, err := db.Query(query, orderID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	events := []OrderStatusEvent{}
+	lastChange := make(map[string]time.Time)
+	for rows.Next() {
+		var (
+			e         OrderStatusEvent
+			from      sql.NullString
+			actorID   sql.NullInt64
+			actorName sql.NullString
+			note      sql.NullString
+		)
+		err := rows.Scan(&e.ID, &e.OrderID, &e.Field, &from, &e.ToStatus,
+			&actorID, &actorName, &e.ActorRole, &note, &e.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		e.FromStatus = from.String
+		e.ActorID = actorID.Int64
+		e.ActorName = actorName.String
+		e.Note = note.String
+		if prev, ok := lastChange[e.Field]; ok {
+			e.DurationSeconds = int64(e.CreatedAt.Sub(prev).Seconds())
+		}
+		lastChange[e.Field] = e.CreatedAt
+		events = append(events, e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return events, nil
+}
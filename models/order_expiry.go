@@ -0,0 +1,78 @@
+package models
+
+import (
+	"database/sql"
+	"time"
+)
+
+// điều kiện một order pending bị coi là quá hạn:
+// chưa thanh toán, tạo trước createdBefore, đơn hẹn giờ thì đã hiện cho admin từ trước cutoff
+// (scheduled_for < releasedBefore) và không có giao dịch online nào đang chờ từ sau cutoff
+const stalePendingCond = `o.order_status = 'pending' and o.payment_status = 'unpaid'
+	and o.created_at < ?
+	and (o.scheduled_for is null or o.scheduled_for < ?)
+	and not exists (select 1 from payments p where p.order_id = o.id and p.status = 'pending' and p.created_at >= ?)`
+
+// ExpiredOrder là order vừa bị huỷ tự động
+type ExpiredOrder struct {
+	ID     int64
+	UserID int64
+}
+
+// GetStalePendingOrderIDs lấy tối đa limit order pending quá hạn, cũ nhất trước.
+// Chỉ để chọn ứng viên, ExpirePendingOrder sẽ kiểm tra lại khi đã khoá order.
+func GetStalePendingOrderIDs(db *sql.DB, createdBefore, releasedBefore time.Time, limit int) ([]int64, error) {
+	query := "select o.id from orders o where " + stalePendingCond + " order by o.created_at, o.id limit ?"
+	rows, err := db.Query(query, createdBefore, releasedBefore, createdBefore, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ids := []int64{}
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// ExpirePendingOrder huỷ order nếu vẫn còn quá hạn, qua state machine với actor system
+// (trả hàng, flash sale, coupon và ghi lý do vào lịch sử trạng thái).
+// Order đã bị instance khác (hoặc admin, khách) xử lý thì trả nil, nil.
+func ExpirePendingOrder(db *sql.DB, orderID int64, createdBefore, releasedBefore time.Time, reason string) (*ExpiredOrder, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	st, err := lockOrderTx(tx, orderID)
+	if err == ErrOrderNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	// kiểm tra lại điều kiện sau khi đã giữ khoá
+	var stale bool
+	query := "select exists (select 1 from orders o where o.id = ? and " + stalePendingCond + ")"
+	if err := tx.QueryRow(query, orderID, createdBefore, releasedBefore, createdBefore).Scan(&stale); err != nil {
+		return nil, err
+	}
+	if !stale {
+		return nil, nil
+	}
+
+	cancelled := OrderStatusCancelled
+	if err := updateStatusOrderTx(tx, orderID, Actor{Role: RoleSystem}, nil, &cancelled, reason); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return &ExpiredOrder{ID: st.ID, UserID: st.UserID}, nil
+}
@@ -0,0 +1,36 @@
+package models
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestOrderFilterScheduledHoldBack(t *testing.T) {
+	now := time.Date(2026, 10, 17, 10, 0, 0, 0, time.UTC)
+
+	where, args := OrderFilter{}.where()
+	if where != "" || len(args) != 0 {
+		t.Fatalf("empty filter: where = %q, args = %v", where, args)
+	}
+
+	where, args = OrderFilter{Customer: "an"}.where()
+	if strings.Contains(where, "scheduled_for") {
+		t.Errorf("explicit filter should include future scheduled orders: %q", where)
+	}
+	if len(args) != 2 {
+		t.Errorf("customer filter args = %v", args)
+	}
+
+	where, args = OrderFilter{ReleaseBefore: &now}.where()
+	if !strings.Contains(where, "o.scheduled_for <= ?") || len(args) != 1 {
+		t.Errorf("work queue filter: where = %q, args = %v", where, args)
+	}
+
+	if !(OrderFilter{Sort: "total_amount", Desc: true}).IsDefault() {
+		t.Error("sorting alone should keep the default list")
+	}
+	if (OrderFilter{OrderStatus: OrderStatusPending}).IsDefault() {
+		t.Error("status filter is not the default list")
+	}
+}
@@ -0,0 +1,232 @@
+package models
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// trạng thái đơn hàng (khớp với enum trong bảng orders)
+const (
+	OrderStatusPending    = "pending"
+	OrderStatusProcessing = "processing"
+	OrderStatusShipping   = "shipping"
+	OrderStatusDelivered  = "delivered"
+	OrderStatusCancelled  = "cancelled"
+)
+
+// trạng thái thanh toán
+const (
+	PaymentStatusUnpaid            = "unpaid"
+	PaymentStatusPaid              = "paid"
+	PaymentStatusPartiallyRefunded = "partially_refunded"
+	PaymentStatusRefunded          = "refunded"
+)
+
+// role của người thực hiện thay đổi
+const (
+	RoleAdmin    = "admin"
+	RoleShipper  = "shipper"
+	RoleCustomer = "customer"
+	RoleSystem   = "system" // thay đổi tự động (webhook thanh toán, job nền)
+)
+
+var (
+	ErrOrderNotFound     = errors.New("order not found")
+	ErrOrderAccessDenied = errors.New("you can't access this order")
+)
+
+// Actor là người thực hiện thay đổi trạng thái order
+type Actor struct {
+	ID   int64
+	Role string
+}
+
+// TransitionError được trả về khi một role không được phép chuyển trạng thái
+type TransitionError struct {
+	OrderID int64
+	Field   string // order_status || payment_status
+	From    string
+	To      string
+	Role    string
+}
+
+func (e *TransitionError) Error() string {
+	return fmt.Sprintf("order %d: %s can't change %s from %q to %q", e.OrderID, e.Role, e.Field, e.From, e.To)
+}
+
+type transition struct {
+	from string
+	to   string
+}
+
+// các bước chuyển hợp lệ của order_status và role được phép thực hiện
+var orderTransitions = map[transition][]string{
+	{OrderStatusPending, OrderStatusProcessing}:   {RoleAdmin},
+	{OrderStatusPending, OrderStatusCancelled}:    {RoleAdmin, RoleCustomer, RoleSystem},
+	{OrderStatusProcessing, OrderStatusShipping}:  {RoleShipper},
+	{OrderStatusProcessing, OrderStatusCancelled}: {RoleAdmin},
+	{OrderStatusShipping, OrderStatusDelivered}:   {RoleShipper},
+}
+
+// các bước chuyển hợp lệ của payment_status,
+// partially_refunded/refunded chỉ được đặt khi duyệt refund (xem ApproveRefund)
+var paymentTransitions = map[transition][]string{
+	{PaymentStatusUnpaid, PaymentStatusPaid}: {RoleAdmin, RoleShipper, RoleSystem},
+}
+
+func allowed(table map[transition][]string, role, from, to string) bool {
+	for _, r := range table[transition{from, to}] {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
+// CanTransitionOrder kiểm tra role có được chuyển order_status từ from sang to không
+func CanTransitionOrder(role, from, to string) bool {
+	return allowed(orderTransitions, role, from, to)
+}
+
+// CanTransitionPayment kiểm tra role có được chuyển payment_status từ from sang to không
+func CanTransitionPayment(role, from, to string) bool {
+	return allowed(paymentTransitions, role, from, to)
+}
+
+// orderState là trạng thái hiện tại của order, được đọc kèm khoá FOR UPDATE
+type orderState struct {
+	ID            int64
+	UserID        int64
+	ShipperID     sql.NullInt64
+	StoreID       int64
+	PaymentStatus string
+	OrderStatus   string
+	ScheduledFor  *time.Time
+	TotalAmount   float64
+}
+
+func lockOrderTx(tx *sql.Tx, orderID int64) (*orderState, error) {
+	query := "select id, user_id, shipper_id, coalesce(store_id, 0), payment_status, order_status, scheduled_for, total_amount from orders where id = ? for update"
+	var st orderState
+	err := tx.QueryRow(query, orderID).Scan(&st.ID, &st.UserID, &st.ShipperID, &st.StoreID, &st.PaymentStatus, &st.OrderStatus, &st.ScheduledFor, &st.TotalAmount)
+	if err == sql.ErrNoRows {
+		return nil, ErrOrderNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &st, nil
+}
+
+// checkOrderActor kiểm tra actor có quyền trên order: customer phải là chủ order,
+// shipper phải là người đang giao order (trừ lúc nhận đơn)
+func checkOrderActor(st *orderState, actor Actor) error {
+	switch actor.Role {
+	case RoleCustomer:
+		if st.UserID != actor.ID {
+			return ErrOrderAccessDenied
+		}
+	case RoleShipper:
+		if !st.ShipperID.Valid || st.ShipperID.Int64 != actor.ID {
+			return ErrOrderAccessDenied
+		}
+	}
+	return nil
+}
+
+// UpdateStatusOrderTx đổi payment_status và/hoặc order_status của order trong tx,
+// chỉ cho phép các bước chuyển hợp lệ với role của actor
+func UpdateStatusOrderTx(tx *sql.Tx, orderID int64, actor Actor, paymentStatus *string, orderStatus *string) error {
+	return updateStatusOrderTx(tx, orderID, actor, paymentStatus, orderStatus, "")
+}
+
+// updateStatusOrderTx giống UpdateStatusOrderTx, note được ghi vào lịch sử trạng thái
+func updateStatusOrderTx(tx *sql.Tx, orderID int64, actor Actor, paymentStatus *string, orderStatus *string, note string) error {
+	st, err := lockOrderTx(tx, orderID)
+	if err != nil {
+		return err
+	}
+	if err := checkOrderActor(st, actor); err != nil {
+		return err
+	}
+
+	query := "update orders set "
+	args := []interface{}{}
+
+	if orderStatus != nil && *orderStatus != st.OrderStatus {
+		if !CanTransitionOrder(actor.Role, st.OrderStatus, *orderStatus) {
+			return &TransitionError{OrderID: orderID, Field: "order_status", From: st.OrderStatus, To: *orderStatus, Role: actor.Role}
+		}
+		query += "order_status = ?"
+		args = append(args, *orderStatus)
+	}
+	if paymentStatus != nil && *paymentStatus != st.PaymentStatus {
+		if !CanTransitionPayment(actor.Role, st.PaymentStatus, *paymentStatus) {
+			return &TransitionError{OrderID: orderID, Field: "payment_status", From: st.PaymentStatus, To: *paymentStatus, Role: actor.Role}
+		}
+		// shipper chỉ thu tiền mặt khi đã giao xong (hoặc giao xong trong cùng lần cập nhật)
+		if actor.Role == RoleShipper && *paymentStatus == PaymentStatusPaid {
+			finalStatus := st.OrderStatus
+			if orderStatus != nil {
+				finalStatus = *orderStatus
+			}
+			if finalStatus != OrderStatusDelivered {
+				return &TransitionError{OrderID: orderID, Field: "payment_status", From: st.PaymentStatus, To: *paymentStatus, Role: actor.Role}
+			}
+		}
+		if len(args) > 0 {
+			query += ", "
+		}
+		query += "payment_status = ?"
+		args = append(args, *paymentStatus)
+	}
+	if len(args) == 0 {
+		// không có gì thay đổi
+		return nil
+	}
+
+	query += " where id = ?"
+	args = append(args, orderID)
+
+	if _, err := tx.Exec(query, args...); err != nil {
+		return err
+	}
+
+	if orderStatus != nil && *orderStatus == OrderStatusCancelled && st.OrderStatus != OrderStatusCancelled {
+		if err := releaseOrderTx(tx, orderID); err != nil {
+			return err
+		}
+	}
+
+	// ghi lịch sử trong cùng transaction
+	if orderStatus != nil && *orderStatus != st.OrderStatus {
+		if err := AddOrderStatusEventTx(tx, orderID, "order_status", st.OrderStatus, *orderStatus, actor, note); err != nil {
+			return err
+		}
+	}
+	if paymentStatus != nil && *paymentStatus != st.PaymentStatus {
+		if err := AddOrderStatusEventTx(tx, orderID, "payment_status", st.PaymentStatus, *paymentStatus, actor, note); err != nil {
+			return err
+		}
+		// shipper xác nhận đã thu tiền của đơn đã giao = thu tiền mặt (COD)
+		if actor.Role == RoleShipper && *paymentStatus == PaymentStatusPaid {
+			if err := addCODCollectionTx(tx, actor.ID, orderID, st.TotalAmount); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// huỷ đơn thì trả lại hàng đã giữ, suất flash sale và lượt dùng coupon
+func releaseOrderTx(tx *sql.Tx, orderID int64) error {
+	if err := ReleaseStockTx(tx, orderID); err != nil {
+		return err
+	}
+	if err := ReleaseFlashSaleTx(tx, orderID); err != nil {
+		return err
+	}
+	return ReleaseCouponTx(tx, orderID)
+}
@@ -0,0 +1,287 @@
+package models
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// trạng thái của một giao dịch thanh toán online (bảng payments)
+const (
+	PaymentPending   = "pending"
+	PaymentSucceeded = "succeeded"
+	PaymentFailed    = "failed"
+)
+
+// result_code của giao dịch bị thay bằng giao dịch mới của cùng order
+const PaymentResultSuperseded = "superseded"
+
+var (
+	ErrPaymentNotFound       = errors.New("payment not found")
+	ErrPaymentAmountMismatch = errors.New("paid amount does not match the payment")
+	ErrOrderNotPayable       = errors.New("order can't be paid online")
+	ErrPaymentDuplicate      = errors.New("transaction has already been recorded")
+	ErrPaymentConfirmed      = errors.New("payment has already succeeded")
+	ErrPaymentRefundRequired = errors.New("order is cancelled or already paid, the payment has to be refunded")
+)
+
+// Payment là một lần khách thanh toán order qua cổng thanh toán
+type Payment struct {
+	ID          int64     `json:"id"`
+	OrderID     int64     `json:"order_id"`
+	Provider    string    `json:"provider"`
+	ProviderRef string    `json:"provider_ref"`
+	Amount      float64   `json:"amount"`
+	Status      string    `json:"status"` // pending || succeeded || failed
+	PaymentURL  string    `json:"payment_url"`
+	ResultCode  string    `json:"result_code"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+const paymentColumns = `id, order_id, provider, coalesce(provider_ref, ''), amount, status,
+	coalesce(payment_url, ''), coalesce(result_code, ''), created_at, updated_at`
+
+func scanPayment(row rowScanner, p *Payment) error {
+	return row.Scan(&p.ID, &p.OrderID, &p.Provider, &p.ProviderRef, &p.Amount, &p.Status, &p.PaymentURL, &p.ResultCode, &p.CreatedAt, &p.UpdatedAt)
+}
+
+// CreatePendingPayment tạo giao dịch chờ thanh toán với số tiền bằng tổng tiền của order,
+// chỉ chủ order được tạo và order phải chưa thanh toán, chưa huỷ.
+// Các giao dịch đang chờ trước đó của order bị đánh dấu failed, mỗi order chỉ có một giao dịch đang chờ.
+func CreatePendingPayment(db *sql.DB, orderID int64, actor Actor, provider string) (*Payment, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	st, err := lockOrderTx(tx, orderID)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkOrderActor(st, actor); err != nil {
+		return nil, err
+	}
+	if st.PaymentStatus != PaymentStatusUnpaid || st.OrderStatus == OrderStatusCancelled {
+		return nil, ErrOrderNotPayable
+	}
+	_, err = tx.Exec("update payments set status = ?, result_code = ? where order_id = ? and status = ?",
+		PaymentFailed, PaymentResultSuperseded, orderID, PaymentPending)
+	if err != nil {
+		return nil, err
+	}
+	result, err := tx.Exec("insert into payments (order_id, provider, amount) values (?, ?, ?)", orderID, provider, st.TotalAmount)
+	if err != nil {
+		return nil, err
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return &Payment{ID: id, OrderID: orderID, Provider: provider, Amount: st.TotalAmount, Status: PaymentPending}, nil
+}
+
+// SetPaymentIntent lưu mã giao dịch và trang thanh toán cổng thanh toán trả về
+func SetPaymentIntent(db *sql.DB, paymentID int64, providerRef, paymentURL string) error {
+	_, err := db.Exec("update payments set provider_ref = ?, payment_url = ? where id = ?", providerRef, paymentURL, paymentID)
+	return err
+}
+
+// MarkPaymentFailed đánh dấu giao dịch lỗi (vd: không tạo được giao dịch ở cổng thanh toán)
+func MarkPaymentFailed(db *sql.DB, paymentID int64, resultCode string) error {
+	_, err := db.Exec("update payments set status = ?, result_code = ? where id = ? and status = ?", PaymentFailed, resultCode, paymentID, PaymentPending)
+	return err
+}
+
+// ApplyPaymentResult ghi kết quả cổng thanh toán báo về. Giao dịch thành công thì order
+// chuyển sang paid trong cùng transaction (order đã huỷ thì mở thêm yêu cầu hoàn tiền).
+// Order đã được thanh toán bằng giao dịch khác thì mở yêu cầu hoàn đúng số tiền của giao dịch này.
+// Webhook gửi lại cho giao dịch đã thành công không ghi gì, trả về payment kèm ErrPaymentConfirmed.
+func ApplyPaymentResult(db *sql.DB, provider, providerRef string, amount float64, status, resultCode string) (*Payment, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	// khoá order trước rồi mới khoá payment (cùng thứ tự với CreatePendingPayment)
+	var orderID int64
+	err = tx.QueryRow("select order_id from payments where provider = ? and provider_ref = ?", provider, providerRef).Scan(&orderID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrPaymentNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	st, err := lockOrderTx(tx, orderID)
+	if err != nil {
+		return nil, err
+	}
+	var p Payment
+	query := "select " + paymentColumns + " from payments where provider = ? and provider_ref = ? for update"
+	if err := scanPayment(tx.QueryRow(query, provider, providerRef), &p); err != nil {
+		return nil, err
+	}
+	// đã thành công rồi thì bỏ qua, chưa có kết quả cuối thì giữ nguyên
+	if p.Status == PaymentSucceeded {
+		return &p, ErrPaymentConfirmed
+	}
+	if status == PaymentPending {
+		return &p, tx.Commit()
+	}
+	if status == PaymentSucceeded && roundMoney(amount) != roundMoney(p.Amount) {
+		return nil, ErrPaymentAmountMismatch
+	}
+
+	if _, err := tx.Exec("update payments set status = ?, result_code = ? where id = ?", status, resultCode, p.ID); err != nil {
+		return nil, err
+	}
+	p.Status = status
+	p.ResultCode = resultCode
+
+	if status == PaymentSucceeded && st.PaymentStatus != PaymentStatusUnpaid {
+		// khách bị thu hai lần (hai giao dịch online, hoặc đã chuyển khoản): hoàn lại giao dịch này
+		reason := fmt.Sprintf("payment %s (%s) received for an order that was already paid", provider, providerRef)
+		if _, err := createPaymentRefundTx(tx, &p, reason); err != nil {
+			return nil, err
+		}
+	}
+	if status == PaymentSucceeded && st.PaymentStatus == PaymentStatusUnpaid {
+		actor := Actor{Role: RoleSystem}
+		paid := PaymentStatusPaid
+		note := fmt.Sprintf("paid via %s (%s)", provider, providerRef)
+		if err := updateStatusOrderTx(tx, orderID, actor, &paid, nil, note); err != nil {
+			return nil, err
+		}
+		// order đã bị huỷ trong lúc khách thanh toán: tiền đã nhận nên mở sẵn yêu cầu hoàn tiền cho admin duyệt
+		if st.OrderStatus == OrderStatusCancelled {
+			st.PaymentStatus = paid
+			reason := fmt.Sprintf("payment %s (%s) received after the order was cancelled", provider, providerRef)
+			if _, err := createRefundTx(tx, st, actor, reason, nil); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return &p, tx.Commit()
+}
+
+func GetPaymentsByOrder(db *sql.DB, orderID int64) ([]Payment, error) {
+	rows, err := db.Query("select "+paymentColumns+" from payments where order_id = ? order by id desc", orderID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	payments := []Payment{}
+	for rows.Next() {
+		var p Payment
+		if err := scanPayment(rows, &p); err != nil {
+			return nil, err
+		}
+		payments = append(payments, p)
+	}
+	return payments, rows.Err()
+}
+
+// GetOrderAmountDue trả số tiền cần thanh toán của order chưa thanh toán, chưa huỷ
+// (dùng để tạo mã QR chuyển khoản). Customer chỉ xem được order của mình.
+func GetOrderAmountDue(db *sql.DB, orderID int64, actor Actor) (float64, error) {
+	var st orderState
+	err := db.QueryRow("select user_id, shipper_id, payment_status, order_status, total_amount from orders where id = ?", orderID).
+		Scan(&st.UserID, &st.ShipperID, &st.PaymentStatus, &st.OrderStatus, &st.TotalAmount)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, ErrOrderNotFound
+	}
+	if err != nil {
+		return 0, err
+	}
+	if err := checkOrderActor(&st, actor); err != nil {
+		return 0, err
+	}
+	if st.PaymentStatus != PaymentStatusUnpaid || st.OrderStatus == OrderStatusCancelled {
+		return 0, ErrOrderNotPayable
+	}
+	return st.TotalAmount, nil
+}
+
+// ApplyBankTransfer ghi một khoản chuyển khoản trong sao kê cho order: số tiền phải khớp
+// tổng tiền, order chuyển sang paid. Mỗi mã giao dịch ngân hàng chỉ được ghi một lần.
+// apply = false thì chỉ kiểm tra (đối soát thử), không ghi gì. Trả về tổng tiền của order.
+// Order đổi trạng thái qua UpdateStatusOrderTx với actor là admin đối soát.
+// Order đã huỷ hoặc đã thanh toán thì khoản chuyển khoản vẫn được ghi kèm yêu cầu hoàn tiền
+// (giống ApplyPaymentResult) và trả về ErrPaymentRefundRequired.
+func ApplyBankTransfer(db *sql.DB, provider string, orderID int64, transactionID string, amount float64, actor Actor, apply bool) (float64, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	st, err := lockOrderTx(tx, orderID)
+	if err != nil {
+		return 0, err
+	}
+	var exists bool
+	err = tx.QueryRow("select exists (select 1 from payments where provider = ? and provider_ref = ?)", provider, transactionID).Scan(&exists)
+	if err != nil {
+		return st.TotalAmount, err
+	}
+	if exists {
+		return st.TotalAmount, ErrPaymentDuplicate
+	}
+	if roundMoney(amount) != roundMoney(st.TotalAmount) {
+		return st.TotalAmount, ErrPaymentAmountMismatch
+	}
+	refundRequired := st.PaymentStatus != PaymentStatusUnpaid || st.OrderStatus == OrderStatusCancelled
+	if !apply {
+		if refundRequired {
+			return st.TotalAmount, ErrPaymentRefundRequired
+		}
+		return st.TotalAmount, nil
+	}
+
+	result, err := tx.Exec("insert into payments (order_id, provider, provider_ref, amount, status) values (?, ?, ?, ?, ?)",
+		orderID, provider, transactionID, amount, PaymentSucceeded)
+	if err != nil {
+		return st.TotalAmount, err
+	}
+	// đã thanh toán bằng giao dịch khác: hoàn lại khoản chuyển khoản này
+	if st.PaymentStatus != PaymentStatusUnpaid {
+		p := Payment{OrderID: orderID, Provider: provider, ProviderRef: transactionID, Amount: amount, Status: PaymentSucceeded}
+		if p.ID, err = result.LastInsertId(); err != nil {
+			return st.TotalAmount, err
+		}
+		reason := fmt.Sprintf("bank transfer %s received for an order that was already paid", transactionID)
+		if _, err := createPaymentRefundTx(tx, &p, reason); err != nil {
+			return st.TotalAmount, err
+		}
+		if err := tx.Commit(); err != nil {
+			return st.TotalAmount, err
+		}
+		return st.TotalAmount, ErrPaymentRefundRequired
+	}
+
+	paid := PaymentStatusPaid
+	note := fmt.Sprintf("bank transfer %s", transactionID)
+	if err := updateStatusOrderTx(tx, orderID, actor, &paid, nil, note); err != nil {
+		return st.TotalAmount, err
+	}
+	if !refundRequired {
+		return st.TotalAmount, tx.Commit()
+	}
+	// order đã huỷ: tiền đã nhận nên mở sẵn yêu cầu hoàn tiền cho admin duyệt
+	st.PaymentStatus = paid
+	reason := fmt.Sprintf("bank transfer %s received after the order was cancelled", transactionID)
+	if _, err := createRefundTx(tx, st, actor, reason, nil); err != nil {
+		return st.TotalAmount, err
+	}
+	if err := tx.Commit(); err != nil {
+		return st.TotalAmount, err
+	}
+	return st.TotalAmount, ErrPaymentRefundRequired
+}
@@ -13,9 +13,11 @@ type Product struct {
 	ID          int64     `json:"id"`
 	Name        string    `json:"name"`
 	Description string    `json:"description"`
+	Category    string    `json:"category"`
 	Price       float64   `json:"price"`
 	QtyInitial  int64     `json:"qty_initial"`
 	QtySold     int64     `json:"qty_sold"`
+	StoreID     int64     `json:"store_id"`
 	CreatedAt   time.Time `json:"created_at"`
 }
 type ProductImage struct {
@@ -24,16 +26,21 @@ type ProductImage struct {
 	IsMain bool
 }
 type ProductResponse struct {
-	ID          int64          `json:"id"`
-	Name        string         `json:"name"`
-	Description string         `json:"description"`
-	Price       float64        `json:"price"`
-	QtyInitial  int64          `json:"qty_initial"`
-	QtySold     int64          `json:"qty_sold"`
-	CreatedAt   time.Time      `json:"created_at"`
-	Images      []ProductImage `json:"images"`
-	AvgRate     float64        `json:"avg_rate"`
-	ReviewCount int            `json:"review_count"`
+	ID            int64             `json:"id"`
+	Name          string            `json:"name"`
+	Description   string            `json:"description"`
+	Category      string            `json:"category"`
+	Price         float64           `json:"price"`          // giá đang bán (đã tính flash sale)
+	OriginalPrice float64           `json:"original_price"` // giá gốc
+	FlashSale     *ProductFlashSale `json:"flash_sale"`     // nil khi không có flash sale
+	QtyInitial    int64             `json:"qty_initial"`
+	QtySold       int64             `json:"qty_sold"`
+	StoreID       int64             `json:"store_id"`
+	CreatedAt     time.Time         `json:"created_at"`
+	Images        []ProductImage    `json:"images"`
+	AvgRate       float64           `json:"avg_rate"`
+	ReviewCount   int               `json:"review_count"`
+	OptionGroups  []OptionGroup     `json:"option_groups,omitempty"` // chỉ có ở chi tiết sản phẩm
 }
 
 // get number of products
@@ -50,11 +57,15 @@ func GetNumberOfProduct(db *sql.DB) (int64, error) {
 // create new products
 func CreateProductTx(tx *sql.Tx, p *Product) (int64, error) {
 	query := `
-        INSERT INTO Products (name, description, price, qty_initial, qty_sold, created_at)
-        VALUES (?, ?, ?, ?, ?, ?)
+        INSERT INTO Products (name, description, category, price, qty_initial, qty_sold, store_id, created_at)
+        VALUES (?, ?, ?, ?, ?, ?, ?, ?)
     `
+	var category sql.NullString
+	if p.Category != "" {
+		category = sql.NullString{String: p.Category, Valid: true}
+	}
 	p.CreatedAt = time.Now()
-	result, err := tx.Exec(query, p.Name, p.Description, p.Price, p.QtyInitial, p.QtySold, p.CreatedAt)
+	result, err := tx.Exec(query, p.Name, p.Description, category, p.Price, p.QtyInitial, p.QtySold, p.StoreID, p.CreatedAt)
 	if err != nil {
 		return 0, err
 	}
@@ -96,16 +107,17 @@ func GetRatingByProductID(db *sql.DB, productID int64) (float64, int, error) {
 	return avgRate.Float64, count, nil
 }
 
-// get products
-func GetProductsPaginated(db *sql.DB, page, limit int) ([]ProductResponse, int, error) {
+// get products, storeID = 0 thì lấy sản phẩm của mọi store
+func GetProductsPaginated(db *sql.DB, storeID int64, page, limit int) ([]ProductResponse, int, error) {
 	offset := (page - 1) * limit
 
 	// Query lấy sản phẩm + ảnh
 	query := `
-    SELECT p.id, p.name, p.description, p.price, p.qty_initial, p.qty_sold, p.created_at,
+    SELECT p.id, p.name, p.description, coalesce(p.category, ''), p.price, p.qty_initial, p.qty_sold, coalesce(p.store_id, 0), p.created_at,
            i.id, i.url, pi.is_main
     FROM (
         SELECT * FROM Products
+        WHERE (? = 0 OR store_id = ?)
         ORDER BY id DESC
         LIMIT ? OFFSET ?
     ) p
@@ -113,7 +125,7 @@ func GetProductsPaginated(db *sql.DB, page, limit int) ([]ProductResponse, int,
     LEFT JOIN Images i ON pi.image_id = i.id
 `
 
-	rows, err := db.Query(query, limit, offset)
+	rows, err := db.Query(query, storeID, storeID, limit, offset)
 	if err != nil {
 		return nil, 0, err
 	}
@@ -127,8 +139,8 @@ func GetProductsPaginated(db *sql.DB, page, limit int) ([]ProductResponse, int,
 			imgURL sql.NullString
 			isMain sql.NullBool
 		)
-		err := rows.Scan(&p.ID, &p.Name, &p.Description, &p.Price,
-			&p.QtyInitial, &p.QtySold, &p.CreatedAt,
+		err := rows.Scan(&p.ID, &p.Name, &p.Description, &p.Category, &p.Price,
+			&p.QtyInitial, &p.QtySold, &p.StoreID, &p.CreatedAt,
 			&imgID, &imgURL, &isMain,
 		)
 		if err != nil {
@@ -159,10 +171,13 @@ func GetProductsPaginated(db *sql.DB, page, limit int) ([]ProductResponse, int,
 	for _, v := range productsMap {
 		products = append(products, *v)
 	}
+	if err := ApplyFlashSales(db, products, time.Now()); err != nil {
+		return nil, 0, err
+	}
 
 	// Query tổng số sản phẩm
 	var total int
-	err = db.QueryRow("SELECT COUNT(*) FROM Products").Scan(&total)
+	err = db.QueryRow("SELECT COUNT(*) FROM Products WHERE (? = 0 OR store_id = ?)", storeID, storeID).Scan(&total)
 	if err != nil {
 		return nil, 0, err
 	}
@@ -171,14 +186,14 @@ func GetProductsPaginated(db *sql.DB, page, limit int) ([]ProductResponse, int,
 }
 func GetProductByID(db *sql.DB, id int64) (*Product, error) {
 	query := `
-        SELECT id, name, description, price, qty_initial, qty_sold, created_at
+        SELECT id, name, description, coalesce(category, ''), price, qty_initial, qty_sold, coalesce(store_id, 0), created_at
         FROM Products
         WHERE id = ?
     `
 	row := db.QueryRow(query, id)
 
 	var p Product
-	err := row.Scan(&p.ID, &p.Name, &p.Description, &p.Price, &p.QtyInitial, &p.QtySold, &p.CreatedAt)
+	err := row.Scan(&p.ID, &p.Name, &p.Description, &p.Category, &p.Price, &p.QtyInitial, &p.QtySold, &p.StoreID, &p.CreatedAt)
 	if err != nil {
 		return nil, err
 	}
@@ -293,7 +308,7 @@ func SearchProductsPaginated(db *sql.DB, keyword string, page, limit int) ([]Pro
 	searchTerm := "%" + keyword + "%"
 
 	query := `
-    SELECT p.id, p.name, p.description, p.price, p.qty_initial, p.qty_sold, p.created_at,
+    SELECT p.id, p.name, p.description, coalesce(p.category, ''), p.price, p.qty_initial, p.qty_sold, coalesce(p.store_id, 0), p.created_at,
            i.id, i.url, pi.is_main
     FROM (
         SELECT * FROM Products
@@ -320,8 +335,8 @@ func SearchProductsPaginated(db *sql.DB, keyword string, page, limit int) ([]Pro
 			isMain sql.NullBool
 		)
 
-		err := rows.Scan(&p.ID, &p.Name, &p.Description, &p.Price,
-			&p.QtyInitial, &p.QtySold, &p.CreatedAt,
+		err := rows.Scan(&p.ID, &p.Name, &p.Description, &p.Category, &p.Price,
+			&p.QtyInitial, &p.QtySold, &p.StoreID, &p.CreatedAt,
 			&imgID, &imgURL, &isMain,
 		)
 		if err != nil {
@@ -355,6 +370,9 @@ func SearchProductsPaginated(db *sql.DB, keyword string, page, limit int) ([]Pro
 	for _, v := range productsMap {
 		products = append(products, *v)
 	}
+	if err := ApplyFlashSales(db, products, time.Now()); err != nil {
+		return nil, 0, err
+	}
 
 	//  Lấy tổng số sản phẩm phù hợp điều kiện tìm kiếm
 	var total int
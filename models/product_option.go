@@ -0,0 +1,292 @@
+package models
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// lý do lựa chọn option của một sản phẩm trong đơn bị từ chối
+const (
+	OptionErrInvalid     = "invalid_option"     // option không thuộc sản phẩm
+	OptionErrUnavailable = "option_unavailable" // option đang tạm hết
+	OptionErrDuplicate   = "duplicate_option"
+	OptionErrTooFew      = "too_few_options"  // chọn ít hơn min_select của nhóm
+	OptionErrTooMany     = "too_many_options" // chọn nhiều hơn max_select của nhóm
+)
+
+var ErrOptionGroupNotFound = errors.New("option group or option does not belong to this product")
+
+// OptionGroup là một nhóm lựa chọn của sản phẩm, vd "Size" (chọn đúng 1) hay "Topping" (chọn 0-3)
+type OptionGroup struct {
+	ID        int64           `json:"id"`
+	ProductID int64           `json:"product_id"`
+	Name      string          `json:"name"`
+	MinSelect int             `json:"min_select"`
+	MaxSelect int             `json:"max_select"`
+	SortOrder int             `json:"sort_order"`
+	Options   []ProductOption `json:"options"`
+}
+
+// ProductOption là một lựa chọn trong nhóm, PriceDelta cộng vào đơn giá (có thể âm, vd size nhỏ)
+type ProductOption struct {
+	ID          int64   `json:"id"`
+	GroupID     int64   `json:"group_id"`
+	Name        string  `json:"name"`
+	PriceDelta  float64 `json:"price_delta"`
+	IsAvailable bool    `json:"is_available"`
+	SortOrder   int     `json:"sort_order"`
+}
+
+// OrderItemOption là option đã chọn của một order_item, lưu lại tên và giá lúc đặt
+type OrderItemOption struct {
+	OptionID   int64   `json:"option_id"` // 0 nếu option đã bị xoá khỏi menu
+	GroupName  string  `json:"group_name"`
+	OptionName string  `json:"option_name"`
+	PriceDelta float64 `json:"price_delta"`
+}
+
+// OptionError mô tả lỗi lựa chọn option của một sản phẩm
+type OptionError struct {
+	ProductID int64
+	GroupID   int64
+	OptionID  int64
+	Reason    string
+}
+
+func (e *OptionError) Error() string {
+	if e.OptionID != 0 {
+		return fmt.Sprintf("product %d: option %d: %s", e.ProductID, e.OptionID, e.Reason)
+	}
+	return fmt.Sprintf("product %d: option group %d: %s", e.ProductID, e.GroupID, e.Reason)
+}
+
+// GetOptionGroups lấy các nhóm option (kèm option) của các sản phẩm, theo sort_order
+func GetOptionGroups(q queryer, productIDs []int64) (map[int64][]OptionGroup, error) {
+	groups := make(map[int64][]OptionGroup)
+	if len(productIDs) == 0 {
+		return groups, nil
+	}
+	placeholders := strings.TrimSuffix(strings.Repeat("?,", len(productIDs)), ",")
+	args := make([]interface{}, len(productIDs))
+	for i, id := range productIDs {
+		args[i] = id
+	}
+	query := `select g.id, g.product_id, g.name, g.min_select, g.max_select, g.sort_order,
+		       coalesce(o.id, 0), coalesce(o.name, ''), coalesce(o.price_delta, 0), coalesce(o.is_available, 0), coalesce(o.sort_order, 0)
+		from product_option_groups g
+		left join product_options o on o.group_id = g.id
+		where g.product_id in (` + placeholders + `)
+		order by g.product_id, g.sort_order, g.id, o.sort_order, o.id`
+	rows, err := q.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var g OptionGroup
+		var o ProductOption
+		if err := rows.Scan(&g.ID, &g.ProductID, &g.Name, &g.MinSelect, &g.MaxSelect, &g.SortOrder,
+			&o.ID, &o.Name, &o.PriceDelta, &o.IsAvailable, &o.SortOrder); err != nil {
+			return nil, err
+		}
+		list := groups[g.ProductID]
+		if n := len(list); n == 0 || list[n-1].ID != g.ID {
+			g.Options = []ProductOption{}
+			list = append(list, g)
+		}
+		if o.ID != 0 {
+			o.GroupID = g.ID
+			last := &list[len(list)-1]
+			last.Options = append(last.Options, o)
+		}
+		groups[g.ProductID] = list
+	}
+	return groups, rows.Err()
+}
+
+// SelectOptions kiểm tra các option được chọn của một sản phẩm theo min/max của từng nhóm,
+// trả về option đã chọn (theo thứ tự nhóm) và tổng tiền cộng thêm vào đơn giá
+func SelectOptions(productID int64, groups []OptionGroup, optionIDs []int64) ([]OrderItemOption, float64, error) {
+	type found struct {
+		group  *OptionGroup
+		option *ProductOption
+	}
+	index := make(map[int64]found)
+	for gi := range groups {
+		for oi := range groups[gi].Options {
+			index[groups[gi].Options[oi].ID] = found{&groups[gi], &groups[gi].Options[oi]}
+		}
+	}
+
+	chosen := make(map[int64]bool, len(optionIDs))
+	counts := make(map[int64]int)
+	for _, id := range optionIDs {
+		f, ok := index[id]
+		switch {
+		case !ok:
+			return nil, 0, &OptionError{ProductID: productID, OptionID: id, Reason: OptionErrInvalid}
+		case chosen[id]:
+			return nil, 0, &OptionError{ProductID: productID, OptionID: id, Reason: OptionErrDuplicate}
+		case !f.option.IsAvailable:
+			return nil, 0, &OptionError{ProductID: productID, OptionID: id, Reason: OptionErrUnavailable}
+		}
+		chosen[id] = true
+		counts[f.group.ID]++
+	}
+
+	selected := []OrderItemOption{}
+	var delta float64
+	for _, g := range groups {
+		switch n := counts[g.ID]; {
+		case n < g.MinSelect:
+			return nil, 0, &OptionError{ProductID: productID, GroupID: g.ID, Reason: OptionErrTooFew}
+		case n > g.MaxSelect:
+			return nil, 0, &OptionError{ProductID: productID, GroupID: g.ID, Reason: OptionErrTooMany}
+		}
+		for _, o := range g.Options {
+			if chosen[o.ID] {
+				selected = append(selected, OrderItemOption{OptionID: o.ID, GroupName: g.Name, OptionName: o.Name, PriceDelta: o.PriceDelta})
+				delta += o.PriceDelta
+			}
+		}
+	}
+	return selected, roundMoney(delta), nil
+}
+
+// ReplaceProductOptions ghi lại toàn bộ nhóm option của sản phẩm: nhóm/option có id thì cập nhật,
+// không có id thì thêm mới, nhóm/option không còn trong danh sách thì xoá.
+// Order cũ vẫn giữ tên và giá option đã chọn (order_item_options).
+func ReplaceProductOptions(db *sql.DB, productID int64, groups []OptionGroup) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var exists int64
+	if err := tx.QueryRow("select id from Products where id = ? for update", productID).Scan(&exists); err != nil {
+		return err
+	}
+	current, err := GetOptionGroups(tx, []int64{productID})
+	if err != nil {
+		return err
+	}
+	oldGroups := make(map[int64]bool)
+	oldOptions := make(map[int64]int64) // option id -> group id
+	for _, g := range current[productID] {
+		oldGroups[g.ID] = true
+		for _, o := range g.Options {
+			oldOptions[o.ID] = g.ID
+		}
+	}
+
+	keepGroups := make(map[int64]bool)
+	keepOptions := make(map[int64]bool)
+	for i := range groups {
+		g := &groups[i]
+		g.ProductID = productID
+		if g.ID != 0 {
+			if !oldGroups[g.ID] {
+				return ErrOptionGroupNotFound
+			}
+			query := "update product_option_groups set name = ?, min_select = ?, max_select = ?, sort_order = ? where id = ?"
+			if _, err := tx.Exec(query, g.Name, g.MinSelect, g.MaxSelect, g.SortOrder, g.ID); err != nil {
+				return err
+			}
+		} else {
+			query := "insert into product_option_groups (product_id, name, min_select, max_select, sort_order) values (?, ?, ?, ?, ?)"
+			result, err := tx.Exec(query, productID, g.Name, g.MinSelect, g.MaxSelect, g.SortOrder)
+			if err != nil {
+				return err
+			}
+			if g.ID, err = result.LastInsertId(); err != nil {
+				return err
+			}
+		}
+		keepGroups[g.ID] = true
+
+		for j := range g.Options {
+			o := &g.Options[j]
+			o.GroupID = g.ID
+			if o.ID != 0 {
+				// option chỉ được sửa trong đúng nhóm của nó
+				if groupID, ok := oldOptions[o.ID]; !ok || groupID != g.ID {
+					return ErrOptionGroupNotFound
+				}
+				query := "update product_options set name = ?, price_delta = ?, is_available = ?, sort_order = ? where id = ?"
+				if _, err := tx.Exec(query, o.Name, o.PriceDelta, o.IsAvailable, o.SortOrder, o.ID); err != nil {
+					return err
+				}
+			} else {
+				query := "insert into product_options (group_id, name, price_delta, is_available, sort_order) values (?, ?, ?, ?, ?)"
+				result, err := tx.Exec(query, g.ID, o.Name, o.PriceDelta, o.IsAvailable, o.SortOrder)
+				if err != nil {
+					return err
+				}
+				if o.ID, err = result.LastInsertId(); err != nil {
+					return err
+				}
+			}
+			keepOptions[o.ID] = true
+		}
+	}
+
+	for id := range oldOptions {
+		if !keepOptions[id] {
+			if _, err := tx.Exec("delete from product_options where id = ?", id); err != nil {
+				return err
+			}
+		}
+	}
+	for id := range oldGroups {
+		if !keepGroups[id] {
+			if _, err := tx.Exec("delete from product_option_groups where id = ?", id); err != nil {
+				return err
+			}
+		}
+	}
+	return tx.Commit()
+}
+
+// addOrderItemOptionsTx lưu các option đã chọn của order_item
+func addOrderItemOptionsTx(tx *sql.Tx, orderItemID int64, options []OrderItemOption) error {
+	query := "insert into order_item_options (order_item_id, option_id, group_name, option_name, price_delta) values (?, ?, ?, ?, ?)"
+	for _, o := range options {
+		var optionID sql.NullInt64
+		if o.OptionID != 0 {
+			optionID = sql.NullInt64{Int64: o.OptionID, Valid: true}
+		}
+		if _, err := tx.Exec(query, orderItemID, optionID, o.GroupName, o.OptionName, o.PriceDelta); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// getOrderItemOptions lấy option đã chọn của các order_item trong order, theo order_item_id
+func getOrderItemOptions(db *sql.DB, orderID int64) (map[int64][]OrderItemOption, error) {
+	query := `select oio.order_item_id, coalesce(oio.option_id, 0), oio.group_name, oio.option_name, oio.price_delta
+		from order_item_options oio
+		join order_items oi on oio.order_item_id = oi.id
+		where oi.order_id = ?
+		order by oio.id`
+	rows, err := db.Query(query, orderID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	options := make(map[int64][]OrderItemOption)
+	for rows.Next() {
+		var itemID int64
+		var o OrderItemOption
+		if err := rows.Scan(&itemID, &o.OptionID, &o.GroupName, &o.OptionName, &o.PriceDelta); err != nil {
+			return nil, err
+		}
+		options[itemID] = append(options[itemID], o)
+	}
+	return options, rows.Err()
+}
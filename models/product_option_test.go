@@ -0,0 +1,73 @@
+package models
+
+import (
+	"errors"
+	"testing"
+)
+
+func testOptionGroups() []OptionGroup {
+	return []OptionGroup{
+		{ID: 1, Name: "Size", MinSelect: 1, MaxSelect: 1, Options: []ProductOption{
+			{ID: 11, GroupID: 1, Name: "M", IsAvailable: true},
+			{ID: 12, GroupID: 1, Name: "L", PriceDelta: 10000, IsAvailable: true},
+			{ID: 13, GroupID: 1, Name: "S", PriceDelta: -5000, IsAvailable: true},
+		}},
+		{ID: 2, Name: "Topping", MinSelect: 0, MaxSelect: 2, Options: []ProductOption{
+			{ID: 21, GroupID: 2, Name: "Trân châu", PriceDelta: 5000, IsAvailable: true},
+			{ID: 22, GroupID: 2, Name: "Thạch", PriceDelta: 4000.5, IsAvailable: true},
+			{ID: 23, GroupID: 2, Name: "Pudding", PriceDelta: 7000, IsAvailable: false},
+		}},
+	}
+}
+
+func TestSelectOptions(t *testing.T) {
+	// trả về theo thứ tự nhóm, không theo thứ tự gửi lên
+	selected, delta, err := SelectOptions(5, testOptionGroups(), []int64{22, 12, 21})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if delta != 19000.5 {
+		t.Errorf("delta = %v, want 19000.5", delta)
+	}
+	want := []string{"Size/L", "Topping/Trân châu", "Topping/Thạch"}
+	if len(selected) != len(want) {
+		t.Fatalf("selected = %+v", selected)
+	}
+	for i, o := range selected {
+		if got := o.GroupName + "/" + o.OptionName; got != want[i] {
+			t.Errorf("selected[%d] = %s, want %s", i, got, want[i])
+		}
+	}
+
+	// sản phẩm không có option
+	if selected, delta, err := SelectOptions(5, nil, nil); err != nil || len(selected) != 0 || delta != 0 {
+		t.Errorf("no options: %v %v %v", selected, delta, err)
+	}
+}
+
+func TestSelectOptionsErrors(t *testing.T) {
+	tests := []struct {
+		name      string
+		optionIDs []int64
+		reason    string
+		groupID   int64
+		optionID  int64
+	}{
+		{"missing required size", []int64{21}, OptionErrTooFew, 1, 0},
+		{"two sizes", []int64{11, 12}, OptionErrTooMany, 1, 0},
+		{"same topping twice", []int64{11, 21, 21}, OptionErrDuplicate, 0, 21},
+		{"option of another product", []int64{11, 99}, OptionErrInvalid, 0, 99},
+		{"unavailable", []int64{11, 23}, OptionErrUnavailable, 0, 23},
+	}
+	for _, tt := range tests {
+		_, _, err := SelectOptions(5, testOptionGroups(), tt.optionIDs)
+		var optErr *OptionError
+		if !errors.As(err, &optErr) {
+			t.Errorf("%s: want OptionError, got %v", tt.name, err)
+			continue
+		}
+		if optErr.Reason != tt.reason || optErr.GroupID != tt.groupID || optErr.OptionID != tt.optionID || optErr.ProductID != 5 {
+			t.Errorf("%s: got %+v", tt.name, optErr)
+		}
+	}
+}
@@ -0,0 +1,444 @@
+package models
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+)
+
+// trạng thái của yêu cầu hoàn tiền
+const (
+	RefundStatusRequested = "requested"
+	RefundStatusApproved  = "approved"
+	RefundStatusRejected  = "rejected"
+)
+
+var (
+	ErrRefundNotFound        = errors.New("refund not found")
+	ErrRefundNotAllowed      = errors.New("order has no paid amount left to refund")
+	ErrRefundPending         = errors.New("order already has a refund waiting for review")
+	ErrRefundAlreadyReviewed = errors.New("refund has already been reviewed")
+	ErrRefundAmountExceeded  = errors.New("refund amount must be greater than 0 and not exceed the refundable amount")
+	ErrInvalidRefundItems    = errors.New("refund items must belong to the order and not exceed the quantity left to refund")
+)
+
+// Refund là một yêu cầu hoàn tiền của order, các refund đã duyệt là sổ hoàn tiền của order.
+// Refund có PaymentID là hoàn một giao dịch bị thu thừa (order đã thanh toán rồi), không tính vào sổ của order.
+type Refund struct {
+	ID              int64        `json:"id"`
+	OrderID         int64        `json:"order_id"`
+	PaymentID       *int64       `json:"payment_id"`
+	RequestedBy     int64        `json:"requested_by"`
+	RequesterRole   string       `json:"requester_role"`
+	Reason          string       `json:"reason"`
+	Status          string       `json:"status"` // requested || approved || rejected
+	RequestedAmount float64      `json:"requested_amount"`
+	ApprovedAmount  *float64     `json:"approved_amount"`
+	ReviewedBy      *int64       `json:"reviewed_by"`
+	ReviewNote      string       `json:"review_note"`
+	CreatedAt       time.Time    `json:"created_at"`
+	ReviewedAt      *time.Time   `json:"reviewed_at"`
+	Items           []RefundItem `json:"items"`
+}
+
+type RefundItem struct {
+	OrderItemID int64   `json:"order_item_id"`
+	ProductID   int64   `json:"product_id"`
+	Quantity    int64   `json:"quantity"`
+	Amount      float64 `json:"amount"`
+}
+
+// RefundItemRequest là một dòng của order được yêu cầu hoàn tiền
+type RefundItemRequest struct {
+	OrderItemID int64 `json:"order_item_id" binding:"required"`
+	Quantity    int64 `json:"quantity" binding:"required,gt=0"`
+}
+
+func roundMoney(v float64) float64 {
+	return math.Round(v*100) / 100
+}
+
+const refundColumns = `id, order_id, payment_id, coalesce(requested_by, 0), requester_role, reason, status, requested_amount,
+	approved_amount, reviewed_by, coalesce(review_note, ''), created_at, reviewed_at`
+
+func scanRefund(row rowScanner, r *Refund) error {
+	var approved sql.NullFloat64
+	var paymentID, reviewedBy sql.NullInt64
+	err := row.Scan(&r.ID, &r.OrderID, &paymentID, &r.RequestedBy, &r.RequesterRole, &r.Reason, &r.Status, &r.RequestedAmount,
+		&approved, &reviewedBy, &r.ReviewNote, &r.CreatedAt, &r.ReviewedAt)
+	if err != nil {
+		return err
+	}
+	if paymentID.Valid {
+		r.PaymentID = &paymentID.Int64
+	}
+	if approved.Valid {
+		r.ApprovedAmount = &approved.Float64
+	}
+	if reviewedBy.Valid {
+		r.ReviewedBy = &reviewedBy.Int64
+	}
+	return nil
+}
+
+// tổng tiền đã hoàn của order (không tính hoàn giao dịch thu thừa)
+func refundedAmount(q queryer, orderID int64) (float64, error) {
+	var amount float64
+	err := q.QueryRow("select coalesce(sum(approved_amount), 0) from refunds where order_id = ? and status = ? and payment_id is null", orderID, RefundStatusApproved).Scan(&amount)
+	return amount, err
+}
+
+// kiểm tra các dòng yêu cầu hoàn với số lượng còn lại chưa hoàn của từng order_item
+func buildRefundItems(tx *sql.Tx, orderID int64, reqItems []RefundItemRequest) ([]RefundItem, float64, error) {
+	query := `
+		select oi.id, oi.product_id, oi.quantity, oi.price,
+		       coalesce((select sum(ri.quantity) from refund_items ri join refunds r on r.id = ri.refund_id
+		                 where ri.order_item_id = oi.id and r.status = ?), 0)
+		from order_items oi where oi.order_id = ?`
+	rows, err := tx.Query(query, RefundStatusApproved, orderID)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	type line struct {
+		productID int64
+		left      int64
+		price     float64
+	}
+	lines := make(map[int64]*line)
+	for rows.Next() {
+		var id, qty, refunded int64
+		var l line
+		if err := rows.Scan(&id, &l.productID, &qty, &l.price, &refunded); err != nil {
+			return nil, 0, err
+		}
+		l.left = qty - refunded
+		lines[id] = &l
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, err
+	}
+
+	var items []RefundItem
+	var total float64
+	seen := make(map[int64]bool)
+	for _, req := range reqItems {
+		l, ok := lines[req.OrderItemID]
+		if !ok || seen[req.OrderItemID] || req.Quantity <= 0 || req.Quantity > l.left {
+			return nil, 0, ErrInvalidRefundItems
+		}
+		seen[req.OrderItemID] = true
+		amount := roundMoney(float64(req.Quantity) * l.price)
+		items = append(items, RefundItem{OrderItemID: req.OrderItemID, ProductID: l.productID, Quantity: req.Quantity, Amount: amount})
+		total += amount
+	}
+	return items, total, nil
+}
+
+// CreateRefund mở yêu cầu hoàn tiền cho order đã thanh toán. Không có items thì yêu cầu hoàn
+// toàn bộ số tiền còn lại, có items thì yêu cầu hoàn theo giá của các dòng đó
+// (không vượt quá số tiền còn lại). Mỗi order chỉ có một yêu cầu chờ duyệt tại một thời điểm.
+func CreateRefund(db *sql.DB, orderID int64, actor Actor, reason string, reqItems []RefundItemRequest) (*Refund, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	st, err := lockOrderTx(tx, orderID)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkOrderActor(st, actor); err != nil {
+		return nil, err
+	}
+	refund, err := createRefundTx(tx, st, actor, reason, reqItems)
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return refund, nil
+}
+
+// createRefundTx mở yêu cầu hoàn tiền cho order đã khoá trong tx
+func createRefundTx(tx *sql.Tx, st *orderState, actor Actor, reason string, reqItems []RefundItemRequest) (*Refund, error) {
+	orderID := st.ID
+	if st.PaymentStatus != PaymentStatusPaid && st.PaymentStatus != PaymentStatusPartiallyRefunded {
+		return nil, ErrRefundNotAllowed
+	}
+	var pending int
+	if err := tx.QueryRow("select count(*) from refunds where order_id = ? and status = ? and payment_id is null", orderID, RefundStatusRequested).Scan(&pending); err != nil {
+		return nil, err
+	}
+	if pending > 0 {
+		return nil, ErrRefundPending
+	}
+	refunded, err := refundedAmount(tx, orderID)
+	if err != nil {
+		return nil, err
+	}
+	remaining := roundMoney(st.TotalAmount - refunded)
+	if remaining <= 0 {
+		return nil, ErrRefundNotAllowed
+	}
+
+	refund := &Refund{
+		OrderID:         orderID,
+		RequestedBy:     actor.ID,
+		RequesterRole:   actor.Role,
+		Reason:          reason,
+		Status:          RefundStatusRequested,
+		RequestedAmount: remaining,
+		Items:           []RefundItem{},
+	}
+	if len(reqItems) > 0 {
+		items, amount, err := buildRefundItems(tx, orderID, reqItems)
+		if err != nil {
+			return nil, err
+		}
+		refund.Items = items
+		refund.RequestedAmount = min(amount, remaining)
+	}
+
+	// refund do hệ thống tự mở thì không có người yêu cầu
+	var requestedBy sql.NullInt64
+	if actor.ID != 0 {
+		requestedBy = sql.NullInt64{Int64: actor.ID, Valid: true}
+	}
+	query := "insert into refunds (order_id, requested_by, requester_role, reason, requested_amount) values (?, ?, ?, ?, ?)"
+	result, err := tx.Exec(query, orderID, requestedBy, actor.Role, reason, refund.RequestedAmount)
+	if err != nil {
+		return nil, err
+	}
+	if refund.ID, err = result.LastInsertId(); err != nil {
+		return nil, err
+	}
+	for _, item := range refund.Items {
+		query := "insert into refund_items (refund_id, order_item_id, quantity, amount) values (?, ?, ?, ?)"
+		if _, err := tx.Exec(query, refund.ID, item.OrderItemID, item.Quantity, item.Amount); err != nil {
+			return nil, err
+		}
+	}
+	refund.CreatedAt = time.Now()
+	return refund, nil
+}
+
+// createPaymentRefundTx mở yêu cầu hoàn toàn bộ số tiền của giao dịch p,
+// dùng khi order đã được thanh toán bằng giao dịch khác (khách bị thu hai lần)
+func createPaymentRefundTx(tx *sql.Tx, p *Payment, reason string) (*Refund, error) {
+	refund := &Refund{
+		OrderID:         p.OrderID,
+		PaymentID:       &p.ID,
+		RequesterRole:   RoleSystem,
+		Reason:          reason,
+		Status:          RefundStatusRequested,
+		RequestedAmount: p.Amount,
+		Items:           []RefundItem{},
+	}
+	query := "insert into refunds (order_id, payment_id, requester_role, reason, requested_amount) values (?, ?, ?, ?, ?)"
+	result, err := tx.Exec(query, p.OrderID, p.ID, RoleSystem, reason, p.Amount)
+	if err != nil {
+		return nil, err
+	}
+	if refund.ID, err = result.LastInsertId(); err != nil {
+		return nil, err
+	}
+	refund.CreatedAt = time.Now()
+	return refund, nil
+}
+
+// khoá order trước rồi mới khoá refund (cùng thứ tự với CreateRefund)
+func lockRefundTx(tx *sql.Tx, refundID int64) (*Refund, *orderState, error) {
+	var orderID int64
+	err := tx.QueryRow("select order_id from refunds where id = ?", refundID).Scan(&orderID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil, ErrRefundNotFound
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	st, err := lockOrderTx(tx, orderID)
+	if err != nil {
+		return nil, nil, err
+	}
+	var r Refund
+	if err := scanRefund(tx.QueryRow("select "+refundColumns+" from refunds where id = ? for update", refundID), &r); err != nil {
+		return nil, nil, err
+	}
+	if r.Status != RefundStatusRequested {
+		return nil, nil, ErrRefundAlreadyReviewed
+	}
+	return &r, st, nil
+}
+
+// ApproveRefund duyệt hoàn tiền, amount = nil thì hoàn đúng số tiền được yêu cầu.
+// payment_status của order thành refunded khi đã hoàn đủ tổng tiền, còn lại là partially_refunded.
+// Hoàn giao dịch thu thừa thì tối đa bằng số tiền giao dịch và không đổi payment_status.
+func ApproveRefund(db *sql.DB, refundID int64, actor Actor, amount *float64, note string) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	refund, st, err := lockRefundTx(tx, refundID)
+	if err != nil {
+		return err
+	}
+	approved := refund.RequestedAmount
+	if amount != nil {
+		approved = roundMoney(*amount)
+	}
+	if refund.PaymentID != nil {
+		if approved <= 0 || approved > refund.RequestedAmount {
+			return ErrRefundAmountExceeded
+		}
+		query := "update refunds set status = ?, approved_amount = ?, reviewed_by = ?, review_note = ?, reviewed_at = ? where id = ?"
+		if _, err := tx.Exec(query, RefundStatusApproved, approved, actor.ID, note, time.Now(), refundID); err != nil {
+			return err
+		}
+		return tx.Commit()
+	}
+	refunded, err := refundedAmount(tx, st.ID)
+	if err != nil {
+		return err
+	}
+	remaining := roundMoney(st.TotalAmount - refunded)
+	if approved <= 0 || approved > remaining {
+		return ErrRefundAmountExceeded
+	}
+
+	query := "update refunds set status = ?, approved_amount = ?, reviewed_by = ?, review_note = ?, reviewed_at = ? where id = ?"
+	if _, err := tx.Exec(query, RefundStatusApproved, approved, actor.ID, note, time.Now(), refundID); err != nil {
+		return err
+	}
+
+	paymentStatus := PaymentStatusPartiallyRefunded
+	if approved >= remaining {
+		paymentStatus = PaymentStatusRefunded
+	}
+	if paymentStatus != st.PaymentStatus {
+		if _, err := tx.Exec("update orders set payment_status = ? where id = ?", paymentStatus, st.ID); err != nil {
+			return err
+		}
+		eventNote := fmt.Sprintf("refund #%d approved: %.2f", refundID, approved)
+		if err := AddOrderStatusEventTx(tx, st.ID, "payment_status", st.PaymentStatus, paymentStatus, actor, eventNote); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// RejectRefund từ chối yêu cầu hoàn tiền, order giữ nguyên payment_status
+func RejectRefund(db *sql.DB, refundID int64, actor Actor, note string) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, _, err := lockRefundTx(tx, refundID); err != nil {
+		return err
+	}
+	query := "update refunds set status = ?, reviewed_by = ?, review_note = ?, reviewed_at = ? where id = ?"
+	if _, err := tx.Exec(query, RefundStatusRejected, actor.ID, note, time.Now(), refundID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func getRefundItems(db *sql.DB, refundID int64) ([]RefundItem, error) {
+	query := `select ri.order_item_id, oi.product_id, ri.quantity, ri.amount
+		from refund_items ri join order_items oi on oi.id = ri.order_item_id
+		where ri.refund_id = ? order by ri.id`
+	rows, err := db.Query(query, refundID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := []RefundItem{}
+	for rows.Next() {
+		var item RefundItem
+		if err := rows.Scan(&item.OrderItemID, &item.ProductID, &item.Quantity, &item.Amount); err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+	return items, rows.Err()
+}
+
+func GetRefundByID(db *sql.DB, refundID int64) (*Refund, error) {
+	var r Refund
+	err := scanRefund(db.QueryRow("select "+refundColumns+" from refunds where id = ?", refundID), &r)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrRefundNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if r.Items, err = getRefundItems(db, r.ID); err != nil {
+		return nil, err
+	}
+	return &r, nil
+}
+
+// GetRefundsByOrder lấy các yêu cầu hoàn tiền của order kèm các dòng được hoàn
+func GetRefundsByOrder(db *sql.DB, orderID int64) ([]Refund, error) {
+	rows, err := db.Query("select "+refundColumns+" from refunds where order_id = ? order by id", orderID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	refunds := []Refund{}
+	for rows.Next() {
+		var r Refund
+		if err := scanRefund(rows, &r); err != nil {
+			return nil, err
+		}
+		refunds = append(refunds, r)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	rows.Close()
+
+	for i := range refunds {
+		if refunds[i].Items, err = getRefundItems(db, refunds[i].ID); err != nil {
+			return nil, err
+		}
+	}
+	return refunds, nil
+}
+
+// GetRefunds cho admin, status rỗng thì lấy tất cả
+func GetRefunds(db *sql.DB, status string, page, limit int) ([]Refund, int, error) {
+	offset := (page - 1) * limit
+	var total int
+	err := db.QueryRow("select count(*) from refunds where (? = '' or status = ?)", status, status).Scan(&total)
+	if err != nil {
+		return nil, 0, err
+	}
+	query := "select " + refundColumns + " from refunds where (? = '' or status = ?) order by id desc limit ? offset ?"
+	rows, err := db.Query(query, status, status, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	refunds := []Refund{}
+	for rows.Next() {
+		var r Refund
+		if err := scanRefund(rows, &r); err != nil {
+			return nil, 0, err
+		}
+		refunds = append(refunds, r)
+	}
+	return refunds, total, rows.Err()
+}
@@ -0,0 +1,95 @@
+package models
+
+import (
+	"database/sql"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// ReorderItem là một sản phẩm + bộ option trong order cũ, gộp các dòng giống nhau
+// (vd phần giá sale và giá gốc của cùng một món)
+type ReorderItem struct {
+	ProductID      int64
+	OptionIDs      []int64
+	Note           string
+	Quantity       int64
+	Price          float64 // đơn giá trung bình lúc đặt, đã gồm tiền option
+	MissingOptions bool    // có option đã bị xoá khỏi menu nên không chọn lại được
+}
+
+// PriceChange là sản phẩm có giá hiện tại khác giá lúc đặt order cũ (cùng bộ option)
+type PriceChange struct {
+	ProductID int64   `json:"product_id"`
+	OptionIDs []int64 `json:"option_ids"`
+	Name      string  `json:"name"`
+	OldPrice  float64 `json:"old_price"`
+	NewPrice  float64 `json:"new_price"`
+}
+
+// GetReorderItems lấy các sản phẩm của order theo thứ tự đã đặt, gộp theo sản phẩm và bộ option.
+// Ghi chú khác nhau của các dòng được gộp được nối lại.
+func GetReorderItems(db *sql.DB, orderID int64) ([]ReorderItem, error) {
+	query := `select oi.product_id, oi.quantity, oi.price, coalesce(oi.note, ''),
+		       coalesce(group_concat(oio.option_id order by oio.option_id), ''),
+		       count(oio.id) - count(oio.option_id)
+		from order_items oi
+		left join order_item_options oio on oio.order_item_id = oi.id
+		where oi.order_id = ?
+		group by oi.id
+		order by oi.id`
+	rows, err := db.Query(query, orderID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := []ReorderItem{}
+	index := make(map[string]int)
+	amounts := make(map[string]float64)
+	for rows.Next() {
+		var (
+			productID, quantity, missing int64
+			price                        float64
+			note, optionKey              string
+		)
+		if err := rows.Scan(&productID, &quantity, &price, &note, &optionKey, &missing); err != nil {
+			return nil, err
+		}
+		key := strconv.FormatInt(productID, 10) + "|" + optionKey
+		i, ok := index[key]
+		if !ok {
+			i = len(items)
+			index[key] = i
+			items = append(items, ReorderItem{ProductID: productID, OptionIDs: parseCartOptionKey(optionKey)})
+		}
+		item := &items[i]
+		item.Quantity += quantity
+		item.MissingOptions = item.MissingOptions || missing > 0
+		item.Note = joinNotes(item.Note, note)
+		amounts[key] += price * float64(quantity)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	for key, i := range index {
+		if items[i].Quantity > 0 {
+			items[i].Price = roundMoney(amounts[key] / float64(items[i].Quantity))
+		}
+	}
+	return items, nil
+}
+
+// nối ghi chú khác nhau bằng "; ", cắt bớt cho vừa MaxItemNoteLength
+func joinNotes(current, note string) string {
+	if note == "" || strings.Contains("; "+current+"; ", "; "+note+"; ") {
+		return current
+	}
+	if current != "" {
+		note = current + "; " + note
+	}
+	if utf8.RuneCountInString(note) > MaxItemNoteLength {
+		note = strings.TrimSpace(string([]rune(note)[:MaxItemNoteLength]))
+	}
+	return note
+}
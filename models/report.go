@@ -0,0 +1,211 @@
+package models
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+)
+
+// độ chia của báo cáo doanh thu
+const (
+	GranularityDay   = "day"
+	GranularityWeek  = "week"
+	GranularityMonth = "month"
+)
+
+// giới hạn số cột trong một báo cáo
+const maxReportBuckets = 1000
+
+var ErrInvalidReportRange = errors.New("invalid report range")
+
+// RevenueBucket là doanh thu của một ngày/tuần/tháng theo giờ cửa hàng
+type RevenueBucket struct {
+	Label             string    `json:"label"` // 2026-10-17 || 2026-W42 || 2026-10
+	Start             time.Time `json:"start"`
+	End               time.Time `json:"end"`
+	Orders            int64     `json:"orders"`
+	Gross             float64   `json:"gross"`   // tổng tiền các order đã thanh toán
+	Refunds           float64   `json:"refunds"` // tiền hoàn đã duyệt trong kỳ
+	Net               float64   `json:"net"`
+	AverageOrderValue float64   `json:"average_order_value"`
+}
+
+// RevenueReport là báo cáo doanh thu từ From tới To (không gồm To)
+type RevenueReport struct {
+	From        time.Time       `json:"from"`
+	To          time.Time       `json:"to"`
+	Granularity string          `json:"granularity"`
+	Timezone    string          `json:"timezone"`
+	Buckets     []RevenueBucket `json:"buckets"`
+	Total       RevenueBucket   `json:"total"`
+}
+
+// bucketStart là đầu kỳ chứa t: đầu ngày, thứ Hai đầu tuần (ISO) hoặc ngày 1 của tháng
+func bucketStart(t time.Time, granularity string, loc *time.Location) time.Time {
+	t = t.In(loc)
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, loc)
+	switch granularity {
+	case GranularityWeek:
+		offset := (int(day.Weekday()) + 6) % 7 // thứ Hai = 0
+		return day.AddDate(0, 0, -offset)
+	case GranularityMonth:
+		return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, loc)
+	default:
+		return day
+	}
+}
+
+func nextBucket(start time.Time, granularity string) time.Time {
+	switch granularity {
+	case GranularityWeek:
+		return start.AddDate(0, 0, 7)
+	case GranularityMonth:
+		return start.AddDate(0, 1, 0)
+	default:
+		return start.AddDate(0, 0, 1)
+	}
+}
+
+func bucketLabel(start time.Time, granularity string) string {
+	switch granularity {
+	case GranularityWeek:
+		year, week := start.ISOWeek()
+		return fmt.Sprintf("%d-W%02d", year, week)
+	case GranularityMonth:
+		return start.Format("2006-01")
+	default:
+		return start.Format("2006-01-02")
+	}
+}
+
+// RevenueBuckets tạo đủ các kỳ (kể cả kỳ không có đơn) phủ khoảng [from, to)
+func RevenueBuckets(from, to time.Time, granularity string, loc *time.Location) ([]RevenueBucket, error) {
+	switch granularity {
+	case GranularityDay, GranularityWeek, GranularityMonth:
+	default:
+		return nil, fmt.Errorf("%w: granularity must be day, week or month", ErrInvalidReportRange)
+	}
+	if !to.After(from) {
+		return nil, fmt.Errorf("%w: to must be after from", ErrInvalidReportRange)
+	}
+	buckets := []RevenueBucket{}
+	for start := bucketStart(from, granularity, loc); start.Before(to); start = nextBucket(start, granularity) {
+		if len(buckets) == maxReportBuckets {
+			return nil, fmt.Errorf("%w: too many %s buckets, narrow the range", ErrInvalidReportRange, granularity)
+		}
+		buckets = append(buckets, RevenueBucket{
+			Label: bucketLabel(start, granularity),
+			Start: start,
+			End:   nextBucket(start, granularity),
+		})
+	}
+	return buckets, nil
+}
+
+// cộng số liệu theo giờ (UTC) vào kỳ tương ứng theo giờ cửa hàng
+func addToBuckets(buckets []RevenueBucket, hour time.Time, add func(b *RevenueBucket)) {
+	for i := range buckets {
+		if !hour.Before(buckets[i].Start) && hour.Before(buckets[i].End) {
+			add(&buckets[i])
+			return
+		}
+	}
+}
+
+// số đơn/số tiền trong một giờ (UTC)
+type hourlyTotal struct {
+	hour   time.Time
+	count  int64
+	amount float64
+}
+
+// gom theo từng giờ trong DB rồi chia kỳ ở Go, để không phụ thuộc bảng múi giờ của MySQL.
+// query trả về 3 cột: giờ dạng '%Y-%m-%d %H:00:00', số dòng, tổng tiền.
+// Giờ được đọc là UTC nên session phải chạy time_zone '+00:00' (xem database.InitDB).
+func getHourlyTotals(db *sql.DB, query string, from, to time.Time) ([]hourlyTotal, error) {
+	rows, err := db.Query(query, from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	totals := []hourlyTotal{}
+	for rows.Next() {
+		var h hourlyTotal
+		var hour string
+		if err := rows.Scan(&hour, &h.count, &h.amount); err != nil {
+			return nil, err
+		}
+		if h.hour, err = time.ParseInLocation("2006-01-02 15:04:05", hour, time.UTC); err != nil {
+			return nil, err
+		}
+		totals = append(totals, h)
+	}
+	return totals, rows.Err()
+}
+
+// GetRevenueReport là doanh thu theo kỳ trong [from, to): doanh thu gộp tính theo ngày đặt
+// của các order đã thanh toán (kể cả đã hoàn tiền), tiền hoàn tính theo ngày duyệt refund
+func GetRevenueReport(db *sql.DB, from, to time.Time, granularity string, loc *time.Location) (*RevenueReport, error) {
+	buckets, err := RevenueBuckets(from, to, granularity, loc)
+	if err != nil {
+		return nil, err
+	}
+	// mở rộng khoảng truy vấn cho trọn kỳ đầu/cuối
+	qFrom, qTo := buckets[0].Start, buckets[len(buckets)-1].End
+
+	salesQuery := `select date_format(created_at, '%Y-%m-%d %H:00:00') as hour, count(*), coalesce(sum(total_amount), 0)
+		from orders
+		where payment_status in ('paid', 'partially_refunded', 'refunded') and created_at >= ? and created_at < ?
+		group by hour`
+	sales, err := getHourlyTotals(db, salesQuery, qFrom.UTC(), qTo.UTC())
+	if err != nil {
+		return nil, err
+	}
+	refundQuery := `select date_format(reviewed_at, '%Y-%m-%d %H:00:00') as hour, count(*), coalesce(sum(approved_amount), 0)
+		from refunds
+		where status = 'approved' and payment_id is null and reviewed_at >= ? and reviewed_at < ?
+		group by hour`
+	refunds, err := getHourlyTotals(db, refundQuery, qFrom.UTC(), qTo.UTC())
+	if err != nil {
+		return nil, err
+	}
+
+	for _, h := range sales {
+		addToBuckets(buckets, h.hour, func(b *RevenueBucket) {
+			b.Orders += h.count
+			b.Gross += h.amount
+		})
+	}
+	for _, h := range refunds {
+		addToBuckets(buckets, h.hour, func(b *RevenueBucket) { b.Refunds += h.amount })
+	}
+
+	report := &RevenueReport{
+		From:        qFrom,
+		To:          qTo,
+		Granularity: granularity,
+		Timezone:    loc.String(),
+		Buckets:     buckets,
+		Total:       RevenueBucket{Label: "total", Start: qFrom, End: qTo},
+	}
+	for i := range buckets {
+		finishBucket(&buckets[i])
+		report.Total.Orders += buckets[i].Orders
+		report.Total.Gross += buckets[i].Gross
+		report.Total.Refunds += buckets[i].Refunds
+	}
+	finishBucket(&report.Total)
+	return report, nil
+}
+
+func finishBucket(b *RevenueBucket) {
+	b.Gross = roundMoney(b.Gross)
+	b.Refunds = roundMoney(b.Refunds)
+	b.Net = roundMoney(b.Gross - b.Refunds)
+	if b.Orders > 0 {
+		b.AverageOrderValue = math.Round(b.Gross / float64(b.Orders))
+	}
+}
@@ -0,0 +1,57 @@
+package models
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestRevenueBuckets(t *testing.T) {
+	loc := time.FixedZone("ICT", 7*60*60)
+	from := time.Date(2026, 9, 28, 0, 0, 0, 0, loc) // thứ Hai
+	to := time.Date(2026, 10, 18, 0, 0, 0, 0, loc)
+
+	tests := []struct {
+		granularity string
+		labels      []string
+	}{
+		{GranularityWeek, []string{"2026-W40", "2026-W41", "2026-W42"}},
+		{GranularityMonth, []string{"2026-09", "2026-10"}},
+	}
+	for _, tt := range tests {
+		buckets, err := RevenueBuckets(from, to, tt.granularity, loc)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(buckets) != len(tt.labels) {
+			t.Fatalf("%s: got %d buckets, want %d", tt.granularity, len(buckets), len(tt.labels))
+		}
+		for i, label := range tt.labels {
+			if buckets[i].Label != label {
+				t.Errorf("%s: bucket %d = %s, want %s", tt.granularity, i, buckets[i].Label, label)
+			}
+		}
+	}
+
+	days, err := RevenueBuckets(from, to, GranularityDay, loc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(days) != 20 || days[0].Label != "2026-09-28" || days[19].Label != "2026-10-17" {
+		t.Fatalf("unexpected day buckets: %d, %s .. %s", len(days), days[0].Label, days[len(days)-1].Label)
+	}
+
+	// 23:00 UTC ngày 16 là 06:00 ngày 17 giờ Việt Nam
+	hour := time.Date(2026, 10, 16, 23, 0, 0, 0, time.UTC)
+	addToBuckets(days, hour, func(b *RevenueBucket) { b.Orders++ })
+	if days[19].Orders != 1 || days[18].Orders != 0 {
+		t.Errorf("order at %s was put in the wrong day", hour)
+	}
+
+	if _, err := RevenueBuckets(from, to, "year", loc); !errors.Is(err, ErrInvalidReportRange) {
+		t.Errorf("invalid granularity: got %v", err)
+	}
+	if _, err := RevenueBuckets(to, from, GranularityDay, loc); !errors.Is(err, ErrInvalidReportRange) {
+		t.Errorf("reversed range: got %v", err)
+	}
+}
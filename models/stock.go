@@ -0,0 +1,140 @@
+package models
+
+import (
+	"database/sql"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// lý do một sản phẩm trong đơn bị từ chối
+const (
+	ItemErrNotFound        = "not_found"
+	ItemErrOutOfStock      = "out_of_stock"
+	ItemErrInvalidQuantity = "invalid_quantity"
+	ItemErrOtherStore      = "different_store"
+)
+
+// OrderItemError mô tả lỗi của từng sản phẩm trong đơn
+type OrderItemError struct {
+	ProductID int64  `json:"product_id"`
+	Reason    string `json:"reason"`
+	Requested int64  `json:"requested"`
+	Available int64  `json:"available"`
+	// lỗi chọn option (Reason là một trong OptionErr*)
+	GroupID  int64 `json:"group_id,omitempty"`
+	OptionID int64 `json:"option_id,omitempty"`
+}
+
+// OrderItemsError gom các lỗi theo từng sản phẩm khi tạo order
+type OrderItemsError struct {
+	Items []OrderItemError
+}
+
+func (e *OrderItemsError) Error() string {
+	parts := make([]string, 0, len(e.Items))
+	for _, item := range e.Items {
+		parts = append(parts, fmt.Sprintf("product %d: %s", item.ProductID, item.Reason))
+	}
+	return "invalid order items: " + strings.Join(parts, ", ")
+}
+
+// StockProduct là thông tin sản phẩm cần khi giữ hàng cho order
+type StockProduct struct {
+	ID         int64
+	Name       string
+	Category   string
+	Price      float64
+	QtyInitial int64
+	QtySold    int64
+	StoreID    int64
+}
+
+// Available là số lượng còn có thể bán
+func (p *StockProduct) Available() int64 {
+	if p.QtyInitial < p.QtySold {
+		return 0
+	}
+	return p.QtyInitial - p.QtySold
+}
+
+// queryer dùng chung cho *sql.DB và *sql.Tx
+type queryer interface {
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
+func loadStockProducts(q queryer, productIDs []int64, forUpdate bool) (map[int64]*StockProduct, error) {
+	products := make(map[int64]*StockProduct)
+	if len(productIDs) == 0 {
+		return products, nil
+	}
+	ids := append([]int64(nil), productIDs...)
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+	placeholders := strings.TrimSuffix(strings.Repeat("?,", len(ids)), ",")
+	query := "select id, name, coalesce(category, ''), price, qty_initial, qty_sold, coalesce(store_id, 0) from Products where id in (" + placeholders + ") order by id"
+	if forUpdate {
+		query += " for update"
+	}
+	args := make([]interface{}, len(ids))
+	for i, id := range ids {
+		args[i] = id
+	}
+
+	rows, err := q.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var p StockProduct
+		if err := rows.Scan(&p.ID, &p.Name, &p.Category, &p.Price, &p.QtyInitial, &p.QtySold, &p.StoreID); err != nil {
+			return nil, err
+		}
+		products[p.ID] = &p
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return products, nil
+}
+
+// LockProductsTx đọc và khoá (FOR UPDATE) các sản phẩm theo id,
+// khoá theo thứ tự id tăng dần để tránh deadlock giữa các đơn
+func LockProductsTx(tx *sql.Tx, productIDs []int64) (map[int64]*StockProduct, error) {
+	return loadStockProducts(tx, productIDs, true)
+}
+
+// GetStockProducts đọc giá và tồn kho hiện tại, không khoá (dùng để báo giá)
+func GetStockProducts(db *sql.DB, productIDs []int64) (map[int64]*StockProduct, error) {
+	return loadStockProducts(db, productIDs, false)
+}
+
+// ReserveStockTx tăng qty_sold, chỉ thành công khi còn đủ hàng
+func ReserveStockTx(tx *sql.Tx, productID int64, quantity int64) error {
+	query := "update Products set qty_sold = qty_sold + ? where id = ? and qty_initial - qty_sold >= ?"
+	result, err := tx.Exec(query, quantity, productID, quantity)
+	if err != nil {
+		return err
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return &OrderItemsError{Items: []OrderItemError{{ProductID: productID, Reason: ItemErrOutOfStock, Requested: quantity}}}
+	}
+	return nil
+}
+
+// ReleaseStockTx trả lại số lượng đã giữ cho các sản phẩm của order (khi huỷ đơn)
+func ReleaseStockTx(tx *sql.Tx, orderID int64) error {
+	query := `
+		update Products p
+		join (select product_id, sum(quantity) as qty from order_items where order_id = ? group by product_id) oi
+		  on p.id = oi.product_id
+		set p.qty_sold = greatest(p.qty_sold - oi.qty, 0)`
+	_, err := tx.Exec(query, orderID)
+	return err
+}
@@ -1,11 +1,166 @@
 package models
 
-import "time"
+import (
+	"database/sql"
+	"errors"
+	"time"
+)
+
+var ErrStoreUnavailable = errors.New("store is not accepting orders")
 
 type Store struct {
-	ID        int       `json:"id"`
+	ID        int64     `json:"id"`
 	Name      string    `json:"name"`
-	OwnerID   int       `json:"owner_id"` // liên kết với User
+	OwnerID   int64     `json:"owner_id"` // liên kết với User
 	Address   string    `json:"address"`
+	Phone     string    `json:"phone"`
+	Latitude  float64   `json:"latitude"`
+	Longitude float64   `json:"longitude"`
+	IsActive  bool      `json:"is_active"`
 	CreatedAt time.Time `json:"created_at"`
 }
+
+// NearbyStore là store kèm khoảng cách tới vị trí của khách
+type NearbyStore struct {
+	Store
+	DistanceKm float64 `json:"distance_km"`
+}
+
+const storeColumns = "id, coalesce(owner_id, 0), name, address, coalesce(phone, ''), latitude, longitude, is_active, created_at"
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanStore(row rowScanner, s *Store, extra ...interface{}) error {
+	dest := []interface{}{&s.ID, &s.OwnerID, &s.Name, &s.Address, &s.Phone, &s.Latitude, &s.Longitude, &s.IsActive, &s.CreatedAt}
+	return row.Scan(append(dest, extra...)...)
+}
+
+func CreateStore(db *sql.DB, s *Store) (int64, error) {
+	query := `insert into stores (name, owner_id, address, phone, latitude, longitude, is_active) values (?, ?, ?, ?, ?, ?, ?)`
+	var ownerID sql.NullInt64
+	if s.OwnerID != 0 {
+		ownerID = sql.NullInt64{Int64: s.OwnerID, Valid: true}
+	}
+	result, err := db.Exec(query, s.Name, ownerID, s.Address, s.Phone, s.Latitude, s.Longitude, s.IsActive)
+	if err != nil {
+		return 0, err
+	}
+	return result.LastInsertId()
+}
+
+func UpdateStore(db *sql.DB, s *Store) error {
+	query := `update stores set name = ?, owner_id = ?, address = ?, phone = ?, latitude = ?, longitude = ?, is_active = ? where id = ?`
+	var ownerID sql.NullInt64
+	if s.OwnerID != 0 {
+		ownerID = sql.NullInt64{Int64: s.OwnerID, Valid: true}
+	}
+	result, err := db.Exec(query, s.Name, ownerID, s.Address, s.Phone, s.Latitude, s.Longitude, s.IsActive, s.ID)
+	if err != nil {
+		return err
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		// có thể không có gì thay đổi, kiểm tra store có tồn tại không
+		if _, err := GetStoreByID(db, s.ID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// DeactivateStore tạm ngừng store thay vì xoá để giữ lịch sử order
+func DeactivateStore(db *sql.DB, storeID int64) error {
+	result, err := db.Exec(`update stores set is_active = false where id = ?`, storeID)
+	if err != nil {
+		return err
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		if _, err := GetStoreByID(db, storeID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func GetStoreByID(db *sql.DB, storeID int64) (*Store, error) {
+	var s Store
+	err := scanStore(db.QueryRow("select "+storeColumns+" from stores where id = ?", storeID), &s)
+	if err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+func GetAllStores(db *sql.DB, page, limit int) ([]Store, int, error) {
+	offset := (page - 1) * limit
+	var total int
+	if err := db.QueryRow("select count(*) from stores").Scan(&total); err != nil {
+		return nil, 0, err
+	}
+	rows, err := db.Query("select "+storeColumns+" from stores order by id limit ? offset ?", limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	stores := []Store{}
+	for rows.Next() {
+		var s Store
+		if err := scanStore(rows, &s); err != nil {
+			return nil, 0, err
+		}
+		stores = append(stores, s)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, err
+	}
+	return stores, total, nil
+}
+
+// GetNearbyStores lấy các store đang hoạt động trong bán kính radiusKm, gần nhất trước
+func GetNearbyStores(db *sql.DB, latitude, longitude, radiusKm float64, limit int) ([]NearbyStore, error) {
+	query := `
+		select ` + storeColumns + `, st_distance_sphere(point(longitude, latitude), point(?, ?)) / 1000 as distance_km
+		from stores
+		where is_active = true
+		having distance_km <= ?
+		order by distance_km
+		limit ?`
+	rows, err := db.Query(query, longitude, latitude, radiusKm, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	stores := []NearbyStore{}
+	for rows.Next() {
+		var s NearbyStore
+		if err := scanStore(rows, &s.Store, &s.DistanceKm); err != nil {
+			return nil, err
+		}
+		stores = append(stores, s)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return stores, nil
+}
+
+// GetShipperStoreID lấy store mà shipper thuộc về, 0 nếu shipper chưa gán store
+func GetShipperStoreID(db *sql.DB, shipperID int64) (int64, error) {
+	var storeID sql.NullInt64
+	err := db.QueryRow("select store_id from users where id = ?", shipperID).Scan(&storeID)
+	if err != nil {
+		return 0, err
+	}
+	return storeID.Int64, nil
+}
@@ -19,6 +19,7 @@ type User struct {
 	ResetOTP          *string    `json:"-"`
 	ResetOTPExpiresAt *time.Time `json:"-"`
 	Status            int        `json:"status"`
+	StoreID           int64      `json:"store_id"` // store của shipper, 0 = không thuộc store nào
 }
 
 func GetNumberOfCustomer(db *sql.DB) (int64, error) {
@@ -52,9 +53,13 @@ func CheckEmailExists(db *sql.DB, email string) (bool, error) {
 
 }
 func CreateUserTx(tx *sql.Tx, user *User) (int64, error) {
-	query := "insert into users (name, email, password, phone, address, role, created_at) values (?,?, ?, ?, ?, ?,?)"
+	query := "insert into users (name, email, password, phone, address, role, store_id, created_at) values (?,?, ?, ?, ?, ?, ?,?)"
 	user.CreatedAt = time.Now()
-	result, err := tx.Exec(query, user.Name, user.Email, user.Password, user.Phone, user.Address, user.Role, user.CreatedAt)
+	var storeID sql.NullInt64
+	if user.StoreID != 0 {
+		storeID = sql.NullInt64{Int64: user.StoreID, Valid: true}
+	}
+	result, err := tx.Exec(query, user.Name, user.Email, user.Password, user.Phone, user.Address, user.Role, storeID, user.CreatedAt)
 	if err != nil {
 		return 0, err
 	}
@@ -138,7 +143,7 @@ func GetAllUserWithType(db *sql.DB, role string, page, limit int) ([]User, int,
 	if err != nil {
 		return nil, 0, err
 	}
-	query := "select id, name, email, phone, address, role, status from users where role = ? order by id limit ? offset ?"
+	query := "select id, name, email, phone, address, role, status, coalesce(store_id, 0) from users where role = ? order by id limit ? offset ?"
 	rows, err := db.Query(query, role, limit, offset)
 	if err != nil {
 		return nil, 0, err
@@ -155,6 +160,7 @@ func GetAllUserWithType(db *sql.DB, role string, page, limit int) ([]User, int,
 			&user.Address,
 			&user.Role,
 			&user.Status,
+			&user.StoreID,
 		)
 		if err != nil {
 			return nil, 0, err
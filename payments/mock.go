@@ -0,0 +1,92 @@
+package payments
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sync"
+)
+
+const MockSignatureHeader = "X-Mock-Signature"
+
+// MockProvider là cổng thanh toán giả để chạy local: giao dịch lưu trong bộ nhớ,
+// webhook là JSON ký bằng HMAC-SHA256 (hex) đặt ở header X-Mock-Signature
+type MockProvider struct {
+	secret []byte
+
+	mu       sync.Mutex
+	statuses map[string]string
+}
+
+// body webhook của mock provider
+type MockWebhook struct {
+	ProviderRef string  `json:"provider_ref"`
+	Amount      float64 `json:"amount"`
+	Status      string  `json:"status"`
+}
+
+func NewMockProvider(secret string) *MockProvider {
+	return &MockProvider{secret: []byte(secret), statuses: make(map[string]string)}
+}
+
+func (p *MockProvider) Name() string { return "mock" }
+
+func (p *MockProvider) CreateIntent(ctx context.Context, intent Intent) (*IntentResult, error) {
+	ref := fmt.Sprintf("MOCK%d", intent.PaymentID)
+	p.mu.Lock()
+	p.statuses[ref] = StatusPending
+	p.mu.Unlock()
+
+	payURL := "mock://pay/" + ref
+	if intent.ReturnURL != "" {
+		payURL = intent.ReturnURL + "?" + url.Values{"provider": {p.Name()}, "provider_ref": {ref}}.Encode()
+	}
+	return &IntentResult{ProviderRef: ref, PaymentURL: payURL}, nil
+}
+
+// Sign tính chữ ký cho body webhook (dùng khi tự gửi webhook lúc test)
+func (p *MockProvider) Sign(body []byte) string {
+	mac := hmac.New(sha256.New, p.secret)
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func (p *MockProvider) VerifyWebhook(r *http.Request, body []byte) (*WebhookEvent, error) {
+	got, err := hex.DecodeString(r.Header.Get(MockSignatureHeader))
+	if err != nil || len(p.secret) == 0 {
+		return nil, ErrInvalidSignature
+	}
+	want, _ := hex.DecodeString(p.Sign(body))
+	if !hmac.Equal(got, want) {
+		return nil, ErrInvalidSignature
+	}
+	var hook MockWebhook
+	if err := json.Unmarshal(body, &hook); err != nil {
+		return nil, err
+	}
+	switch hook.Status {
+	case StatusSucceeded, StatusFailed, StatusPending:
+	default:
+		return nil, fmt.Errorf("mock webhook: unknown status %q", hook.Status)
+	}
+
+	p.mu.Lock()
+	p.statuses[hook.ProviderRef] = hook.Status
+	p.mu.Unlock()
+	return &WebhookEvent{ProviderRef: hook.ProviderRef, Amount: hook.Amount, Status: hook.Status, ResultCode: hook.Status}, nil
+}
+
+func (p *MockProvider) QueryStatus(ctx context.Context, providerRef string) (string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	status, ok := p.statuses[providerRef]
+	if !ok {
+		return "", ErrPaymentNotFound
+	}
+	return status, nil
+}
@@ -0,0 +1,230 @@
+package payments
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// MoMoConfig là thông tin merchant do MoMo cấp
+type MoMoConfig struct {
+	PartnerCode string
+	AccessKey   string
+	SecretKey   string
+	Endpoint    string // vd https://test-payment.momo.vn
+	IPNURL      string // URL public của /payments/webhook/momo
+}
+
+// MoMoProvider là cổng thanh toán ví MoMo (API v2 captureWallet): tạo giao dịch để lấy
+// payUrl/deeplink, các request và IPN ký HMAC-SHA256
+type MoMoProvider struct {
+	cfg    MoMoConfig
+	client *http.Client
+	now    func() time.Time
+}
+
+func NewMoMoProvider(cfg MoMoConfig) *MoMoProvider {
+	return &MoMoProvider{
+		cfg:    cfg,
+		client: &http.Client{Timeout: 30 * time.Second},
+		now:    time.Now,
+	}
+}
+
+func (p *MoMoProvider) Name() string { return "momo" }
+
+// momoSign ký rawSignature bằng HMAC-SHA256, trả về hex
+func momoSign(secret, raw string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(raw))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// MoMoStatus đổi resultCode của MoMo sang trạng thái giao dịch: 0 và 9000 (đã xác nhận)
+// là thành công, các mã đang xử lý để pending, còn lại là thất bại
+func MoMoStatus(resultCode int) string {
+	switch resultCode {
+	case 0, 9000:
+		return StatusSucceeded
+	case 1000, 7000, 7002, 8000:
+		return StatusPending
+	default:
+		return StatusFailed
+	}
+}
+
+type momoCreateRequest struct {
+	PartnerCode string `json:"partnerCode"`
+	RequestID   string `json:"requestId"`
+	Amount      int64  `json:"amount"`
+	OrderID     string `json:"orderId"`
+	OrderInfo   string `json:"orderInfo"`
+	RedirectURL string `json:"redirectUrl"`
+	IpnURL      string `json:"ipnUrl"`
+	RequestType string `json:"requestType"`
+	ExtraData   string `json:"extraData"`
+	Lang        string `json:"lang"`
+	Signature   string `json:"signature"`
+}
+
+type momoCreateResponse struct {
+	PartnerCode string `json:"partnerCode"`
+	OrderID     string `json:"orderId"`
+	RequestID   string `json:"requestId"`
+	Amount      int64  `json:"amount"`
+	ResultCode  int    `json:"resultCode"`
+	Message     string `json:"message"`
+	PayURL      string `json:"payUrl"`
+	Deeplink    string `json:"deeplink"`
+	QRCodeURL   string `json:"qrCodeUrl"`
+}
+
+// MoMoIPN là body MoMo gửi về ipnUrl
+type MoMoIPN struct {
+	PartnerCode  string `json:"partnerCode"`
+	OrderID      string `json:"orderId"`
+	RequestID    string `json:"requestId"`
+	Amount       int64  `json:"amount"`
+	OrderInfo    string `json:"orderInfo"`
+	OrderType    string `json:"orderType"`
+	TransID      int64  `json:"transId"`
+	ResultCode   int    `json:"resultCode"`
+	Message      string `json:"message"`
+	PayType      string `json:"payType"`
+	ResponseTime int64  `json:"responseTime"`
+	ExtraData    string `json:"extraData"`
+	Signature    string `json:"signature"`
+}
+
+func (p *MoMoProvider) ipnRawSignature(ipn *MoMoIPN) string {
+	return "accessKey=" + p.cfg.AccessKey +
+		"&amount=" + strconv.FormatInt(ipn.Amount, 10) +
+		"&extraData=" + ipn.ExtraData +
+		"&message=" + ipn.Message +
+		"&orderId=" + ipn.OrderID +
+		"&orderInfo=" + ipn.OrderInfo +
+		"&orderType=" + ipn.OrderType +
+		"&partnerCode=" + ipn.PartnerCode +
+		"&payType=" + ipn.PayType +
+		"&requestId=" + ipn.RequestID +
+		"&responseTime=" + strconv.FormatInt(ipn.ResponseTime, 10) +
+		"&resultCode=" + strconv.Itoa(ipn.ResultCode) +
+		"&transId=" + strconv.FormatInt(ipn.TransID, 10)
+}
+
+// mã đơn gửi sang MoMo = DH<order_id>-<payment_id>, không trùng giữa các lần thanh toán lại
+func momoOrderID(orderID, paymentID int64) string {
+	return fmt.Sprintf("DH%d-%d", orderID, paymentID)
+}
+
+func (p *MoMoProvider) requestID(orderID string) string {
+	return orderID + "-" + strconv.FormatInt(p.now().UnixMilli(), 10)
+}
+
+func (p *MoMoProvider) CreateIntent(ctx context.Context, intent Intent) (*IntentResult, error) {
+	req := momoCreateRequest{
+		PartnerCode: p.cfg.PartnerCode,
+		Amount:      int64(math.Round(intent.Amount)),
+		OrderID:     momoOrderID(intent.OrderID, intent.PaymentID),
+		OrderInfo:   intent.Description,
+		RedirectURL: intent.ReturnURL,
+		IpnURL:      p.cfg.IPNURL,
+		RequestType: "captureWallet",
+		Lang:        "vi",
+	}
+	req.RequestID = p.requestID(req.OrderID)
+	raw := "accessKey=" + p.cfg.AccessKey +
+		"&amount=" + strconv.FormatInt(req.Amount, 10) +
+		"&extraData=" + req.ExtraData +
+		"&ipnUrl=" + req.IpnURL +
+		"&orderId=" + req.OrderID +
+		"&orderInfo=" + req.OrderInfo +
+		"&partnerCode=" + req.PartnerCode +
+		"&redirectUrl=" + req.RedirectURL +
+		"&requestId=" + req.RequestID +
+		"&requestType=" + req.RequestType
+	req.Signature = momoSign(p.cfg.SecretKey, raw)
+
+	var resp momoCreateResponse
+	if err := postJSON(ctx, p.client, p.cfg.Endpoint+"/v2/gateway/api/create", req, &resp); err != nil {
+		return nil, err
+	}
+	if resp.ResultCode != 0 {
+		return nil, fmt.Errorf("momo create: result code %d: %s", resp.ResultCode, resp.Message)
+	}
+	return &IntentResult{ProviderRef: req.OrderID, PaymentURL: resp.PayURL, Deeplink: resp.Deeplink}, nil
+}
+
+// VerifyWebhook kiểm tra IPN của MoMo (JSON trong body)
+func (p *MoMoProvider) VerifyWebhook(r *http.Request, body []byte) (*WebhookEvent, error) {
+	var ipn MoMoIPN
+	if err := json.Unmarshal(body, &ipn); err != nil {
+		return nil, ErrInvalidSignature
+	}
+	got, err := hex.DecodeString(ipn.Signature)
+	if err != nil || len(got) == 0 {
+		return nil, ErrInvalidSignature
+	}
+	want, _ := hex.DecodeString(momoSign(p.cfg.SecretKey, p.ipnRawSignature(&ipn)))
+	if !hmac.Equal(got, want) || ipn.PartnerCode != p.cfg.PartnerCode {
+		return nil, ErrInvalidSignature
+	}
+	return &WebhookEvent{
+		ProviderRef: ipn.OrderID,
+		Amount:      float64(ipn.Amount),
+		Status:      MoMoStatus(ipn.ResultCode),
+		ResultCode:  strconv.Itoa(ipn.ResultCode),
+	}, nil
+}
+
+// WebhookResponse: MoMo chỉ cần HTTP 204 khi đã nhận IPN
+func (p *MoMoProvider) WebhookResponse(err error) (int, any) {
+	switch {
+	case err == nil, errors.Is(err, ErrAlreadyConfirmed):
+		return http.StatusNoContent, nil
+	case errors.Is(err, ErrInvalidSignature):
+		return http.StatusBadRequest, map[string]string{"message": err.Error()}
+	case errors.Is(err, ErrPaymentNotFound):
+		return http.StatusNotFound, map[string]string{"message": err.Error()}
+	case errors.Is(err, ErrAmountMismatch):
+		return http.StatusBadRequest, map[string]string{"message": err.Error()}
+	default:
+		return http.StatusInternalServerError, map[string]string{"message": "Unknown error"}
+	}
+}
+
+// QueryStatus gọi API truy vấn trạng thái giao dịch của MoMo
+func (p *MoMoProvider) QueryStatus(ctx context.Context, providerRef string) (string, error) {
+	req := map[string]string{
+		"partnerCode": p.cfg.PartnerCode,
+		"requestId":   p.requestID(providerRef),
+		"orderId":     providerRef,
+		"lang":        "vi",
+	}
+	raw := "accessKey=" + p.cfg.AccessKey +
+		"&orderId=" + req["orderId"] +
+		"&partnerCode=" + req["partnerCode"] +
+		"&requestId=" + req["requestId"]
+	req["signature"] = momoSign(p.cfg.SecretKey, raw)
+
+	var resp struct {
+		ResultCode int    `json:"resultCode"`
+		Message    string `json:"message"`
+	}
+	if err := postJSON(ctx, p.client, p.cfg.Endpoint+"/v2/gateway/api/query", req, &resp); err != nil {
+		return "", err
+	}
+	if resp.ResultCode == 42 {
+		// không tìm thấy giao dịch
+		return "", ErrPaymentNotFound
+	}
+	return MoMoStatus(resp.ResultCode), nil
+}
@@ -0,0 +1,173 @@
+package payments
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+)
+
+// khoá test công khai trong tài liệu MoMo, fixture trong testdata được ký bằng các khoá này
+func newTestMoMo(endpoint string) *MoMoProvider {
+	p := NewMoMoProvider(MoMoConfig{
+		PartnerCode: "MOMO",
+		AccessKey:   "F8BBA842ECF85",
+		SecretKey:   "K951B6PE1waDMi640xX08PD3vg6EkVlz",
+		Endpoint:    endpoint,
+		IPNURL:      "https://api.example.com/api/v1/payments/webhook/momo",
+	})
+	p.now = func() time.Time { return time.UnixMilli(1792121400000) }
+	return p
+}
+
+func readFixture(t *testing.T, name string) []byte {
+	t.Helper()
+	body, err := os.ReadFile("testdata/" + name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return body
+}
+
+func TestMoMoVerifyWebhook(t *testing.T) {
+	p := newTestMoMo("")
+	tests := []struct {
+		fixture    string
+		status     string
+		resultCode string
+	}{
+		{"momo_ipn_success.json", StatusSucceeded, "0"},
+		{"momo_ipn_cancelled.json", StatusFailed, "1006"},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest(http.MethodPost, "/api/v1/payments/webhook/momo", nil)
+		event, err := p.VerifyWebhook(r, readFixture(t, tt.fixture))
+		if err != nil {
+			t.Fatalf("%s: %v", tt.fixture, err)
+		}
+		if event.ProviderRef != "DH42-12" || event.Amount != 150000 {
+			t.Errorf("%s: got ref %q amount %v", tt.fixture, event.ProviderRef, event.Amount)
+		}
+		if event.Status != tt.status || event.ResultCode != tt.resultCode {
+			t.Errorf("%s: got status %q code %q, want %q %q", tt.fixture, event.Status, event.ResultCode, tt.status, tt.resultCode)
+		}
+	}
+}
+
+func TestMoMoVerifyWebhookRejectsTampered(t *testing.T) {
+	p := newTestMoMo("")
+	r := httptest.NewRequest(http.MethodPost, "/api/v1/payments/webhook/momo", nil)
+
+	body := bytes.Replace(readFixture(t, "momo_ipn_success.json"), []byte(`"amount": 150000`), []byte(`"amount": 1000`), 1)
+	if _, err := p.VerifyWebhook(r, body); !errors.Is(err, ErrInvalidSignature) {
+		t.Fatalf("tampered amount: got %v, want ErrInvalidSignature", err)
+	}
+	if _, err := p.VerifyWebhook(r, []byte("not json")); !errors.Is(err, ErrInvalidSignature) {
+		t.Fatalf("invalid body: got %v, want ErrInvalidSignature", err)
+	}
+}
+
+func TestMoMoCreateIntent(t *testing.T) {
+	var got momoCreateRequest
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v2/gateway/api/create" {
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Error(err)
+		}
+		json.NewEncoder(w).Encode(momoCreateResponse{
+			PartnerCode: got.PartnerCode,
+			OrderID:     got.OrderID,
+			RequestID:   got.RequestID,
+			Amount:      got.Amount,
+			ResultCode:  0,
+			Message:     "Thành công.",
+			PayURL:      "https://test-payment.momo.vn/v2/gateway/pay?t=abc",
+			Deeplink:    "momo://app?action=payWithApp&t=abc",
+		})
+	}))
+	defer srv.Close()
+
+	p := newTestMoMo(srv.URL)
+	result, err := p.CreateIntent(context.Background(), Intent{
+		PaymentID:   12,
+		OrderID:     42,
+		Amount:      150000,
+		Description: "Thanh toan don hang DH42",
+		ReturnURL:   "https://shop.example.com/payment/return",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.ProviderRef != "DH42-12" || result.Deeplink == "" || result.PaymentURL == "" {
+		t.Errorf("unexpected result %+v", result)
+	}
+	if got.RequestID != "DH42-12-1792121400000" || got.Amount != 150000 || got.RequestType != "captureWallet" {
+		t.Errorf("unexpected request %+v", got)
+	}
+	raw := "accessKey=F8BBA842ECF85&amount=150000&extraData=" +
+		"&ipnUrl=https://api.example.com/api/v1/payments/webhook/momo&orderId=DH42-12" +
+		"&orderInfo=Thanh toan don hang DH42&partnerCode=MOMO" +
+		"&redirectUrl=https://shop.example.com/payment/return&requestId=DH42-12-1792121400000&requestType=captureWallet"
+	if want := momoSign("K951B6PE1waDMi640xX08PD3vg6EkVlz", raw); got.Signature != want {
+		t.Errorf("signature = %s, want %s", got.Signature, want)
+	}
+}
+
+func TestMoMoCreateIntentError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(momoCreateResponse{ResultCode: 22, Message: "Số tiền giao dịch không hợp lệ."})
+	}))
+	defer srv.Close()
+
+	p := newTestMoMo(srv.URL)
+	if _, err := p.CreateIntent(context.Background(), Intent{PaymentID: 1, OrderID: 1, Amount: 1}); err == nil {
+		t.Fatal("expected error for result code 22")
+	}
+}
+
+func TestMoMoStatus(t *testing.T) {
+	tests := []struct {
+		resultCode int
+		want       string
+	}{
+		{0, StatusSucceeded},
+		{9000, StatusSucceeded},
+		{1000, StatusPending},
+		{7000, StatusPending},
+		{8000, StatusPending},
+		{1005, StatusFailed},
+		{1006, StatusFailed},
+		{49, StatusFailed},
+	}
+	for _, tt := range tests {
+		if got := MoMoStatus(tt.resultCode); got != tt.want {
+			t.Errorf("MoMoStatus(%d) = %q, want %q", tt.resultCode, got, tt.want)
+		}
+	}
+}
+
+func TestMoMoWebhookResponse(t *testing.T) {
+	p := newTestMoMo("")
+	tests := []struct {
+		err  error
+		want int
+	}{
+		{nil, http.StatusNoContent},
+		{ErrInvalidSignature, http.StatusBadRequest},
+		{ErrPaymentNotFound, http.StatusNotFound},
+		{ErrAmountMismatch, http.StatusBadRequest},
+		{errors.New("db down"), http.StatusInternalServerError},
+	}
+	for _, tt := range tests {
+		if status, _ := p.WebhookResponse(tt.err); status != tt.want {
+			t.Errorf("%v: got HTTP %d, want %d", tt.err, status, tt.want)
+		}
+	}
+}
@@ -0,0 +1,151 @@
+package payments
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"sort"
+	"sync"
+
+	"example.com/delivery-app/config"
+)
+
+// trạng thái giao dịch, giống enum status trong bảng payments
+const (
+	StatusPending   = "pending"
+	StatusSucceeded = "succeeded"
+	StatusFailed    = "failed"
+)
+
+var (
+	ErrInvalidSignature = errors.New("invalid webhook signature")
+	ErrUnknownProvider  = errors.New("unknown payment provider")
+	ErrPaymentNotFound  = errors.New("payment not found")
+	ErrAmountMismatch   = errors.New("paid amount does not match the payment")
+	ErrAlreadyConfirmed = errors.New("payment has already been confirmed")
+)
+
+// secret mẫu từng nằm trong .env, ai cũng biết nên không được dùng để ký webhook
+const defaultMockSecret = "mock-webhook-secret"
+
+// Intent là yêu cầu tạo giao dịch thanh toán cho một order
+type Intent struct {
+	PaymentID   int64 // id trong bảng payments, dùng làm mã tham chiếu gửi sang cổng thanh toán
+	OrderID     int64
+	Amount      float64 // VND
+	Description string
+	ReturnURL   string // trang khách được chuyển về sau khi thanh toán
+	ClientIP    string
+}
+
+// IntentResult là giao dịch đã được tạo ở cổng thanh toán
+type IntentResult struct {
+	ProviderRef string `json:"provider_ref"`
+	PaymentURL  string `json:"payment_url"`        // trang thanh toán để chuyển khách tới
+	Deeplink    string `json:"deeplink,omitempty"` // mở thẳng app ví (MoMo)
+}
+
+// WebhookEvent là kết quả giao dịch cổng thanh toán báo về (đã kiểm tra chữ ký)
+type WebhookEvent struct {
+	ProviderRef string
+	Amount      float64
+	Status      string // succeeded || failed || pending
+	ResultCode  string // mã kết quả gốc của cổng thanh toán
+}
+
+// Provider là một cổng thanh toán
+type Provider interface {
+	Name() string
+	// CreateIntent tạo giao dịch và trả về trang thanh toán
+	CreateIntent(ctx context.Context, intent Intent) (*IntentResult, error)
+	// VerifyWebhook kiểm tra chữ ký của webhook, sai chữ ký trả ErrInvalidSignature.
+	// body là nội dung request đã đọc sẵn.
+	VerifyWebhook(r *http.Request, body []byte) (*WebhookEvent, error)
+	// QueryStatus hỏi trạng thái giao dịch, dùng khi webhook bị lỡ
+	QueryStatus(ctx context.Context, providerRef string) (string, error)
+}
+
+// WebhookResponder cho cổng thanh toán cần body trả về riêng cho webhook
+// (không có thì trả {"message": "ok"}). err là lỗi của VerifyWebhook hoặc
+// ErrPaymentNotFound/ErrAmountMismatch/ErrAlreadyConfirmed khi ghi kết quả.
+type WebhookResponder interface {
+	WebhookResponse(err error) (int, any)
+}
+
+var (
+	mu        sync.RWMutex
+	providers = make(map[string]Provider)
+)
+
+// Register thêm cổng thanh toán, đăng ký lại cùng tên sẽ thay cổng cũ
+func Register(p Provider) {
+	mu.Lock()
+	defer mu.Unlock()
+	providers[p.Name()] = p
+}
+
+// Get lấy cổng thanh toán theo tên
+func Get(name string) (Provider, error) {
+	mu.RLock()
+	defer mu.RUnlock()
+	p, ok := providers[name]
+	if !ok {
+		return nil, ErrUnknownProvider
+	}
+	return p, nil
+}
+
+// Names là tên các cổng thanh toán đang bật
+func Names() []string {
+	mu.RLock()
+	defer mu.RUnlock()
+	names := make([]string, 0, len(providers))
+	for name := range providers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// LoadProviders đăng ký các cổng thanh toán được bật trong config
+func LoadProviders() {
+	cfg := config.Payment
+	if cfg.MockEnabled {
+		if err := checkMockConfig(cfg.MockSecret, config.IsDevelopment()); err != nil {
+			log.Println("⚠️  Không bật cổng thanh toán giả:", err)
+		} else {
+			Register(NewMockProvider(cfg.MockSecret))
+		}
+	}
+	if cfg.VNPayTmnCode != "" {
+		Register(NewVNPayProvider(VNPayConfig{
+			TmnCode:    cfg.VNPayTmnCode,
+			HashSecret: cfg.VNPayHashSecret,
+			PayURL:     cfg.VNPayPayURL,
+			APIURL:     cfg.VNPayAPIURL,
+		}, config.Location))
+	}
+	if cfg.MoMoPartnerCode != "" {
+		Register(NewMoMoProvider(MoMoConfig{
+			PartnerCode: cfg.MoMoPartnerCode,
+			AccessKey:   cfg.MoMoAccessKey,
+			SecretKey:   cfg.MoMoSecretKey,
+			Endpoint:    cfg.MoMoEndpoint,
+			IPNURL:      cfg.MoMoIPNURL,
+		}))
+	}
+}
+
+// cổng giả cho phép tự ký webhook báo đã thanh toán nên chỉ chạy ở môi trường dev với secret riêng
+func checkMockConfig(secret string, development bool) error {
+	switch {
+	case !development:
+		return errors.New("mock provider is only allowed when APP_ENV=development")
+	case secret == "":
+		return errors.New("PAYMENT_MOCK_SECRET is empty")
+	case secret == defaultMockSecret:
+		return errors.New("PAYMENT_MOCK_SECRET must not be the shipped default")
+	}
+	return nil
+}
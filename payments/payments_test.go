@@ -0,0 +1,23 @@
+package payments
+
+import "testing"
+
+func TestCheckMockConfig(t *testing.T) {
+	tests := []struct {
+		name        string
+		secret      string
+		development bool
+		ok          bool
+	}{
+		{"dev with own secret", "local-only-secret", true, true},
+		{"production", "local-only-secret", false, false},
+		{"empty secret", "", true, false},
+		{"shipped default secret", defaultMockSecret, true, false},
+	}
+	for _, tt := range tests {
+		err := checkMockConfig(tt.secret, tt.development)
+		if (err == nil) != tt.ok {
+			t.Errorf("%s: checkMockConfig() error = %v, want ok = %v", tt.name, err, tt.ok)
+		}
+	}
+}
@@ -0,0 +1,210 @@
+package payments
+
+import (
+	"crypto/sha1"
+	"encoding/csv"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/skip2/go-qrcode"
+)
+
+// ProviderBankTransfer là tên provider của các giao dịch chuyển khoản đối soát từ sao kê
+const ProviderBankTransfer = "bank_transfer"
+
+var ErrInvalidStatement = errors.New("invalid bank statement")
+
+// mã định danh dịch vụ chuyển khoản nhanh NAPAS 247 trong chuẩn VietQR
+const (
+	vietQRGUID        = "A000000727"
+	vietQRToAccount   = "QRIBFTTA"
+	vietQRCurrencyVND = "704"
+)
+
+// OrderReference là nội dung chuyển khoản của order, vd DH42
+func OrderReference(orderID int64) string {
+	return "DH" + strconv.FormatInt(orderID, 10)
+}
+
+var orderReferencePattern = regexp.MustCompile(`(?i)\bDH(\d+)\b`)
+
+// ParseOrderReference tìm mã DH<order_id> trong nội dung chuyển khoản. Ngân hàng hay thêm
+// tiền tố/hậu tố và đổi chữ hoa thường nên chỉ cần mã đứng riêng là được.
+func ParseOrderReference(description string) (int64, bool) {
+	m := orderReferencePattern.FindStringSubmatch(description)
+	if m == nil {
+		return 0, false
+	}
+	id, err := strconv.ParseInt(m[1], 10, 64)
+	if err != nil || id <= 0 {
+		return 0, false
+	}
+	return id, true
+}
+
+// một trường TLV của EMVCo: id 2 số, độ dài 2 số, giá trị
+func emvField(id, value string) string {
+	return fmt.Sprintf("%s%02d%s", id, len(value), value)
+}
+
+// crc16 là CRC-16/CCITT-FALSE (poly 0x1021, init 0xFFFF) theo chuẩn EMVCo
+func crc16(data string) uint16 {
+	crc := uint16(0xFFFF)
+	for i := 0; i < len(data); i++ {
+		crc ^= uint16(data[i]) << 8
+		for b := 0; b < 8; b++ {
+			if crc&0x8000 != 0 {
+				crc = crc<<1 ^ 0x1021
+			} else {
+				crc <<= 1
+			}
+		}
+	}
+	return crc
+}
+
+// VietQRPayload tạo chuỗi EMVCo/VietQR động (có số tiền) để app ngân hàng quét chuyển khoản
+// tới tài khoản bankBIN/accountNo với số tiền amount (VND) và nội dung reference
+func VietQRPayload(bankBIN, accountNo string, amount float64, reference string) string {
+	beneficiary := emvField("00", bankBIN) + emvField("01", accountNo)
+	merchant := emvField("00", vietQRGUID) + emvField("01", beneficiary) + emvField("02", vietQRToAccount)
+
+	payload := emvField("00", "01") + // payload format indicator
+		emvField("01", "12") + // QR động, dùng một lần
+		emvField("38", merchant) +
+		emvField("53", vietQRCurrencyVND) +
+		emvField("54", strconv.FormatInt(int64(math.Round(amount)), 10)) +
+		emvField("58", "VN") +
+		emvField("62", emvField("08", reference)) + // mục đích giao dịch = nội dung chuyển khoản
+		"6304"
+	return payload + fmt.Sprintf("%04X", crc16(payload))
+}
+
+// VietQRPNG vẽ payload thành ảnh QR PNG
+func VietQRPNG(payload string, size int) ([]byte, error) {
+	return qrcode.Encode(payload, qrcode.Medium, size)
+}
+
+// BankTransaction là một dòng tiền vào trong sao kê ngân hàng
+type BankTransaction struct {
+	Line          int     `json:"line"`
+	TransactionID string  `json:"transaction_id"`
+	Date          string  `json:"date,omitempty"`
+	Amount        float64 `json:"amount"`
+	Description   string  `json:"description"`
+}
+
+// tên cột chấp nhận trong file sao kê (không phân biệt hoa thường)
+var statementColumns = map[string][]string{
+	"transaction_id": {"transaction_id", "transaction id", "reference", "ref", "ma giao dich"},
+	"date":           {"date", "transaction_date", "ngay giao dich"},
+	"amount":         {"amount", "credit", "so tien"},
+	"description":    {"description", "content", "remark", "noi dung"},
+}
+
+// ParseBankStatement đọc sao kê CSV có dòng tiêu đề, cần ít nhất cột amount và description.
+// Các dòng tiền ra (số âm) hoặc bằng 0 được bỏ qua. Dòng không có mã giao dịch thì lấy
+// hash của ngày, số tiền, nội dung làm mã để tải lại cùng file không ghi trùng.
+func ParseBankStatement(r io.Reader) ([]BankTransaction, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidStatement, err)
+	}
+	cols := map[string]int{}
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))
+		for key, aliases := range statementColumns {
+			for _, alias := range aliases {
+				if _, found := cols[key]; !found && name == alias {
+					cols[key] = i
+				}
+			}
+		}
+	}
+	if _, ok := cols["amount"]; !ok {
+		return nil, fmt.Errorf("%w: missing amount column", ErrInvalidStatement)
+	}
+	if _, ok := cols["description"]; !ok {
+		return nil, fmt.Errorf("%w: missing description column", ErrInvalidStatement)
+	}
+	field := func(record []string, key string) string {
+		i, ok := cols[key]
+		if !ok || i >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[i])
+	}
+
+	transactions := []BankTransaction{}
+	for line := 2; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: line %d: %v", ErrInvalidStatement, line, err)
+		}
+		amount, err := parseStatementAmount(field(record, "amount"))
+		if err != nil {
+			return nil, fmt.Errorf("%w: line %d: %v", ErrInvalidStatement, line, err)
+		}
+		if amount <= 0 {
+			continue
+		}
+		tx := BankTransaction{
+			Line:          line,
+			TransactionID: field(record, "transaction_id"),
+			Date:          field(record, "date"),
+			Amount:        amount,
+			Description:   field(record, "description"),
+		}
+		if tx.TransactionID == "" {
+			sum := sha1.Sum([]byte(tx.Date + "|" + strconv.FormatFloat(amount, 'f', 0, 64) + "|" + tx.Description))
+			tx.TransactionID = "h" + hex.EncodeToString(sum[:])
+		}
+		transactions = append(transactions, tx)
+	}
+	return transactions, nil
+}
+
+// số tiền VND trong sao kê có thể có dấu phân cách hàng nghìn (150,000 hoặc 150.000),
+// phần lẻ .00 và hậu tố VND, bỏ phần lẻ rồi chỉ giữ chữ số
+func parseStatementAmount(s string) (float64, error) {
+	if s == "" {
+		return 0, nil
+	}
+	negative := strings.HasPrefix(s, "-")
+	// nhóm hàng nghìn luôn đủ 3 số, sau dấu phân cách cuối chỉ có 1-2 số là phần lẻ
+	if i := strings.LastIndexAny(s, ".,"); i >= 0 {
+		if frac := strings.TrimSpace(strings.TrimSuffix(strings.ToUpper(s[i+1:]), "VND")); len(frac) == 1 || len(frac) == 2 {
+			s = s[:i]
+		}
+	}
+	digits := strings.Map(func(r rune) rune {
+		if r >= '0' && r <= '9' {
+			return r
+		}
+		return -1
+	}, s)
+	if digits == "" {
+		return 0, fmt.Errorf("invalid amount %q", s)
+	}
+	amount, err := strconv.ParseFloat(digits, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid amount %q", s)
+	}
+	if negative {
+		amount = -amount
+	}
+	return amount, nil
+}
@@ -0,0 +1,85 @@
+package payments
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestCRC16(t *testing.T) {
+	// giá trị kiểm tra chuẩn của CRC-16/CCITT-FALSE
+	if got := crc16("123456789"); got != 0x29B1 {
+		t.Fatalf("crc16 = %04X, want 29B1", got)
+	}
+}
+
+func TestVietQRPayload(t *testing.T) {
+	payload := VietQRPayload("970436", "0011001234567", 150000, "DH42")
+	want := "000201" + "010212" +
+		"3857" + "0010A000000727" + "0127" + "0006970436" + "01130011001234567" + "0208QRIBFTTA" +
+		"5303704" + "5406150000" + "5802VN" + "6208" + "0804DH42" +
+		"6304526B" // CRC16 tính trên toàn bộ chuỗi kể cả "6304"
+	if payload != want {
+		t.Fatalf("payload = %s\nwant      %s", payload, want)
+	}
+	png, err := VietQRPNG(payload, 256)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.HasPrefix(png, []byte("\x89PNG")) {
+		t.Fatal("VietQRPNG did not return a PNG image")
+	}
+}
+
+func TestParseOrderReference(t *testing.T) {
+	tests := []struct {
+		description string
+		want        int64
+		ok          bool
+	}{
+		{"DH42", 42, true},
+		{"MBVCB.5521.DH42.Nguyen Van A chuyen tien", 42, true},
+		{"dh7 thanh toan", 7, true},
+		{"CHUYEN TIEN DH0", 0, false},
+		{"ADH42", 0, false},
+		{"chuyen tien an trua", 0, false},
+	}
+	for _, tt := range tests {
+		got, ok := ParseOrderReference(tt.description)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("ParseOrderReference(%q) = %d, %v; want %d, %v", tt.description, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestParseBankStatement(t *testing.T) {
+	transactions, err := ParseBankStatement(bytes.NewReader(readFixture(t, "bank_statement.csv")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// dòng tiền ra (-50,000) bị bỏ qua
+	if len(transactions) != 3 {
+		t.Fatalf("got %d transactions, want 3", len(transactions))
+	}
+	first := transactions[0]
+	if first.Line != 2 || first.TransactionID != "FT26290123456" || first.Amount != 150000 {
+		t.Errorf("unexpected first transaction %+v", first)
+	}
+	// không có mã giao dịch thì lấy hash, đọc lại cùng file ra cùng mã
+	second := transactions[1]
+	if second.Amount != 89000 || !strings.HasPrefix(second.TransactionID, "h") {
+		t.Errorf("unexpected second transaction %+v", second)
+	}
+	again, _ := ParseBankStatement(bytes.NewReader(readFixture(t, "bank_statement.csv")))
+	if again[1].TransactionID != second.TransactionID {
+		t.Errorf("generated transaction id is not stable")
+	}
+	if transactions[2].Amount != 200000 {
+		t.Errorf("amount with decimals = %v, want 200000", transactions[2].Amount)
+	}
+
+	if _, err := ParseBankStatement(strings.NewReader("date,note\n1,2\n")); !errors.Is(err, ErrInvalidStatement) {
+		t.Errorf("missing columns: got %v, want ErrInvalidStatement", err)
+	}
+}
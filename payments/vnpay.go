@@ -0,0 +1,248 @@
+package payments
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha512"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// định dạng thời gian của VNPay (giờ Việt Nam)
+const vnpayTimeLayout = "20060102150405"
+
+// VNPayConfig là thông tin merchant do VNPay cấp
+type VNPayConfig struct {
+	TmnCode    string
+	HashSecret string
+	PayURL     string // trang thanh toán, vd https://sandbox.vnpayment.vn/paymentv2/vpcpay.html
+	APIURL     string // API truy vấn giao dịch (querydr)
+}
+
+// VNPayProvider là cổng thanh toán VNPay (API v2.1.0): chuyển khách sang trang thanh toán
+// có ký HMAC-SHA512, VNPay gọi IPN (GET) về POST|GET /payments/webhook/vnpay
+type VNPayProvider struct {
+	cfg    VNPayConfig
+	client *http.Client
+	loc    *time.Location
+	now    func() time.Time
+}
+
+func NewVNPayProvider(cfg VNPayConfig, loc *time.Location) *VNPayProvider {
+	return &VNPayProvider{
+		cfg:    cfg,
+		client: &http.Client{Timeout: 15 * time.Second},
+		loc:    loc,
+		now:    time.Now,
+	}
+}
+
+func (p *VNPayProvider) Name() string { return "vnpay" }
+
+// vnpaySign ký data bằng HMAC-SHA512, trả về hex
+func vnpaySign(secret, data string) string {
+	mac := hmac.New(sha512.New, []byte(secret))
+	mac.Write([]byte(data))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// chuỗi cần ký: các tham số vnp_* (trừ chữ ký) sắp xếp theo tên, urlencode giống khi gửi đi
+func vnpayHashData(params url.Values) string {
+	data := url.Values{}
+	for k, v := range params {
+		if k == "vnp_SecureHash" || k == "vnp_SecureHashType" || !strings.HasPrefix(k, "vnp_") || len(v) == 0 || v[0] == "" {
+			continue
+		}
+		data.Set(k, v[0])
+	}
+	return data.Encode()
+}
+
+// mã giao dịch = thời điểm tạo (14 số) + id trong bảng payments,
+// để lúc truy vấn lấy lại được vnp_TransactionDate
+func vnpayTxnRef(createdAt time.Time, paymentID int64) string {
+	return createdAt.Format(vnpayTimeLayout) + strconv.FormatInt(paymentID, 10)
+}
+
+// VNPayStatus đổi vnp_ResponseCode và vnp_TransactionStatus sang trạng thái giao dịch.
+// 00/00 là thành công, 07 (trừ tiền thành công nhưng bị nghi ngờ) và 01 (chưa hoàn tất)
+// để pending chờ đối soát, còn lại là thất bại.
+func VNPayStatus(responseCode, transactionStatus string) string {
+	if transactionStatus == "" {
+		transactionStatus = responseCode
+	}
+	switch {
+	case responseCode == "00" && transactionStatus == "00":
+		return StatusSucceeded
+	case responseCode == "07" || transactionStatus == "07" || transactionStatus == "01":
+		return StatusPending
+	default:
+		return StatusFailed
+	}
+}
+
+func (p *VNPayProvider) CreateIntent(ctx context.Context, intent Intent) (*IntentResult, error) {
+	now := p.now().In(p.loc)
+	ipAddr := intent.ClientIP
+	if ipAddr == "" {
+		ipAddr = "127.0.0.1"
+	}
+	txnRef := vnpayTxnRef(now, intent.PaymentID)
+	params := url.Values{}
+	params.Set("vnp_Version", "2.1.0")
+	params.Set("vnp_Command", "pay")
+	params.Set("vnp_TmnCode", p.cfg.TmnCode)
+	params.Set("vnp_Amount", strconv.FormatInt(int64(math.Round(intent.Amount*100)), 10)) // VNPay nhận số tiền x100
+	params.Set("vnp_CurrCode", "VND")
+	params.Set("vnp_TxnRef", txnRef)
+	params.Set("vnp_OrderInfo", intent.Description)
+	params.Set("vnp_OrderType", "other")
+	params.Set("vnp_Locale", "vn")
+	params.Set("vnp_ReturnUrl", intent.ReturnURL)
+	params.Set("vnp_IpAddr", ipAddr)
+	params.Set("vnp_CreateDate", now.Format(vnpayTimeLayout))
+	params.Set("vnp_ExpireDate", now.Add(15*time.Minute).Format(vnpayTimeLayout))
+
+	query := vnpayHashData(params)
+	payURL := p.cfg.PayURL + "?" + query + "&vnp_SecureHash=" + vnpaySign(p.cfg.HashSecret, query)
+	return &IntentResult{ProviderRef: txnRef, PaymentURL: payURL}, nil
+}
+
+// VerifyWebhook kiểm tra IPN của VNPay, tham số nằm trên query string
+func (p *VNPayProvider) VerifyWebhook(r *http.Request, body []byte) (*WebhookEvent, error) {
+	params := r.URL.Query()
+	got, err := hex.DecodeString(params.Get("vnp_SecureHash"))
+	if err != nil || len(got) == 0 {
+		return nil, ErrInvalidSignature
+	}
+	want, _ := hex.DecodeString(vnpaySign(p.cfg.HashSecret, vnpayHashData(params)))
+	if !hmac.Equal(got, want) || params.Get("vnp_TmnCode") != p.cfg.TmnCode {
+		return nil, ErrInvalidSignature
+	}
+	amount, err := strconv.ParseInt(params.Get("vnp_Amount"), 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("vnpay: invalid vnp_Amount: %w", err)
+	}
+	responseCode := params.Get("vnp_ResponseCode")
+	return &WebhookEvent{
+		ProviderRef: params.Get("vnp_TxnRef"),
+		Amount:      float64(amount) / 100,
+		Status:      VNPayStatus(responseCode, params.Get("vnp_TransactionStatus")),
+		ResultCode:  responseCode,
+	}, nil
+}
+
+// WebhookResponse trả đúng định dạng VNPay yêu cầu cho IPN, luôn là HTTP 200
+func (p *VNPayProvider) WebhookResponse(err error) (int, any) {
+	code, message := "00", "Confirm Success"
+	switch {
+	case err == nil:
+	case errors.Is(err, ErrInvalidSignature):
+		code, message = "97", "Invalid Checksum"
+	case errors.Is(err, ErrPaymentNotFound):
+		code, message = "01", "Order not found"
+	case errors.Is(err, ErrAlreadyConfirmed):
+		code, message = "02", "Order already confirmed"
+	case errors.Is(err, ErrAmountMismatch):
+		code, message = "04", "Invalid amount"
+	default:
+		code, message = "99", "Unknown error"
+	}
+	return http.StatusOK, map[string]string{"RspCode": code, "Message": message}
+}
+
+// QueryStatus gọi API querydr của VNPay
+func (p *VNPayProvider) QueryStatus(ctx context.Context, providerRef string) (string, error) {
+	if len(providerRef) <= len(vnpayTimeLayout) {
+		return "", ErrPaymentNotFound
+	}
+	now := p.now().In(p.loc)
+	req := map[string]string{
+		"vnp_RequestId":       now.Format(vnpayTimeLayout) + providerRef[len(vnpayTimeLayout):],
+		"vnp_Version":         "2.1.0",
+		"vnp_Command":         "querydr",
+		"vnp_TmnCode":         p.cfg.TmnCode,
+		"vnp_TxnRef":          providerRef,
+		"vnp_OrderInfo":       "Truy van giao dich " + providerRef,
+		"vnp_TransactionDate": providerRef[:len(vnpayTimeLayout)],
+		"vnp_CreateDate":      now.Format(vnpayTimeLayout),
+		"vnp_IpAddr":          "127.0.0.1",
+	}
+	req["vnp_SecureHash"] = vnpaySign(p.cfg.HashSecret, joinFields(req,
+		"vnp_RequestId", "vnp_Version", "vnp_Command", "vnp_TmnCode", "vnp_TxnRef",
+		"vnp_TransactionDate", "vnp_CreateDate", "vnp_IpAddr", "vnp_OrderInfo"))
+
+	var raw map[string]any
+	if err := postJSON(ctx, p.client, p.cfg.APIURL, req, &raw); err != nil {
+		return "", err
+	}
+	// các trường trả về có thể là chuỗi hoặc số, đổi hết về chuỗi (số giữ nguyên dạng gốc)
+	resp := make(map[string]string, len(raw))
+	for k, v := range raw {
+		if v != nil {
+			resp[k] = fmt.Sprint(v)
+		}
+	}
+	got, err := hex.DecodeString(resp["vnp_SecureHash"])
+	if err != nil {
+		return "", ErrInvalidSignature
+	}
+	want, _ := hex.DecodeString(vnpaySign(p.cfg.HashSecret, joinFields(resp,
+		"vnp_ResponseId", "vnp_Command", "vnp_ResponseCode", "vnp_Message", "vnp_TmnCode", "vnp_TxnRef",
+		"vnp_Amount", "vnp_BankCode", "vnp_PayDate", "vnp_TransactionNo", "vnp_TransactionType",
+		"vnp_TransactionStatus", "vnp_OrderInfo", "vnp_PromotionCode", "vnp_PromotionAmount")))
+	if !hmac.Equal(got, want) {
+		return "", ErrInvalidSignature
+	}
+	switch resp["vnp_ResponseCode"] {
+	case "00":
+		return VNPayStatus("00", resp["vnp_TransactionStatus"]), nil
+	case "91":
+		// không tìm thấy giao dịch
+		return "", ErrPaymentNotFound
+	default:
+		return "", fmt.Errorf("vnpay querydr: response code %s: %s", resp["vnp_ResponseCode"], resp["vnp_Message"])
+	}
+}
+
+// nối giá trị các trường theo thứ tự bằng dấu |
+func joinFields(m map[string]string, keys ...string) string {
+	values := make([]string, len(keys))
+	for i, k := range keys {
+		values[i] = m[k]
+	}
+	return strings.Join(values, "|")
+}
+
+// gửi JSON và đọc response JSON vào out (số được đọc dạng json.Number nếu out là map)
+func postJSON(ctx context.Context, client *http.Client, endpoint string, payload, out any) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s: unexpected status %d", endpoint, resp.StatusCode)
+	}
+	dec := json.NewDecoder(resp.Body)
+	dec.UseNumber()
+	return dec.Decode(out)
+}
@@ -0,0 +1,164 @@
+package payments
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fixture trong testdata có các tham số theo định dạng IPN của VNPay nhưng là dữ liệu tự tạo,
+// ký bằng secret giả dưới đây (không phải mẫu hay secret do VNPay công bố)
+func newTestVNPay(t *testing.T) *VNPayProvider {
+	t.Helper()
+	loc, err := time.LoadLocation("Asia/Ho_Chi_Minh")
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := NewVNPayProvider(VNPayConfig{
+		TmnCode:    "DEMOTMN1",
+		HashSecret: "VNPAYTESTSECRET0123456789ABCDEF",
+		PayURL:     "https://sandbox.vnpayment.vn/paymentv2/vpcpay.html",
+	}, loc)
+	p.now = func() time.Time { return time.Date(2026, 10, 17, 10, 30, 0, 0, loc) }
+	return p
+}
+
+func vnpayIPNRequest(t *testing.T, fixture string) *http.Request {
+	t.Helper()
+	query, err := os.ReadFile("testdata/" + fixture)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return httptest.NewRequest(http.MethodGet, "/api/v1/payments/webhook/vnpay?"+strings.TrimSpace(string(query)), nil)
+}
+
+func TestVNPayVerifyWebhook(t *testing.T) {
+	p := newTestVNPay(t)
+	tests := []struct {
+		fixture    string
+		status     string
+		resultCode string
+	}{
+		{"vnpay_ipn_success.txt", StatusSucceeded, "00"},
+		{"vnpay_ipn_cancelled.txt", StatusFailed, "24"},
+	}
+	for _, tt := range tests {
+		event, err := p.VerifyWebhook(vnpayIPNRequest(t, tt.fixture), nil)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.fixture, err)
+		}
+		if event.ProviderRef != "2026101710300012" || event.Amount != 150000 {
+			t.Errorf("%s: got ref %q amount %v", tt.fixture, event.ProviderRef, event.Amount)
+		}
+		if event.Status != tt.status || event.ResultCode != tt.resultCode {
+			t.Errorf("%s: got status %q code %q, want %q %q", tt.fixture, event.Status, event.ResultCode, tt.status, tt.resultCode)
+		}
+	}
+}
+
+func TestVNPayVerifyWebhookRejectsTampered(t *testing.T) {
+	p := newTestVNPay(t)
+	r := vnpayIPNRequest(t, "vnpay_ipn_success.txt")
+	r.URL.RawQuery = strings.Replace(r.URL.RawQuery, "vnp_Amount=15000000", "vnp_Amount=100", 1)
+	if _, err := p.VerifyWebhook(r, nil); !errors.Is(err, ErrInvalidSignature) {
+		t.Fatalf("tampered amount: got %v, want ErrInvalidSignature", err)
+	}
+
+	// chữ ký đúng nhưng của merchant khác
+	other := newTestVNPay(t)
+	other.cfg.TmnCode = "OTHERTMN"
+	if _, err := other.VerifyWebhook(vnpayIPNRequest(t, "vnpay_ipn_success.txt"), nil); !errors.Is(err, ErrInvalidSignature) {
+		t.Fatalf("wrong tmn code: got %v, want ErrInvalidSignature", err)
+	}
+}
+
+func TestVNPayCreateIntent(t *testing.T) {
+	p := newTestVNPay(t)
+	result, err := p.CreateIntent(context.Background(), Intent{
+		PaymentID:   12,
+		OrderID:     42,
+		Amount:      150000,
+		Description: "Thanh toan don hang DH42",
+		ReturnURL:   "https://shop.example.com/payment/return",
+		ClientIP:    "10.0.0.5",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.ProviderRef != "2026101710300012" {
+		t.Errorf("got ref %q", result.ProviderRef)
+	}
+	u, err := url.Parse(result.PaymentURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	params := u.Query()
+	want := map[string]string{
+		"vnp_Amount":     "15000000",
+		"vnp_Command":    "pay",
+		"vnp_CreateDate": "20261017103000",
+		"vnp_ExpireDate": "20261017104500",
+		"vnp_IpAddr":     "10.0.0.5",
+		"vnp_TmnCode":    "DEMOTMN1",
+		"vnp_TxnRef":     "2026101710300012",
+		"vnp_Version":    "2.1.0",
+	}
+	for k, v := range want {
+		if params.Get(k) != v {
+			t.Errorf("%s = %q, want %q", k, params.Get(k), v)
+		}
+	}
+	// URL tạo ra phải tự kiểm tra lại được bằng cùng thuật toán với IPN
+	if got := vnpaySign(p.cfg.HashSecret, vnpayHashData(params)); got != params.Get("vnp_SecureHash") {
+		t.Errorf("secure hash mismatch: got %s", params.Get("vnp_SecureHash"))
+	}
+}
+
+func TestVNPayStatus(t *testing.T) {
+	tests := []struct {
+		responseCode, transactionStatus, want string
+	}{
+		{"00", "00", StatusSucceeded},
+		{"00", "", StatusSucceeded},
+		{"07", "00", StatusPending},
+		{"00", "01", StatusPending},
+		{"24", "02", StatusFailed},
+		{"51", "", StatusFailed},
+		{"99", "02", StatusFailed},
+	}
+	for _, tt := range tests {
+		if got := VNPayStatus(tt.responseCode, tt.transactionStatus); got != tt.want {
+			t.Errorf("VNPayStatus(%q, %q) = %q, want %q", tt.responseCode, tt.transactionStatus, got, tt.want)
+		}
+	}
+}
+
+func TestVNPayWebhookResponse(t *testing.T) {
+	p := newTestVNPay(t)
+	tests := []struct {
+		err  error
+		want string
+	}{
+		{nil, "00"},
+		{ErrInvalidSignature, "97"},
+		{ErrPaymentNotFound, "01"},
+		{ErrAlreadyConfirmed, "02"},
+		{ErrAmountMismatch, "04"},
+		{errors.New("db down"), "99"},
+	}
+	for _, tt := range tests {
+		status, body := p.WebhookResponse(tt.err)
+		if status != http.StatusOK {
+			t.Errorf("%v: got HTTP %d", tt.err, status)
+		}
+		if got := body.(map[string]string)["RspCode"]; got != tt.want {
+			t.Errorf("%v: got RspCode %q, want %q", tt.err, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package pricing
+
+import (
+	"math"
+
+	"example.com/delivery-app/config"
+)
+
+const earthRadiusKm = 6371.0
+
+// làm tròn phí giao hàng lên bội số của 1000 VND
+const feeRoundTo = 1000
+
+type Location struct {
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+}
+
+// FeeSchedule là biểu phí giao hàng
+type FeeSchedule struct {
+	BaseFee               float64 `json:"base_fee"`
+	PerKmFee              float64 `json:"per_km_fee"`
+	FreeDeliveryThreshold float64 `json:"free_delivery_threshold"` // 0 = không miễn phí
+}
+
+// Breakdown là cách tính tổng tiền của một order
+type Breakdown struct {
+	Subtotal    float64 `json:"subtotal"`
+	DeliveryFee float64 `json:"delivery_fee"`
+	Discount    float64 `json:"discount"`
+	Total       float64 `json:"total"`
+	DistanceKm  float64 `json:"distance_km"`
+}
+
+// CurrentSchedule lấy biểu phí từ config
+func CurrentSchedule() FeeSchedule {
+	return FeeSchedule{
+		BaseFee:               config.Delivery.BaseFee,
+		PerKmFee:              config.Delivery.PerKmFee,
+		FreeDeliveryThreshold: config.Delivery.FreeDeliveryThreshold,
+	}
+}
+
+// StoreLocation là vị trí cửa hàng mặc định trong config
+func StoreLocation() Location {
+	return Location{Latitude: config.Delivery.StoreLatitude, Longitude: config.Delivery.StoreLongitude}
+}
+
+// DistanceKm tính khoảng cách đường chim bay (haversine) giữa 2 điểm
+func DistanceKm(a, b Location) float64 {
+	toRad := func(deg float64) float64 { return deg * math.Pi / 180 }
+	dLat := toRad(b.Latitude - a.Latitude)
+	dLon := toRad(b.Longitude - a.Longitude)
+	h := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(toRad(a.Latitude))*math.Cos(toRad(b.Latitude))*math.Sin(dLon/2)*math.Sin(dLon/2)
+	return 2 * earthRadiusKm * math.Asin(math.Sqrt(h))
+}
+
+// DeliveryFee = phí cơ bản + phí theo km, miễn phí khi subtotal đạt ngưỡng
+func (s FeeSchedule) DeliveryFee(subtotal, distanceKm float64) float64 {
+	if s.FreeDeliveryThreshold > 0 && subtotal >= s.FreeDeliveryThreshold {
+		return 0
+	}
+	fee := s.BaseFee + s.PerKmFee*distanceKm
+	return math.Ceil(fee/feeRoundTo) * feeRoundTo
+}
+
+// Quote tính breakdown cho order giao từ store tới dest
+func Quote(schedule FeeSchedule, store, dest Location, subtotal, discount float64) Breakdown {
+	distance := DistanceKm(store, dest)
+	fee := schedule.DeliveryFee(subtotal, distance)
+	if discount > subtotal {
+		discount = subtotal
+	}
+	return Breakdown{
+		Subtotal:    subtotal,
+		DeliveryFee: fee,
+		Discount:    discount,
+		Total:       subtotal - discount + fee,
+		DistanceKm:  math.Round(distance*100) / 100,
+	}
+}
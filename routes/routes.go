@@ -9,12 +9,10 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
-func SetupRoutes(r *gin.Engine, db *sql.DB, cld *cloudinary.Cloudinary) {
-	var Hub = websocket.NewHub(db)
-	go Hub.Run()
+func SetupRoutes(r *gin.Engine, db *sql.DB, cld *cloudinary.Cloudinary, hub *websocket.Hub) {
 	api := r.Group("/api/v1")
 	api.GET("/ws", func(c *gin.Context) {
-		websocket.ServeWs(Hub, c)
+		websocket.ServeWs(hub, c)
 	})
 	// User routes
 	api.POST("/signup", func(c *gin.Context) {
@@ -40,6 +38,9 @@ func SetupRoutes(r *gin.Engine, db *sql.DB, cld *cloudinary.Cloudinary) {
 		products.GET("/:id", func(c *gin.Context) {
 			handlers.GetProductByIDHandler(c, db)
 		})
+		products.GET("/:id/options", func(c *gin.Context) {
+			handlers.GetProductOptionsHandler(c, db)
+		})
 		products.GET("/:id/reviews", func(c *gin.Context) {
 			handlers.GetReviewsByProductIDHandler(c, db)
 		})
@@ -48,6 +49,27 @@ func SetupRoutes(r *gin.Engine, db *sql.DB, cld *cloudinary.Cloudinary) {
 		})
 	}
 
+	stores := api.Group("/stores")
+	{
+		stores.GET("/nearby", func(c *gin.Context) {
+			handlers.GetNearbyStoresHandler(c, db)
+		})
+		stores.GET("/:id", func(c *gin.Context) {
+			handlers.GetStoreByIDHandler(c, db)
+		})
+		stores.GET("/:id/delivery-slots", func(c *gin.Context) {
+			handlers.GetStoreDeliverySlotsHandler(c, db)
+		})
+	}
+
+	// cổng thanh toán gọi về, xác thực bằng chữ ký (IPN của VNPay là GET)
+	api.POST("/payments/webhook/:provider", func(c *gin.Context) {
+		handlers.PaymentWebhookHandler(c, db)
+	})
+	api.GET("/payments/webhook/:provider", func(c *gin.Context) {
+		handlers.PaymentWebhookHandler(c, db)
+	})
+
 	api.POST("/forgot-password", func(c *gin.Context) { handlers.ForgetPasswordHandler(c, db) })
 	api.POST("/verify-otp-for-reset", func(c *gin.Context) { handlers.VerifyOTPForResetHandler(c, db) })
 	api.POST("/reset-password", func(c *gin.Context) { handlers.ResetPasswordHandler(c, db) })
@@ -57,16 +79,60 @@ func SetupRoutes(r *gin.Engine, db *sql.DB, cld *cloudinary.Cloudinary) {
 	protected.GET("/profile", middleware.RoleMiddleWare("customer", "shipper"), func(c *gin.Context) {
 		handlers.ProfileHandler(c, db)
 	})
+	// sổ địa chỉ giao hàng
+	protected.GET("/profile/addresses", middleware.RoleMiddleWare("customer"), func(c *gin.Context) {
+		handlers.GetAddressesHandler(c, db)
+	})
+	protected.POST("/profile/addresses", middleware.RoleMiddleWare("customer"), func(c *gin.Context) {
+		handlers.CreateAddressHandler(c, db)
+	})
+	protected.PUT("/profile/addresses/:id", middleware.RoleMiddleWare("customer"), func(c *gin.Context) {
+		handlers.UpdateAddressHandler(c, db)
+	})
+	protected.DELETE("/profile/addresses/:id", middleware.RoleMiddleWare("customer"), func(c *gin.Context) {
+		handlers.DeleteAddressHandler(c, db)
+	})
 	// chỉ cho customer
-	protected.POST("/create-order", middleware.RoleMiddleWare("customer"), func(c *gin.Context) {
+	protected.POST("/create-order", middleware.RoleMiddleWare("customer"), middleware.IdempotencyMiddleware(db), func(c *gin.Context) {
 		handlers.CreateOrderHandler(c, db)
 	})
+	protected.POST("/orders/quote", middleware.RoleMiddleWare("customer"), func(c *gin.Context) {
+		handlers.QuoteOrderHandler(c, db)
+	})
+	// giỏ hàng lưu trên server
+	protected.GET("/cart", middleware.RoleMiddleWare("customer"), func(c *gin.Context) {
+		handlers.GetCartHandler(c, db)
+	})
+	protected.PUT("/cart", middleware.RoleMiddleWare("customer"), func(c *gin.Context) {
+		handlers.ReplaceCartHandler(c, db)
+	})
+	protected.DELETE("/cart", middleware.RoleMiddleWare("customer"), func(c *gin.Context) {
+		handlers.ClearCartHandler(c, db)
+	})
+	protected.POST("/cart/items", middleware.RoleMiddleWare("customer"), func(c *gin.Context) {
+		handlers.AddCartItemHandler(c, db)
+	})
+	protected.PUT("/cart/items/:product_id", middleware.RoleMiddleWare("customer"), func(c *gin.Context) {
+		handlers.UpdateCartItemHandler(c, db)
+	})
+	protected.DELETE("/cart/items/:product_id", middleware.RoleMiddleWare("customer"), func(c *gin.Context) {
+		handlers.RemoveCartItemHandler(c, db)
+	})
+	protected.POST("/cart/checkout", middleware.RoleMiddleWare("customer"), middleware.IdempotencyMiddleware(db), func(c *gin.Context) {
+		handlers.CheckoutCartHandler(c, db)
+	})
 	protected.GET("/orders", middleware.RoleMiddleWare("customer"), func(c *gin.Context) {
 		handlers.GetOrdersByUserIDHandler(c, db)
 	})
 	protected.GET("/orders/:id", middleware.RoleMiddleWare("customer", "admin", "shipper"), func(c *gin.Context) {
 		handlers.GetOrderDetailHandler(c, db)
 	})
+	protected.GET("/orders/:id/invoice", middleware.RoleMiddleWare("customer", "admin", "shipper"), func(c *gin.Context) {
+		handlers.GetOrderInvoiceHandler(c, db)
+	})
+	protected.GET("/orders/:id/timeline", middleware.RoleMiddleWare("customer", "admin", "shipper"), func(c *gin.Context) {
+		handlers.GetOrderTimelineHandler(c, db)
+	})
 	protected.GET("/orders/shipper-info/:id", middleware.RoleMiddleWare("customer"), func(c *gin.Context) {
 		handlers.GetShipperInfoByOrderIDHandler(c, db)
 	})
@@ -80,6 +146,27 @@ func SetupRoutes(r *gin.Engine, db *sql.DB, cld *cloudinary.Cloudinary) {
 	protected.DELETE("/orders/:id", middleware.RoleMiddleWare("customer"), func(c *gin.Context) {
 		handlers.CancleOrderByUserHandler(c, db)
 	})
+	protected.POST("/orders/:id/reorder", middleware.RoleMiddleWare("customer"), func(c *gin.Context) {
+		handlers.ReorderHandler(c, db)
+	})
+	// thanh toán online
+	protected.POST("/orders/:id/payments", middleware.RoleMiddleWare("customer"), middleware.IdempotencyMiddleware(db), func(c *gin.Context) {
+		handlers.CreatePaymentHandler(c, db)
+	})
+	protected.GET("/orders/:id/payments", middleware.RoleMiddleWare("customer", "admin"), func(c *gin.Context) {
+		handlers.GetOrderPaymentsHandler(c, db)
+	})
+	// chuyển khoản ngân hàng qua mã VietQR
+	protected.GET("/orders/:id/payment-qr", middleware.RoleMiddleWare("customer", "admin"), func(c *gin.Context) {
+		handlers.GetOrderPaymentQRHandler(c, db)
+	})
+	// hoàn tiền
+	protected.POST("/orders/:id/refunds", middleware.RoleMiddleWare("customer", "admin"), func(c *gin.Context) {
+		handlers.CreateRefundHandler(c, db)
+	})
+	protected.GET("/orders/:id/refunds", middleware.RoleMiddleWare("customer", "admin"), func(c *gin.Context) {
+		handlers.GetOrderRefundsHandler(c, db)
+	})
 
 	// chi cho admin
 	protected.POST("/admin/create-shipper", middleware.RoleMiddleWare("admin"), func(c *gin.Context) {
@@ -88,12 +175,66 @@ func SetupRoutes(r *gin.Engine, db *sql.DB, cld *cloudinary.Cloudinary) {
 	protected.POST("/admin/products/create-product", middleware.RoleMiddleWare("admin"), func(c *gin.Context) {
 		handlers.CreateNewProductHandler(c, db)
 	})
+	protected.PUT("/admin/products/:id/options", middleware.RoleMiddleWare("admin"), func(c *gin.Context) {
+		handlers.UpdateProductOptionsHandler(c, db)
+	})
 	protected.DELETE("/admin/products/:id", middleware.RoleMiddleWare("admin"), func(c *gin.Context) {
 		handlers.DeleteProductHandler(c, db, cld)
 	})
 	protected.POST("/admin/orders/accept-order/:id", middleware.RoleMiddleWare("admin"), func(c *gin.Context) {
 		handlers.AcceptOrderAdmin(c, db)
 	})
+	protected.POST("/admin/orders/cancel-order/:id", middleware.RoleMiddleWare("admin"), func(c *gin.Context) {
+		handlers.CancelOrderAdmin(c, db)
+	})
+	protected.GET("/admin/stores", middleware.RoleMiddleWare("admin"), func(c *gin.Context) {
+		handlers.GetAllStoresHandler(c, db)
+	})
+	protected.POST("/admin/stores", middleware.RoleMiddleWare("admin"), func(c *gin.Context) {
+		handlers.CreateStoreHandler(c, db)
+	})
+	protected.PUT("/admin/stores/:id", middleware.RoleMiddleWare("admin"), func(c *gin.Context) {
+		handlers.UpdateStoreHandler(c, db)
+	})
+	protected.DELETE("/admin/stores/:id", middleware.RoleMiddleWare("admin"), func(c *gin.Context) {
+		handlers.DeactivateStoreHandler(c, db)
+	})
+	protected.POST("/admin/stores/:id/delivery-slots", middleware.RoleMiddleWare("admin"), func(c *gin.Context) {
+		handlers.CreateDeliverySlotHandler(c, db)
+	})
+	protected.PUT("/admin/delivery-slots/:id", middleware.RoleMiddleWare("admin"), func(c *gin.Context) {
+		handlers.UpdateDeliverySlotHandler(c, db)
+	})
+	protected.DELETE("/admin/delivery-slots/:id", middleware.RoleMiddleWare("admin"), func(c *gin.Context) {
+		handlers.DeactivateDeliverySlotHandler(c, db)
+	})
+	protected.GET("/admin/coupons", middleware.RoleMiddleWare("admin"), func(c *gin.Context) {
+		handlers.GetAllCouponsHandler(c, db)
+	})
+	protected.GET("/admin/coupons/:id", middleware.RoleMiddleWare("admin"), func(c *gin.Context) {
+		handlers.GetCouponByIDHandler(c, db)
+	})
+	protected.POST("/admin/coupons", middleware.RoleMiddleWare("admin"), func(c *gin.Context) {
+		handlers.CreateCouponHandler(c, db)
+	})
+	protected.PUT("/admin/coupons/:id", middleware.RoleMiddleWare("admin"), func(c *gin.Context) {
+		handlers.UpdateCouponHandler(c, db)
+	})
+	protected.DELETE("/admin/coupons/:id", middleware.RoleMiddleWare("admin"), func(c *gin.Context) {
+		handlers.DeactivateCouponHandler(c, db)
+	})
+	protected.GET("/admin/flash-sales", middleware.RoleMiddleWare("admin"), func(c *gin.Context) {
+		handlers.GetAllFlashSalesHandler(c, db)
+	})
+	protected.POST("/admin/flash-sales", middleware.RoleMiddleWare("admin"), func(c *gin.Context) {
+		handlers.CreateFlashSaleHandler(c, db)
+	})
+	protected.PUT("/admin/flash-sales/:id", middleware.RoleMiddleWare("admin"), func(c *gin.Context) {
+		handlers.UpdateFlashSaleHandler(c, db)
+	})
+	protected.DELETE("/admin/flash-sales/:id", middleware.RoleMiddleWare("admin"), func(c *gin.Context) {
+		handlers.DeleteFlashSaleHandler(c, db)
+	})
 	protected.GET("/admin/customers", middleware.RoleMiddleWare("admin"), func(c *gin.Context) {
 		handlers.GetAllCustomersHandler(c, db)
 	})
@@ -118,9 +259,30 @@ func SetupRoutes(r *gin.Engine, db *sql.DB, cld *cloudinary.Cloudinary) {
 	protected.GET("/admin/orders", middleware.RoleMiddleWare("admin"), func(c *gin.Context) {
 		handlers.GetOrdersByAdminHandler(c, db)
 	})
+	protected.GET("/admin/exports/orders", middleware.RoleMiddleWare("admin"), func(c *gin.Context) {
+		handlers.ExportOrdersHandler(c, db)
+	})
+	protected.GET("/admin/exports/customers", middleware.RoleMiddleWare("admin"), func(c *gin.Context) {
+		handlers.ExportCustomersHandler(c, db)
+	})
+	protected.POST("/admin/payments/bank-transfers/reconcile", middleware.RoleMiddleWare("admin"), func(c *gin.Context) {
+		handlers.ReconcileBankTransfersHandler(c, db)
+	})
+	protected.GET("/admin/refunds", middleware.RoleMiddleWare("admin"), func(c *gin.Context) {
+		handlers.GetRefundsHandler(c, db)
+	})
+	protected.GET("/admin/refunds/:id", middleware.RoleMiddleWare("admin"), func(c *gin.Context) {
+		handlers.GetRefundByIDHandler(c, db)
+	})
+	protected.POST("/admin/refunds/:id/approve", middleware.RoleMiddleWare("admin"), func(c *gin.Context) {
+		handlers.ApproveRefundHandler(c, db)
+	})
+	protected.POST("/admin/refunds/:id/reject", middleware.RoleMiddleWare("admin"), func(c *gin.Context) {
+		handlers.RejectRefundHandler(c, db)
+	})
 	// chi cho shipper
 	protected.POST("/shipper/receive-order", middleware.RoleMiddleWare("shipper"), func(c *gin.Context) {
-		handlers.ReceiveOrderByShipperHandler(c, db, Hub)
+		handlers.ReceiveOrderByShipperHandler(c, db, hub)
 	})
 	protected.POST("/shipper/update-order", middleware.RoleMiddleWare("shipper"), func(c *gin.Context) {
 		handlers.UpdateOrderShipper(c, db)
@@ -131,9 +293,25 @@ func SetupRoutes(r *gin.Engine, db *sql.DB, cld *cloudinary.Cloudinary) {
 	protected.GET("/shipper/orders/received-orders", middleware.RoleMiddleWare("shipper"), func(c *gin.Context) {
 		handlers.GetReceivedOrdersByShipperHandler(c, db)
 	})
+	// tiền mặt COD shipper đang giữ
+	protected.GET("/shipper/cod", middleware.RoleMiddleWare("shipper"), func(c *gin.Context) {
+		handlers.GetMyCODHandler(c, db)
+	})
+	protected.GET("/admin/shippers/cod", middleware.RoleMiddleWare("admin"), func(c *gin.Context) {
+		handlers.GetCODBalancesHandler(c, db)
+	})
+	protected.GET("/admin/shippers/:id/cod", middleware.RoleMiddleWare("admin"), func(c *gin.Context) {
+		handlers.GetShipperCODHandler(c, db)
+	})
+	protected.POST("/admin/shippers/:id/cod/handovers", middleware.RoleMiddleWare("admin"), func(c *gin.Context) {
+		handlers.CreateCODHandoverHandler(c, db)
+	})
 	protected.GET("/admin/orders/num-revenue", middleware.RoleMiddleWare("admin"), func(c *gin.Context) {
 		handlers.GetNumberOfOrderAndRevenueHandler(c, db)
 	})
+	protected.GET("/admin/reports/revenue", middleware.RoleMiddleWare("admin"), func(c *gin.Context) {
+		handlers.GetRevenueReportHandler(c, db)
+	})
 	protected.GET("/admin/customers/num-customer", middleware.RoleMiddleWare("admin"), func(c *gin.Context) {
 		handlers.GetNumberOfCustomerHandler(c, db)
 	})